@@ -5,11 +5,15 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+	"go.opentelemetry.io/otel/trace"
 	"log"
 	cfg "pixelPromo/config"
 )
 
-func NewConfigAWS(c *cfg.Config) *aws.Config {
+func NewConfigAWS(c *cfg.Config, registerer prometheus.Registerer, tracerProvider trace.TracerProvider) *aws.Config {
+	metrics := newCallMetrics(registerer)
 
 	if c.Env == cfg.Local {
 
@@ -25,6 +29,8 @@ func NewConfigAWS(c *cfg.Config) *aws.Config {
 			log.Fatalf("unable to load SDK config, %v", err)
 		}
 
+		awsConfig.APIOptions = append(awsConfig.APIOptions, metrics.addMiddleware)
+		otelaws.AppendMiddlewares(&awsConfig.APIOptions, otelaws.WithTracerProvider(tracerProvider))
 		return &awsConfig
 	}
 
@@ -35,5 +41,7 @@ func NewConfigAWS(c *cfg.Config) *aws.Config {
 		log.Fatalf("unable to load SDK config, %v", err)
 	}
 
+	awsConfig.APIOptions = append(awsConfig.APIOptions, metrics.addMiddleware)
+	otelaws.AppendMiddlewares(&awsConfig.APIOptions, otelaws.WithTracerProvider(tracerProvider))
 	return &awsConfig
 }
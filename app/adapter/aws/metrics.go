@@ -0,0 +1,82 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"reflect"
+	"time"
+)
+
+type callMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+func newCallMetrics(registerer prometheus.Registerer) *callMetrics {
+	m := &callMetrics{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "pixelpromo",
+			Name:      "aws_call_duration_seconds",
+			Help:      "AWS SDK call latency, including retries, by service, operation and table or bucket.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "operation", "resource"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pixelpromo",
+			Name:      "aws_call_errors_total",
+			Help:      "AWS SDK calls that returned an error, by service, operation, table or bucket and error code.",
+		}, []string{"service", "operation", "resource", "code"}),
+	}
+
+	registerer.MustRegister(m.duration, m.errors)
+	return m
+}
+
+// addMiddleware runs after the SDK registered the service metadata of the
+// operation, so the service id and operation name are already in the context.
+func (m *callMetrics) addMiddleware(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("PixelPromoCallMetrics",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			start := time.Now()
+			out, metadata, err := next.HandleInitialize(ctx, in)
+
+			service := awsmiddleware.GetServiceID(ctx)
+			operation := awsmiddleware.GetOperationName(ctx)
+			resource := callResource(in.Parameters)
+
+			m.duration.WithLabelValues(service, operation, resource).Observe(time.Since(start).Seconds())
+			if err != nil {
+				m.errors.WithLabelValues(service, operation, resource, errorCode(err)).Inc()
+			}
+			return out, metadata, err
+		}), middleware.After)
+}
+
+func callResource(params interface{}) string {
+	value := reflect.Indirect(reflect.ValueOf(params))
+	if value.Kind() != reflect.Struct {
+		return ""
+	}
+
+	for _, name := range []string{"TableName", "Bucket"} {
+		field := value.FieldByName(name)
+		if field.IsValid() && field.Kind() == reflect.Pointer && !field.IsNil() && field.Elem().Kind() == reflect.String {
+			return field.Elem().String()
+		}
+	}
+	return ""
+}
+
+func errorCode(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return "Canceled"
+	}
+	return "ClientError"
+}
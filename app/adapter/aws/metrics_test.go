@@ -0,0 +1,57 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"testing"
+)
+
+func TestCallResource(t *testing.T) {
+	tests := []struct {
+		name   string
+		params interface{}
+		want   string
+	}{
+		{name: "dynamodb table", params: &dynamodb.GetItemInput{TableName: aws.String("pp-user-catalog")}, want: "pp-user-catalog"},
+		{name: "s3 bucket", params: &s3.PutObjectInput{Bucket: aws.String("pp-user-imgs")}, want: "pp-user-imgs"},
+		{name: "missing table name", params: &dynamodb.GetItemInput{}, want: ""},
+		{name: "operation without resource", params: &dynamodb.ListTablesInput{}, want: ""},
+		{name: "not a struct", params: "pp-user-catalog", want: ""},
+		{name: "nil", params: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := callResource(tt.params); got != tt.want {
+				t.Fatalf("resource = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "api error", err: &smithy.GenericAPIError{Code: "ConditionalCheckFailedException"}, want: "ConditionalCheckFailedException"},
+		{name: "wrapped api error", err: fmt.Errorf("operation error: %w", &smithy.GenericAPIError{Code: "ThrottlingException"}), want: "ThrottlingException"},
+		{name: "canceled", err: fmt.Errorf("request: %w", context.Canceled), want: "Canceled"},
+		{name: "deadline", err: context.DeadlineExceeded, want: "Canceled"},
+		{name: "other", err: errors.New("dial tcp: connection refused"), want: "ClientError"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCode(tt.err); got != tt.want {
+				t.Fatalf("code = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+func (r *Controller) VerifyEmail(ctx *gin.Context) {
+	token, _ := ctx.GetQuery("token")
+
+	if len(strings.TrimSpace(token)) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err := r.handler.VerifyEmail(ctx, token)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+func (r *Controller) SendVerificationEmail(ctx *gin.Context) {
+	err := r.handler.SendVerificationEmail(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+func (r *Controller) ForgotPassword(ctx *gin.Context) {
+	var request forgotPasswordRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = r.handler.ForgotPassword(ctx, request.Email)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+func (r *Controller) ResetPassword(ctx *gin.Context) {
+	var request passwordResetRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = r.handler.ResetPassword(ctx, request.toModel())
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Password updated"})
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+func (r *Controller) GetUsers(ctx *gin.Context) {
+	page, err := pageRequest(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	users, err := r.handler.GetUsers(ctx, page)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if len(users.Items) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPageResponse(users, newUserResponse))
+}
+
+func (r *Controller) UpdateUserRole(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	if len(strings.TrimSpace(id)) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var request userRoleRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = r.handler.UpdateUserRole(ctx, id, request.Role)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "User role updated"})
+}
+
+func (r *Controller) DeleteComment(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	if len(strings.TrimSpace(id)) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err := r.handler.DeleteComment(ctx, id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Comment deleted"})
+}
+
+func (r *Controller) CreateCategory(ctx *gin.Context) {
+	var request categoryRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	category := request.toModel()
+	err = r.handler.CreateCategory(ctx, category)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newCategoryResponse(category))
+}
+
+func (r *Controller) DeleteCategory(ctx *gin.Context) {
+	name := ctx.Param("name")
+
+	if len(strings.TrimSpace(name)) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err := r.handler.DeleteCategory(ctx, name)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Category deleted"})
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"testing"
+)
+
+// usersHandler serves a single page and records what was asked for
+type usersHandler struct {
+	port.Handler
+	page      *model.Page[model.User]
+	requested *model.PageRequest
+}
+
+func (h *usersHandler) GetUsers(_ context.Context, page *model.PageRequest) (*model.Page[model.User], error) {
+	h.requested = page
+	return h.page, nil
+}
+
+func TestAdminGetUsers(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		page       *model.Page[model.User]
+		status     int
+		limit      int32
+		cursor     string
+		nextCursor string
+	}{
+		{
+			name:       "first page",
+			query:      "?limit=2",
+			page:       &model.Page[model.User]{Items: []model.User{{Id: "a"}, {Id: "b"}}, NextCursor: "next"},
+			status:     http.StatusOK,
+			limit:      2,
+			nextCursor: "next",
+		},
+		{
+			name:   "last page",
+			query:  "?limit=2&cursor=next",
+			page:   &model.Page[model.User]{Items: []model.User{{Id: "c"}}},
+			status: http.StatusOK,
+			limit:  2,
+			cursor: "next",
+		},
+		{name: "empty", page: &model.Page[model.User]{}, status: http.StatusNoContent},
+		{name: "invalid limit", query: "?limit=none", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &usersHandler{page: tt.page}
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.GET("/admin/users", (&Controller{handler: handler}).GetUsers)
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/users"+tt.query, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if handler.requested.Limit != tt.limit || handler.requested.Cursor != tt.cursor {
+				t.Fatalf("requested = %+v", handler.requested)
+			}
+
+			var body pageResponse[userResponse]
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Items) != len(tt.page.Items) || body.NextCursor != tt.nextCursor {
+				t.Fatalf("body = %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+func (r *Controller) CreateApiKey(ctx *gin.Context) {
+	var request apiKeyRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	apiKey, err := r.handler.CreateApiKey(ctx, request.toModel())
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, newCreatedApiKeyResponse(apiKey))
+}
+
+func (r *Controller) GetApiKeys(ctx *gin.Context) {
+	apiKeys, err := r.handler.GetApiKeys(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if len(apiKeys) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mapSlice(apiKeys, newApiKeyResponse))
+}
+
+func (r *Controller) RevokeApiKey(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	if len(strings.TrimSpace(id)) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err := r.handler.RevokeApiKey(ctx, id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
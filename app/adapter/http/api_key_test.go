@@ -0,0 +1,85 @@
+package http
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"testing"
+)
+
+// apiKeyHandler accepts the keys it knows and leaves every other handler
+// method to the nil embedded interface.
+type apiKeyHandler struct {
+	port.Handler
+	keys map[string]*model.Actor
+}
+
+func (h apiKeyHandler) AuthenticateApiKey(_ context.Context, key string) (*model.Actor, error) {
+	actor, ok := h.keys[key]
+	if !ok {
+		return nil, errs.Unauthenticated("invalid_api_key", "invalid api key")
+	}
+	return actor, nil
+}
+
+func TestApiKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := &router{
+		controller: &Controller{handler: apiKeyHandler{keys: map[string]*model.Actor{
+			"pp_read_secret":  {UserId: "user-1", Role: model.RoleUser, ApiKeyId: "read", Scopes: []model.Scope{model.ScopeReadPromotions}},
+			"pp_write_secret": {UserId: "user-1", Role: model.RoleUser, ApiKeyId: "write", Scopes: []model.Scope{model.ScopeWritePromotions, model.ScopeInteract}},
+		}}},
+		keys: newTestKeys(t).keySet(t, "hs"),
+		log:  nopLogger{},
+	}
+
+	engine := gin.New()
+	engine.ContextWithFallback = true
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	engine.GET("/promotions/:id", r.authMiddleware(), ok)
+	engine.POST("/promotions", r.authMiddleware(), ok)
+	engine.POST("/interactions", r.authMiddleware(), ok)
+	engine.GET("/api-keys", r.authMiddleware(), ok)
+	engine.GET("/admin/users", r.authMiddleware(), policyMiddleware(model.RoleModerator, model.RoleAdmin), ok)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+		status int
+	}{
+		{name: "read scope reads", method: http.MethodGet, path: "/promotions/1", key: "pp_read_secret", status: http.StatusOK},
+		{name: "read scope cannot write", method: http.MethodPost, path: "/promotions", key: "pp_read_secret", status: http.StatusForbidden},
+		{name: "write scope writes", method: http.MethodPost, path: "/promotions", key: "pp_write_secret", status: http.StatusOK},
+		{name: "write scope cannot read", method: http.MethodGet, path: "/promotions/1", key: "pp_write_secret", status: http.StatusForbidden},
+		{name: "interaction scope", method: http.MethodPost, path: "/interactions", key: "pp_write_secret", status: http.StatusOK},
+		{name: "keys cannot manage keys", method: http.MethodGet, path: "/api-keys", key: "pp_write_secret", status: http.StatusForbidden},
+		{name: "keys never reach admin routes", method: http.MethodGet, path: "/admin/users", key: "pp_read_secret", status: http.StatusForbidden},
+		{name: "unknown key", method: http.MethodGet, path: "/promotions/1", key: "pp_unknown_secret", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		for _, header := range []string{"X-API-Key", "Authorization"} {
+			t.Run(tt.name+" in "+header, func(t *testing.T) {
+				request := httptest.NewRequest(tt.method, tt.path, nil)
+				if header == "Authorization" {
+					request.Header.Set(header, "Bearer "+tt.key)
+				} else {
+					request.Header.Set(header, tt.key)
+				}
+				response := httptest.NewRecorder()
+				engine.ServeHTTP(response, request)
+
+				if response.Code != tt.status {
+					t.Fatalf("status = %d, want %d", response.Code, tt.status)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"pixelPromo/domain/errs"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var requestValidator = newRequestValidator()
+
+func newRequestValidator() *validator.Validate {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return validate
+}
+
+// bindJSON decodes the body into a request DTO, rejecting unknown fields and
+// trailing data, and then checks the validate tags declared on the DTO.
+func bindJSON(ctx *gin.Context, request any) error {
+	decoder := json.NewDecoder(ctx.Request.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(request); err != nil {
+		return bindError(err)
+	}
+	if decoder.More() {
+		return errs.Invalid("malformed_body", "request body must hold a single JSON value")
+	}
+
+	if err := requestValidator.Struct(request); err != nil {
+		return validationError(err)
+	}
+	return nil
+}
+
+func validationError(err error) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fields := make([]errs.FieldError, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, fieldError(fieldErr))
+	}
+	return errs.Validation("request body is invalid", fields...)
+}
+
+func fieldError(fieldErr validator.FieldError) errs.FieldError {
+	// Namespace starts with the DTO type name, which is not part of the contract.
+	_, field, _ := strings.Cut(fieldErr.Namespace(), ".")
+	param := fieldErr.Param()
+	isText := fieldErr.Kind() == reflect.String
+	isList := fieldErr.Kind() == reflect.Slice
+
+	switch fieldErr.Tag() {
+	case "required", "required_if":
+		return errs.Required(field)
+	case "max":
+		if isText {
+			return errs.Field(field, "too_long", fmt.Sprintf("%s must have at most %s characters", field, param))
+		}
+		if isList {
+			return errs.Field(field, "too_many", fmt.Sprintf("%s must have at most %s items", field, param))
+		}
+		return errs.Field(field, "out_of_range", fmt.Sprintf("%s must be at most %s", field, param))
+	case "min":
+		if isText {
+			return errs.Field(field, "too_short", fmt.Sprintf("%s must have at least %s characters", field, param))
+		}
+		if isList {
+			return errs.Field(field, "too_few", fmt.Sprintf("%s must have at least %s items", field, param))
+		}
+		return errs.Field(field, "out_of_range", fmt.Sprintf("%s must be at least %s", field, param))
+	case "gt":
+		return errs.Field(field, "out_of_range", fmt.Sprintf("%s must be greater than %s", field, param))
+	case "gte":
+		return errs.Field(field, "out_of_range", fmt.Sprintf("%s must be greater than or equal to %s", field, param))
+	case "lte":
+		return errs.Field(field, "out_of_range", fmt.Sprintf("%s must be less than or equal to %s", field, param))
+	case "ltefield":
+		return errs.Field(field, "out_of_range", fmt.Sprintf("%s must be less than or equal to %s", field, jsonFieldName(param)))
+	case "email":
+		return errs.Field(field, "invalid_format", fmt.Sprintf("%s must be a valid email", field))
+	case "http_url":
+		return errs.Field(field, "invalid_format", fmt.Sprintf("%s must be an http or https URL", field))
+	case "oneof":
+		return errs.Field(field, "invalid_value", fmt.Sprintf("%s must be one of %s", field, strings.Join(strings.Fields(param), ", ")))
+	case "unique":
+		return errs.Field(field, "duplicated", fmt.Sprintf("%s must not repeat values", field))
+	default:
+		return errs.Field(field, "invalid", fmt.Sprintf("%s is invalid", field))
+	}
+}
+
+func jsonFieldName(field string) string {
+	if field == "" {
+		return field
+	}
+	return strings.ToLower(field[:1]) + field[1:]
+}
+
+// bodyLimitMiddleware caps the request body at max-body-bytes, or at
+// max-upload-bytes on image uploads, answering 413 before reading when the
+// declared length is already too large.
+func (r *router) bodyLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := r.server.maxBodyBytes
+		if uploadRoutes[c.Request.Method+" "+routePath(c)] {
+			limit = r.server.maxUploadBytes
+		}
+
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		if c.Request.ContentLength > limit {
+			abortWithError(c, bodyTooLarge(limit))
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+var uploadRoutes = map[string]bool{
+	"POST /users/picture/:id":    true,
+	"POST /promotions/image/:id": true,
+}
+
+func bodyTooLarge(limit int64) error {
+	return errs.TooLarge("body_too_large", fmt.Sprintf("request body must have at most %s bytes", strconv.FormatInt(limit, 10)))
+}
+
+func formFileError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return bodyTooLarge(maxBytesErr.Limit)
+	}
+	return errs.Validation("image is invalid", errs.Required("image"))
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/errs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const validPromotionBody = `{"title":"Console","originalPrice":100,"discountedPrice":80,"platform":"store","link":"https://store.com/console","categories":["games"]}`
+
+func TestBindJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		code   string
+		fields []string
+	}{
+		{name: "valid", body: validPromotionBody},
+		{name: "empty body", body: "", code: "empty_body"},
+		{name: "trailing data", body: validPromotionBody + `{}`, code: "malformed_body"},
+		{name: "unknown field", body: `{"title":"Console","userId":"user-2"}`, code: "validation_failed", fields: []string{"userId:unknown_field"}},
+		{name: "wrong type", body: `{"title":1}`, code: "validation_failed", fields: []string{"title:invalid_type"}},
+		{
+			name:   "missing fields",
+			body:   `{"originalPrice":100}`,
+			code:   "validation_failed",
+			fields: []string{"title:required", "platform:required", "link:required"},
+		},
+		{
+			name:   "discount above price",
+			body:   `{"title":"Console","originalPrice":100,"discountedPrice":120,"platform":"store","link":"https://store.com/console"}`,
+			code:   "validation_failed",
+			fields: []string{"discountedPrice:out_of_range"},
+		},
+		{
+			name:   "invalid link and long title",
+			body:   `{"title":"` + strings.Repeat("a", 121) + `","originalPrice":100,"platform":"store","link":"ftp://store.com"}`,
+			code:   "validation_failed",
+			fields: []string{"title:too_long", "link:invalid_format"},
+		},
+		{
+			name:   "repeated categories",
+			body:   `{"title":"Console","originalPrice":100,"platform":"store","link":"https://store.com","categories":["games","games"]}`,
+			code:   "validation_failed",
+			fields: []string{"categories:duplicated"},
+		},
+		{
+			name:   "empty category",
+			body:   `{"title":"Console","originalPrice":100,"platform":"store","link":"https://store.com","categories":[""]}`,
+			code:   "validation_failed",
+			fields: []string{"categories[0]:required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/v1/promotions", strings.NewReader(tt.body))
+
+			var request createPromotionRequest
+			err := bindJSON(ctx, &request)
+			if tt.code == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			e, ok := errs.As(err)
+			if !ok || e.Code != tt.code {
+				t.Fatalf("err = %v, want %s", err, tt.code)
+			}
+			var fields []string
+			for _, field := range e.Fields {
+				fields = append(fields, field.Field+":"+field.Code)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Fatalf("fields = %v, want %v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestInteractionCommentRequiredIf(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "like without comment", body: `{"promotionId":"promo-1","interactionType":"like"}`},
+		{name: "comment with text", body: `{"promotionId":"promo-1","interactionType":"comment","comment":"nice"}`},
+		{name: "comment without text", body: `{"promotionId":"promo-1","interactionType":"comment"}`, wantErr: true},
+		{name: "create is not public", body: `{"promotionId":"promo-1","interactionType":"create"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/v1/interactions", strings.NewReader(tt.body))
+
+			var request interactionRequest
+			if err := bindJSON(ctx, &request); (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		// chunked hides the length, so the limit is only hit while reading
+		chunked bool
+		status  int
+	}{
+		{name: "small body", path: "/v1/promotions", body: `{"title":"a"}`, status: http.StatusOK},
+		{name: "declared length too large", path: "/v1/promotions", body: strings.Repeat("a", 33), status: http.StatusRequestEntityTooLarge},
+		{name: "chunked body too large", path: "/v1/promotions", body: `{"title":"` + strings.Repeat("a", 33) + `"}`, chunked: true, status: http.StatusRequestEntityTooLarge},
+		{name: "upload gets the larger limit", path: "/v1/promotions/image/1", body: strings.Repeat("a", 33), status: http.StatusOK},
+		{name: "upload over its limit", path: "/v1/promotions/image/1", body: strings.Repeat("a", 65), status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := &router{server: serverConfig{maxBodyBytes: 32, maxUploadBytes: 64}}
+			engine := gin.New()
+			group := engine.Group("/v1", r.versionMiddleware(apiVersion{prefix: "/v1"}), r.bodyLimitMiddleware())
+			group.POST("/promotions", func(ctx *gin.Context) {
+				var request map[string]any
+				if err := ctx.ShouldBindJSON(&request); err != nil {
+					writeError(ctx, bindError(err))
+					return
+				}
+				ctx.Status(http.StatusOK)
+			})
+			group.POST("/promotions/image/:id", func(ctx *gin.Context) {
+				ctx.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
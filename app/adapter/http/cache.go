@@ -0,0 +1,154 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"pixelPromo/config"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cachePolicy struct {
+	Route        string `mapstructure:"route"`
+	CacheControl string `mapstructure:"cache-control"`
+}
+
+func newCachePolicies(cfg *config.Config) map[string]string {
+	var policies []cachePolicy
+	if err := cfg.Viper.UnmarshalKey("service.http.cache.policies", &policies); err != nil {
+		panic(err)
+	}
+
+	cacheControl := make(map[string]string, len(policies))
+	for _, policy := range policies {
+		method, path, found := strings.Cut(policy.Route, " ")
+		if !found || policy.CacheControl == "" {
+			panic(fmt.Errorf("service.http.cache.policies: route [%s] must be \"METHOD /path\" with a cache-control", policy.Route))
+		}
+		cacheControl[strings.ToUpper(method)+" "+path] = policy.CacheControl
+	}
+	return cacheControl
+}
+
+// cacheMiddleware buffers successful GET responses to give them a strong ETag
+// hashed from the body, answers conditional requests with 304 and applies the
+// Cache-Control policy configured for the route.
+func (r *router) cacheMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		header := c.Writer.Header()
+		if writer.Status() != http.StatusOK {
+			writer.flush()
+			return
+		}
+
+		if cacheControl, ok := r.cachePolicies[c.Request.Method+" "+routePath(c)]; ok && header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", cacheControl)
+		}
+
+		etag := header.Get("ETag")
+		if etag == "" {
+			// Mirrors compressWriter: small or already encoded bodies are sent as is.
+			encoding := c.GetString(contentEncodingKey)
+			if writer.body.Len() < r.compression.minSize || header.Get("Content-Encoding") != "" {
+				encoding = ""
+			}
+			etag = strongETag(writer.body.Bytes(), encoding)
+			header.Set("ETag", etag)
+		}
+
+		if notModified(c.Request, etag, header.Get("Last-Modified")) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			writer.ResponseWriter.WriteHeader(http.StatusNotModified)
+			writer.ResponseWriter.WriteHeaderNow()
+			return
+		}
+
+		header.Set("Content-Length", strconv.Itoa(writer.body.Len()))
+		writer.flush()
+	}
+}
+
+// strongETag hashes the uncompressed body; the negotiated content coding is part
+// of the tag because each coding is a different representation.
+func strongETag(body []byte, encoding string) string {
+	sum := sha256.Sum256(body)
+	tag := hex.EncodeToString(sum[:16])
+	if encoding != "" {
+		tag += "-" + encoding
+	}
+	return `"` + tag + `"`
+}
+
+// notModified follows RFC 9110: If-None-Match wins, and If-Modified-Since is only
+// evaluated when the request has no If-None-Match.
+func notModified(request *http.Request, etag string, lastModified string) bool {
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ifModifiedSince := request.Header.Get("If-Modified-Since")
+	if ifModifiedSince == "" || lastModified == "" {
+		return false
+	}
+	since, err := http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// setLastModified lets If-Modified-Since work for resources that track their
+// last write; items written before updatedAt existed get no header.
+func setLastModified(ctx *gin.Context, updatedAt time.Time) {
+	if updatedAt.IsZero() {
+		return
+	}
+	ctx.Header("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+}
+
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) flush() {
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,135 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	const etag = `"abc"`
+	lastModified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Format(http.TimeFormat)
+
+	tests := []struct {
+		name            string
+		ifNoneMatch     string
+		ifModifiedSince string
+		lastModified    string
+		want            bool
+	}{
+		{name: "no validators", lastModified: lastModified},
+		{name: "matching etag", ifNoneMatch: `"abc"`, want: true},
+		{name: "etag in a list", ifNoneMatch: `"x", "abc"`, want: true},
+		{name: "weak comparison", ifNoneMatch: `W/"abc"`, want: true},
+		{name: "wildcard", ifNoneMatch: "*", want: true},
+		{name: "other etag", ifNoneMatch: `"other"`},
+		{
+			name:            "If-None-Match wins over If-Modified-Since",
+			ifNoneMatch:     `"other"`,
+			ifModifiedSince: lastModified,
+			lastModified:    lastModified,
+		},
+		{name: "not modified since", ifModifiedSince: lastModified, lastModified: lastModified, want: true},
+		{
+			name:            "modified after",
+			ifModifiedSince: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Format(http.TimeFormat),
+			lastModified:    lastModified,
+		},
+		{name: "resource without Last-Modified", ifModifiedSince: lastModified},
+		{name: "invalid date", ifModifiedSince: "yesterday", lastModified: lastModified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			if tt.ifModifiedSince != "" {
+				request.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+
+			if got := notModified(request, etag, tt.lastModified); got != tt.want {
+				t.Fatalf("notModified = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrongETag(t *testing.T) {
+	plain := strongETag([]byte("body"), "")
+	if plain != strongETag([]byte("body"), "") {
+		t.Fatal("etag is not stable")
+	}
+	if plain == strongETag([]byte("other"), "") {
+		t.Fatal("different bodies share an etag")
+	}
+	if gzip := strongETag([]byte("body"), "gzip"); gzip == plain || !strings.HasSuffix(gzip, `-gzip"`) {
+		t.Fatalf("gzip etag = %s", gzip)
+	}
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := &router{
+		cachePolicies: map[string]string{"GET /categories": "public, max-age=300"},
+		compression:   compressionConfig{minSize: 1024},
+	}
+	engine := gin.New()
+	engine.Use(r.cacheMiddleware())
+	engine.GET("/categories", func(c *gin.Context) { c.JSON(http.StatusOK, []string{"games"}) })
+	engine.GET("/missing", func(c *gin.Context) { c.JSON(http.StatusNotFound, gin.H{"code": "not_found"}) })
+	engine.POST("/categories", func(c *gin.Context) { c.JSON(http.StatusOK, []string{"games"}) })
+
+	first := httptest.NewRecorder()
+	engine.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/categories", nil))
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.String() != `["games"]` {
+		t.Fatalf("first response = %d %q etag %q", first.Code, first.Body.String(), etag)
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		ifNoneMatch  string
+		status       int
+		cacheControl string
+		hasETag      bool
+		emptyBody    bool
+	}{
+		{name: "fresh GET", method: http.MethodGet, path: "/categories", status: http.StatusOK, cacheControl: "public, max-age=300", hasETag: true},
+		{name: "revalidation", method: http.MethodGet, path: "/categories", ifNoneMatch: etag, status: http.StatusNotModified, cacheControl: "public, max-age=300", hasETag: true, emptyBody: true},
+		{name: "stale etag", method: http.MethodGet, path: "/categories", ifNoneMatch: `"stale"`, status: http.StatusOK, cacheControl: "public, max-age=300", hasETag: true},
+		{name: "errors are not cached", method: http.MethodGet, path: "/missing", ifNoneMatch: "*", status: http.StatusNotFound},
+		{name: "writes are not cached", method: http.MethodPost, path: "/categories", ifNoneMatch: etag, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			response := httptest.NewRecorder()
+			engine.ServeHTTP(response, request)
+
+			if response.Code != tt.status {
+				t.Fatalf("status = %d, want %d", response.Code, tt.status)
+			}
+			if got := response.Header().Get("Cache-Control"); got != tt.cacheControl {
+				t.Fatalf("Cache-Control = %q, want %q", got, tt.cacheControl)
+			}
+			if got := response.Header().Get("ETag"); (got != "") != tt.hasETag || (tt.hasETag && got != etag) {
+				t.Fatalf("ETag = %q", got)
+			}
+			if (response.Body.Len() == 0) != tt.emptyBody {
+				t.Fatalf("body = %q", response.Body.String())
+			}
+		})
+	}
+}
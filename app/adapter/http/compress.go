@@ -0,0 +1,136 @@
+package http
+
+import (
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"pixelPromo/config"
+	"strconv"
+	"strings"
+)
+
+const contentEncodingKey = "contentEncoding"
+
+type compressionConfig struct {
+	enabled   bool
+	minSize   int
+	gzipLevel int
+	brLevel   int
+}
+
+func newCompressionConfig(cfg *config.Config) compressionConfig {
+	return compressionConfig{
+		enabled:   cfg.Viper.GetBool("service.http.compression.enabled"),
+		minSize:   cfg.Viper.GetInt("service.http.compression.min-size"),
+		gzipLevel: cfg.Viper.GetInt("service.http.compression.gzip-level"),
+		brLevel:   cfg.Viper.GetInt("service.http.compression.br-level"),
+	}
+}
+
+// compressionMiddleware negotiates br or gzip from Accept-Encoding and compresses
+// the body once the handler starts writing, unless it is already encoded, too
+// small to be worth it or not allowed to have a body.
+func (r *router) compressionMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if !r.compression.enabled || encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		c.Set(contentEncodingKey, encoding)
+		writer := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, config: r.compression}
+		c.Writer = writer
+		defer func() {
+			writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// negotiateEncoding prefers br over gzip and honours q=0 exclusions.
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = quality > 0
+	}
+
+	switch {
+	case accepted["br"]:
+		return "br"
+	case accepted["gzip"]:
+		return "gzip"
+	default:
+		return ""
+	}
+}
+
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	config   compressionConfig
+	decided  bool
+	encoder  io.WriteCloser
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.decide()
+	}
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.encoder.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) decide() {
+	w.decided = true
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || !bodyAllowed(w.Status()) {
+		return
+	}
+	if length, err := strconv.Atoi(header.Get("Content-Length")); err == nil && length < w.config.minSize {
+		return
+	}
+
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", w.encoding)
+	if w.encoding == "br" {
+		w.encoder = brotli.NewWriterLevel(w.ResponseWriter, w.config.brLevel)
+		return
+	}
+
+	encoder, err := gzip.NewWriterLevel(w.ResponseWriter, w.config.gzipLevel)
+	if err != nil {
+		encoder = gzip.NewWriter(w.ResponseWriter)
+	}
+	w.encoder = encoder
+}
+
+func (w *compressWriter) close() {
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,117 @@
+package http
+
+import (
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: ""},
+		{acceptEncoding: "identity", want: ""},
+		{acceptEncoding: "gzip", want: "gzip"},
+		{acceptEncoding: "gzip, deflate, br", want: "br"},
+		{acceptEncoding: "br;q=0, gzip;q=0.5", want: "gzip"},
+		{acceptEncoding: "GZIP", want: "gzip"},
+		{acceptEncoding: "br;q=0, gzip;q=0", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.acceptEncoding); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat("promotion ", 200)
+
+	tests := []struct {
+		name           string
+		disabled       bool
+		method         string
+		acceptEncoding string
+		path           string
+		want           string
+	}{
+		{name: "gzip", acceptEncoding: "gzip", path: "/large", want: "gzip"},
+		{name: "brotli", acceptEncoding: "br, gzip", path: "/large", want: "br"},
+		{name: "not accepted", path: "/large"},
+		{name: "disabled", disabled: true, acceptEncoding: "gzip", path: "/large"},
+		{name: "below min size", acceptEncoding: "gzip", path: "/small"},
+		{name: "already encoded", acceptEncoding: "gzip", path: "/encoded", want: "identity"},
+		{name: "no content", acceptEncoding: "gzip", path: "/empty"},
+		{name: "head", method: http.MethodHead, acceptEncoding: "gzip", path: "/large"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := &router{compression: compressionConfig{enabled: !tt.disabled, minSize: 1024, gzipLevel: gzip.DefaultCompression, brLevel: brotli.DefaultCompression}}
+			engine := gin.New()
+			engine.Use(r.compressionMiddleware())
+			engine.Match([]string{http.MethodGet, http.MethodHead}, "/large", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, large)
+			})
+			engine.GET("/small", func(ctx *gin.Context) {
+				ctx.Header("Content-Length", "2")
+				ctx.String(http.StatusOK, "ok")
+			})
+			engine.GET("/encoded", func(ctx *gin.Context) {
+				ctx.Header("Content-Encoding", "identity")
+				ctx.String(http.StatusOK, large)
+			})
+			engine.GET("/empty", func(ctx *gin.Context) {
+				ctx.Status(http.StatusNoContent)
+			})
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Header().Get("Vary") != "Accept-Encoding" {
+				t.Fatalf("vary = %q", w.Header().Get("Vary"))
+			}
+			if encoding := w.Header().Get("Content-Encoding"); encoding != tt.want {
+				t.Fatalf("content encoding = %q, want %q", encoding, tt.want)
+			}
+
+			var body io.Reader = w.Body
+			switch tt.want {
+			case "gzip":
+				reader, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				body = reader
+			case "br":
+				body = brotli.NewReader(w.Body)
+			default:
+				return
+			}
+			decoded, err := io.ReadAll(body)
+			if err != nil || string(decoded) != large {
+				t.Fatalf("decoded %d bytes, err = %v", len(decoded), err)
+			}
+			if length := w.Header().Get("Content-Length"); length != "" {
+				t.Fatalf("content length = %s on a compressed body", length)
+			}
+		})
+	}
+}
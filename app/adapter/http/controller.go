@@ -2,10 +2,12 @@ package http
 
 import (
 	"bytes"
-	"errors"
-	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"net/http"
+	"net/url"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
 	"pixelPromo/domain/model"
 	"pixelPromo/domain/port"
 	"strconv"
@@ -14,14 +16,51 @@ import (
 )
 
 type Controller struct {
-	handler port.Handler
+	handler        port.Handler
+	keys           *KeySet
+	providers      map[string]port.IdentityProvider
+	accessTokenTTL time.Duration
+	oauth          oauthConfig
+	gatherer       prometheus.Gatherer
+}
+
+type oauthConfig struct {
+	redirectBaseURL    string
+	cookiePath         string
+	successRedirectURL string
+	secureCookie       bool
 }
 
 func NewController(
 	handler port.Handler,
+	keys *KeySet,
+	identityProviders []port.IdentityProvider,
+	cfg *config.Config,
+	gatherer prometheus.Gatherer,
 ) *Controller {
+	providers := make(map[string]port.IdentityProvider, len(identityProviders))
+	for _, provider := range identityProviders {
+		providers[provider.Name()] = provider
+	}
+
+	redirectBaseURL := cfg.Viper.GetString("service.oauth.redirect-base-url")
+	cookiePath := "/"
+	if base, err := url.Parse(redirectBaseURL); err == nil && base.Path != "" {
+		cookiePath = strings.TrimSuffix(base.Path, "/")
+	}
+
 	return &Controller{
-		handler: handler,
+		handler:        handler,
+		keys:           keys,
+		providers:      providers,
+		accessTokenTTL: cfg.Viper.GetDuration("service.auth.jwt.access-token-ttl"),
+		oauth: oauthConfig{
+			redirectBaseURL:    redirectBaseURL,
+			cookiePath:         cookiePath,
+			successRedirectURL: cfg.Viper.GetString("service.oauth.success-redirect-url"),
+			secureCookie:       cfg.Env != config.Local,
+		},
+		gatherer: gatherer,
 	}
 }
 
@@ -35,7 +74,7 @@ func (r *Controller) GetInteractionStatisticsByPromotionId(ctx *gin.Context) {
 
 	counters, err := r.handler.GetInteractionStatisticsByPromotionId(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
@@ -44,7 +83,7 @@ func (r *Controller) GetInteractionStatisticsByPromotionId(ctx *gin.Context) {
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, counters)
+	ctx.JSON(http.StatusOK, counters)
 	return
 }
 
@@ -58,7 +97,7 @@ func (r *Controller) GetInteractionStatisticsByUserId(ctx *gin.Context) {
 
 	counters, err := r.handler.GetInteractionStatisticsByUserId(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
@@ -67,7 +106,7 @@ func (r *Controller) GetInteractionStatisticsByUserId(ctx *gin.Context) {
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, counters)
+	ctx.JSON(http.StatusOK, counters)
 	return
 }
 
@@ -82,7 +121,7 @@ func (r *Controller) GetInteractionStatisticsByUserIdWithPromotionId(ctx *gin.Co
 
 	counters, err := r.handler.GetInteractionStatisticsByUserIdWithPromotionId(ctx, userId, promotionId)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
@@ -91,7 +130,7 @@ func (r *Controller) GetInteractionStatisticsByUserIdWithPromotionId(ctx *gin.Co
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, counters)
+	ctx.JSON(http.StatusOK, counters)
 	return
 }
 
@@ -103,72 +142,81 @@ func (r *Controller) GetCommentsByPromotionId(ctx *gin.Context) {
 		return
 	}
 
-	promotion, err := r.handler.GetCommentsByPromotionId(ctx, id)
+	page, err := pageRequest(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	if promotion == nil {
+	comments, err := r.handler.GetCommentsByPromotionId(ctx, id, page)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if len(comments.Items) == 0 {
 		ctx.Writer.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, promotion)
+	ctx.JSON(http.StatusOK, newPageResponse(comments, newInteractionResponse))
 	return
 }
 
 func (r *Controller) CreateInteraction(ctx *gin.Context) {
 
-	var interaction model.PromotionInteraction
-	err := ctx.ShouldBindJSON(&interaction)
+	var request interactionRequest
+	err := bindJSON(ctx, &request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	err = r.handler.CreateInteraction(ctx, &interaction)
+	interaction := request.toModel()
+	err = r.handler.CreateInteraction(ctx, interaction)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, interaction)
+	ctx.JSON(http.StatusOK, newInteractionResponse(interaction))
 }
 
 func (r *Controller) CreateUser(ctx *gin.Context) {
 
-	var user model.User
-	err := ctx.ShouldBindJSON(&user)
+	var request createUserRequest
+	err := bindJSON(ctx, &request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	err = r.handler.CreateUser(ctx, &user)
+	user := request.toModel()
+	err = r.handler.CreateUser(ctx, user)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusCreated, user)
+	ctx.JSON(http.StatusCreated, newUserResponse(user))
 }
 func (r *Controller) UpdateUser(ctx *gin.Context) {
 
-	var user model.User
-	err := ctx.ShouldBindJSON(&user)
+	var request updateUserRequest
+	err := bindJSON(ctx, &request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	err = r.handler.UpdateUser(ctx, &user)
+	user := request.toModel()
+	err = r.handler.UpdateUser(ctx, user)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusCreated, user)
+	ctx.JSON(http.StatusCreated, newUserResponse(user))
 }
 
 func (r *Controller) DeleteUser(ctx *gin.Context) {
@@ -181,11 +229,11 @@ func (r *Controller) DeleteUser(ctx *gin.Context) {
 
 	err := r.handler.DeleteUser(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "User deleted"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "User deleted"})
 }
 
 func (r *Controller) UpdateUserPicture(ctx *gin.Context) {
@@ -198,12 +246,12 @@ func (r *Controller) UpdateUserPicture(ctx *gin.Context) {
 
 	f, fh, err := ctx.Request.FormFile("image")
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, formFileError(err))
 		return
 	}
 
 	if fh.Size <= 0 {
-		ctx.JSON(http.StatusBadRequest, gin.Error{Err: errors.New("file length <= 0")})
+		writeError(ctx, errs.Validation("image is invalid", errs.Field("image", "empty", "image is empty")))
 		return
 	}
 
@@ -214,7 +262,7 @@ func (r *Controller) UpdateUserPicture(ctx *gin.Context) {
 
 	err = r.handler.UpdateUserPicture(ctx, id, fileBytes)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
@@ -231,110 +279,107 @@ func (r *Controller) GetUserById(ctx *gin.Context) {
 
 	user, err := r.handler.GetUserById(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
 	if user == nil {
-		ctx.Writer.WriteHeader(http.StatusNoContent)
+		writeError(ctx, errs.NotFound("user"))
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, user)
+	setLastModified(ctx, user.UpdatedAt)
+	ctx.JSON(http.StatusOK, newUserResponse(user))
 }
 
 func (r *Controller) GetUserRank(ctx *gin.Context) {
-	limitStr, _ := ctx.GetQuery("limit")
-
-	if len(strings.TrimSpace(limitStr)) == 0 {
-		ctx.Writer.WriteHeader(http.StatusNoContent)
-		return
-	}
-	limit, err := strconv.Atoi(limitStr)
+	page, err := pageRequest(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
+		return
 	}
 
-	users, err := r.handler.GetUserRank(ctx, limit)
+	users, err := r.handler.GetUserRank(ctx, page)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	if users == nil {
+	if len(users.Items) == 0 {
 		ctx.Writer.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, users)
+	ctx.JSON(http.StatusOK, newPageResponse(users, newUserResponse))
 }
 
 func (r *Controller) Login(ctx *gin.Context) {
-	var login model.Login
-	err := ctx.ShouldBindJSON(&login)
+	var request loginRequest
+	err := bindJSON(ctx, &request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	user, err := r.handler.Login(ctx, &login)
+	user, err := r.handler.Login(ctx, request.toModel(), sessionClient(ctx))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
 	if user == nil {
-		ctx.Writer.WriteHeader(http.StatusUnauthorized)
+		writeError(ctx, errs.Unauthenticated("invalid_credentials", "invalid email or password"))
 		return
 	}
 
-	expirationTime := time.Now().Add(24 * 7 * time.Hour)
-	claims := &Claims{
-		Username: login.Email,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
-	}
+	r.issueTokens(ctx, user, nil)
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
+func (r *Controller) Health(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, "ok")
+}
+
+func (r *Controller) LiveHealth(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, gin.H{"status": model.HealthUp})
+}
+
+func (r *Controller) ReadyHealth(ctx *gin.Context) {
+	report, err := r.handler.CheckReadiness(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		writeError(ctx, err)
 		return
 	}
 
-	type loginStr struct {
-		Token string      `json:"token"`
-		User  *model.User `json:"user"`
-	}
-
-	response := loginStr{
-		Token: tokenString,
-		User:  user,
+	ctx.Header("Cache-Control", "no-store")
+	if report.Status != model.HealthUp {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
 	}
-	ctx.JSON(http.StatusOK, response)
+	ctx.JSON(http.StatusOK, report)
 }
 
-func (r *Controller) Health(ctx *gin.Context) {
-	ctx.JSON(http.StatusOK, "ok")
+func (r *Controller) GetJWKS(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, r.keys.JWKS())
 }
 
 func (r *Controller) CreatePromotion(ctx *gin.Context) {
 
-	var promotion model.Promotion
-	err := ctx.ShouldBindJSON(&promotion)
+	var request createPromotionRequest
+	err := bindJSON(ctx, &request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	err = r.handler.CreatePromotion(ctx, &promotion)
+	promotion := request.toModel()
+	err = r.handler.CreatePromotion(ctx, promotion)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, promotion)
+	ctx.JSON(http.StatusOK, newPromotionResponse(promotion))
 }
 
 func (r *Controller) DeletePromotion(ctx *gin.Context) {
@@ -347,29 +392,68 @@ func (r *Controller) DeletePromotion(ctx *gin.Context) {
 
 	err := r.handler.DeletePromotion(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "Promotion deleted"})
 }
 
 func (r *Controller) UpdatePromotion(ctx *gin.Context) {
 
-	var promotion model.Promotion
-	err := ctx.ShouldBindJSON(&promotion)
+	var request updatePromotionRequest
+	err := bindJSON(ctx, &request)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	err = r.handler.UpdatePromotion(ctx, &promotion)
+	promotion := request.toModel()
+	err = r.handler.UpdatePromotion(ctx, promotion)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, promotion)
+	ctx.JSON(http.StatusOK, newPromotionResponse(promotion))
+}
+
+func (r *Controller) UpdatePromotionStatus(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var request promotionStatusRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	promotion, err := r.handler.UpdatePromotionStatus(ctx, id, request.toModel())
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPromotionResponse(promotion))
+}
+
+func (r *Controller) ResolveExpiredReports(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	var request expiredReportResolutionRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	promotion, err := r.handler.ResolveExpiredReports(ctx, id, request.Resolution)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newPromotionResponse(promotion))
 }
 
 func (r *Controller) UpdatePromotionImage(ctx *gin.Context) {
@@ -382,12 +466,12 @@ func (r *Controller) UpdatePromotionImage(ctx *gin.Context) {
 
 	f, fh, err := ctx.Request.FormFile("image")
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, formFileError(err))
 		return
 	}
 
 	if fh.Size <= 0 {
-		ctx.JSON(http.StatusBadRequest, gin.Error{Err: errors.New("file length <= 0")})
+		writeError(ctx, errs.Validation("image is invalid", errs.Field("image", "empty", "image is empty")))
 		return
 	}
 
@@ -398,7 +482,7 @@ func (r *Controller) UpdatePromotionImage(ctx *gin.Context) {
 
 	err = r.handler.UpdatePromotionImage(ctx, id, fileBytes)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
@@ -415,16 +499,17 @@ func (r *Controller) GetPromotionById(ctx *gin.Context) {
 
 	promotion, err := r.handler.GetPromotionById(ctx, id)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
 	if promotion == nil {
-		ctx.Writer.WriteHeader(http.StatusNoContent)
+		writeError(ctx, errs.NotFound("promotion"))
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, promotion)
+	setLastModified(ctx, promotion.UpdatedAt)
+	ctx.JSON(http.StatusOK, newPromotionResponse(promotion))
 	return
 }
 
@@ -436,18 +521,24 @@ func (r *Controller) GetFavoritesPromotionsByUserId(ctx *gin.Context) {
 		return
 	}
 
-	promotion, err := r.handler.GetFavoritesPromotionsByUserId(ctx, id)
+	page, err := pageRequest(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	if promotion == nil {
+	promotions, err := r.handler.GetFavoritesPromotionsByUserId(ctx, id, page)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if len(promotions.Items) == 0 {
 		ctx.Writer.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, promotion)
+	ctx.JSON(http.StatusOK, newPageResponse(promotions, newPromotionResponse))
 	return
 }
 
@@ -455,31 +546,45 @@ func (r *Controller) GetPromotions(ctx *gin.Context) {
 
 	categories, _ := ctx.GetQueryArray("category")
 	search, _ := ctx.GetQuery("search")
-	limit, _ := ctx.GetQuery("limit")
 	userId, _ := ctx.GetQuery("userId")
-	var limitInt int
-	if limit != "" {
-		limitInt, _ = strconv.Atoi(limit)
+	sort, _ := ctx.GetQuery("sort")
+
+	page, err := pageRequest(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	includeExpired := false
+	if value := ctx.Query("includeExpired"); value != "" {
+		includeExpired, err = strconv.ParseBool(value)
+		if err != nil {
+			writeError(ctx, errs.Validation("query is invalid", errs.Field("includeExpired", "invalid", "includeExpired must be true or false")))
+			return
+		}
 	}
 
 	params := &model.PromotionQuery{
-		Search:     search,
-		Categories: categories,
-		UserId:     userId,
-		Limit:      int32(limitInt),
+		Search:         search,
+		Categories:     categories,
+		UserId:         userId,
+		Sort:           model.PromotionSort(sort),
+		Limit:          page.Limit,
+		Cursor:         page.Cursor,
+		IncludeExpired: includeExpired,
 	}
 	promotions, err := r.handler.GetPromotions(ctx, params)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
-	if promotions == nil || len(promotions) == 0 {
+	if len(promotions.Items) == 0 {
 		ctx.Writer.WriteHeader(http.StatusNoContent)
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, promotions)
+	ctx.JSON(http.StatusOK, newPageResponse(promotions, newPromotionResponse))
 	return
 }
 
@@ -487,7 +592,7 @@ func (r *Controller) GetCategories(ctx *gin.Context) {
 
 	categories, err := r.handler.GetCategories(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"Err": err.Error()})
+		writeError(ctx, err)
 		return
 	}
 
@@ -496,6 +601,6 @@ func (r *Controller) GetCategories(ctx *gin.Context) {
 		return
 	}
 
-	ctx.IndentedJSON(http.StatusOK, categories)
+	ctx.JSON(http.StatusOK, mapSlice(categories, newCategoryResponse))
 	return
 }
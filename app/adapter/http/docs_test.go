@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	engine := gin.New()
+	newTestRouter(t, nil).setup(engine)
+
+	if err := checkRouteCoverage(engine.Routes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckRouteCoverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		extra   gin.RouteInfo
+		drop    string
+		problem string
+	}{
+		{name: "every route documented"},
+		{
+			name:    "undocumented route",
+			extra:   gin.RouteInfo{Method: http.MethodGet, Path: "/promotions/:id/history"},
+			problem: "undocumented route GET /promotions/{id}/history",
+		},
+		{
+			name:    "documented route not registered",
+			drop:    "DELETE /promotions/:id",
+			problem: "documented route not registered DELETE /promotions/{id}",
+		},
+	}
+
+	engine := gin.New()
+	newTestRouter(t, nil).setup(engine)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var routes gin.RoutesInfo
+			for _, route := range engine.Routes() {
+				if route.Method+" "+route.Path != tt.drop {
+					routes = append(routes, route)
+				}
+			}
+			if tt.extra.Path != "" {
+				routes = append(routes, tt.extra)
+			}
+
+			err := checkRouteCoverage(routes)
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("error = %v, want %s", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/promotions", want: "/promotions"},
+		{path: "/promotions/:id", want: "/promotions/{id}"},
+		{path: "/admin/users/:id/role", want: "/admin/users/{id}/role"},
+		{path: "/files/*filepath", want: "/files/{filepath}"},
+	}
+
+	for _, tt := range tests {
+		if got := openAPIPath(tt.path); got != tt.want {
+			t.Errorf("openAPIPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// checkRouteCoverage fails when a registered route is missing from openapi.json
+// or when the spec documents an operation that is no longer registered.
+func checkRouteCoverage(routes gin.RoutesInfo) error {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return fmt.Errorf("openapi.json: %w", err)
+	}
+
+	documented := make(map[string]bool)
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+
+	var problems []string
+	for _, route := range routes {
+		key := route.Method + " " + openAPIPath(route.Path)
+		if !documented[key] {
+			problems = append(problems, "undocumented route "+key)
+		}
+		delete(documented, key)
+	}
+	for key := range documented {
+		problems = append(problems, "documented route not registered "+key)
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi.json is out of date: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
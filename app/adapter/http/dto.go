@@ -0,0 +1,177 @@
+package http
+
+import (
+	"pixelPromo/domain/model"
+	"time"
+)
+
+// Request and response bodies of the public API. They are mapped from and to the
+// domain models in mapper.go, so storage attributes never leak into the contract.
+// Requests only carry client owned fields and declare their rules in validate tags,
+// checked by bindJSON before the handler runs.
+
+type createUserRequest struct {
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Name     string `json:"name" validate:"required,max=80"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type updateUserRequest struct {
+	Id       string `json:"id" validate:"max=64"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Name     string `json:"name" validate:"required,max=80"`
+	Password string `json:"password" validate:"omitempty,min=8,max=72"`
+}
+
+type userResponse struct {
+	Id            string     `json:"id"`
+	Email         string     `json:"email"`
+	Name          string     `json:"name"`
+	PictureUrl    string     `json:"pictureUrl"`
+	TotalScore    int        `json:"totalScore"`
+	Level         int        `json:"level"`
+	Elo           string     `json:"elo"`
+	Role          model.Role `json:"role"`
+	EmailVerified bool       `json:"emailVerified"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+type userRoleRequest struct {
+	Role model.Role `json:"role" validate:"required,oneof=user moderator admin"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email" validate:"required,max=254"`
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+type refreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required,max=512"`
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type passwordResetRequest struct {
+	Token    string `json:"token" validate:"required,max=512"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type tokenResponse struct {
+	Token        string        `json:"token"`
+	RefreshToken string        `json:"refreshToken"`
+	ExpiresAt    time.Time     `json:"expiresAt"`
+	User         *userResponse `json:"user"`
+}
+
+type sessionResponse struct {
+	Id         string    `json:"id"`
+	UserId     string    `json:"userId"`
+	Device     string    `json:"device"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+type apiKeyRequest struct {
+	Name      string        `json:"name" validate:"required,max=64"`
+	Scopes    []model.Scope `json:"scopes" validate:"required,min=1,unique,dive,oneof=promotions:read promotions:write interactions:write"`
+	ExpiresAt *time.Time    `json:"expiresAt"`
+}
+
+type apiKeyResponse struct {
+	Id         string        `json:"id"`
+	UserId     string        `json:"userId"`
+	Name       string        `json:"name"`
+	Scopes     []model.Scope `json:"scopes"`
+	Revoked    bool          `json:"revoked"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	ExpiresAt  *time.Time    `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time    `json:"lastUsedAt,omitempty"`
+}
+
+type createdApiKeyResponse struct {
+	apiKeyResponse
+	Key string `json:"key"`
+}
+
+type createPromotionRequest struct {
+	Title           string     `json:"title" validate:"required,max=120"`
+	OriginalPrice   float64    `json:"originalPrice" validate:"gt=0,lte=1000000"`
+	DiscountedPrice float64    `json:"discountedPrice" validate:"gte=0,ltefield=OriginalPrice"`
+	Platform        string     `json:"platform" validate:"required,max=40"`
+	Link            string     `json:"link" validate:"required,http_url,max=2048"`
+	Categories      []string   `json:"categories" validate:"max=10,unique,dive,required,max=40"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+}
+
+type updatePromotionRequest struct {
+	Id              string     `json:"id" validate:"required,max=64"`
+	Title           string     `json:"title" validate:"required,max=120"`
+	OriginalPrice   float64    `json:"originalPrice" validate:"gt=0,lte=1000000"`
+	DiscountedPrice float64    `json:"discountedPrice" validate:"gte=0,ltefield=OriginalPrice"`
+	Platform        string     `json:"platform" validate:"required,max=40"`
+	Link            string     `json:"link" validate:"required,http_url,max=2048"`
+	Categories      []string   `json:"categories" validate:"max=10,unique,dive,required,max=40"`
+	ExpiresAt       *time.Time `json:"expiresAt"`
+}
+
+type promotionResponse struct {
+	Id              string                `json:"id"`
+	UserId          string                `json:"userId"`
+	Title           string                `json:"title"`
+	OriginalPrice   float64               `json:"originalPrice"`
+	DiscountedPrice float64               `json:"discountedPrice"`
+	DiscountBadge   float64               `json:"discountBadge"`
+	Platform        string                `json:"platform"`
+	ImageUrl        string                `json:"imageUrl"`
+	Link            string                `json:"link"`
+	Categories      []string              `json:"categories"`
+	Popularity      int                   `json:"popularity"`
+	HotScore        float64               `json:"hotScore"`
+	Status          model.PromotionStatus `json:"status"`
+	ExpiresAt       *time.Time            `json:"expiresAt"`
+	ExpiredReports  float64               `json:"expiredReports"`
+	ProbablyExpired bool                  `json:"probablyExpired"`
+	CreatedAt       time.Time             `json:"createdAt"`
+}
+
+type promotionStatusRequest struct {
+	Status    model.PromotionStatus `json:"status" validate:"required,oneof=active expired sold_out removed"`
+	ExpiresAt *time.Time            `json:"expiresAt"`
+}
+
+type expiredReportResolutionRequest struct {
+	Resolution model.ExpiredReportResolution `json:"resolution" validate:"required,oneof=confirmed dismissed"`
+}
+
+type categoryRequest struct {
+	Name string `json:"name" validate:"required,max=40"`
+}
+
+type categoryResponse struct {
+	Name string `json:"name"`
+}
+
+type interactionRequest struct {
+	PromotionId     string                `json:"promotionId" validate:"required,max=64"`
+	Comment         string                `json:"comment" validate:"required_if=InteractionType comment,max=1000"`
+	InteractionType model.InteractionType `json:"interactionType" validate:"required,oneof=favorite like comment expired_report"`
+}
+
+type interactionResponse struct {
+	Id              string                `json:"id"`
+	PromotionId     string                `json:"promotionId"`
+	OwnerUserId     string                `json:"ownerUserId"`
+	UserId          string                `json:"userId"`
+	Comment         string                `json:"comment"`
+	InteractionType model.InteractionType `json:"interactionType"`
+	CreatedAt       time.Time             `json:"createdAt"`
+}
+
+type pageResponse[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"strings"
+	"testing"
+)
+
+type healthHandler struct {
+	port.Handler
+	report *model.HealthReport
+}
+
+func (h healthHandler) CheckReadiness(context.Context) (*model.HealthReport, error) {
+	return h.report, nil
+}
+
+func TestReadyHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *model.HealthReport
+		status int
+	}{
+		{
+			name:   "ready",
+			report: &model.HealthReport{Status: model.HealthUp, Dependencies: []model.DependencyHealth{{Name: "user", Kind: "dynamodb-table", Status: model.HealthUp}}},
+			status: http.StatusOK,
+		},
+		{
+			name: "not ready",
+			report: &model.HealthReport{Status: model.HealthDown, Dependencies: []model.DependencyHealth{
+				{Name: "promotion", Kind: "s3-bucket", Status: model.HealthDown, Reason: "not_found", Err: context.DeadlineExceeded},
+			}},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.GET("/health/ready", (&Controller{handler: healthHandler{report: tt.report}}).ReadyHealth)
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+			if w.Code != tt.status || w.Header().Get("Cache-Control") != "no-store" {
+				t.Fatalf("status = %d, headers = %v", w.Code, w.Header())
+			}
+			var body model.HealthReport
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Status != tt.report.Status {
+				t.Fatalf("body = %s", w.Body.String())
+			}
+			// raw errors only go to the logs
+			if strings.Contains(w.Body.String(), context.DeadlineExceeded.Error()) {
+				t.Fatalf("body leaks the error: %s", w.Body.String())
+			}
+		})
+	}
+}
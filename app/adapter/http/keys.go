@@ -0,0 +1,235 @@
+package http
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"os"
+	"pixelPromo/config"
+	"sort"
+)
+
+type KeySet struct {
+	issuer  string
+	signing *signingKey
+	keys    map[string]*signingKey
+}
+
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.PrivateKey
+	public  crypto.PublicKey
+}
+
+type keyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Alg            string `mapstructure:"alg"`
+	Secret         string `mapstructure:"secret"`
+	SecretEnv      string `mapstructure:"secret-env"`
+	PrivateKeyFile string `mapstructure:"private-key-file"`
+	PublicKeyFile  string `mapstructure:"public-key-file"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeySet(cfg *config.Config) *KeySet {
+	var keyConfigs []keyConfig
+	if err := cfg.Viper.UnmarshalKey("service.auth.jwt.keys", &keyConfigs); err != nil {
+		panic(err)
+	}
+
+	keySet := &KeySet{
+		issuer: cfg.Viper.GetString("service.auth.jwt.issuer"),
+		keys:   make(map[string]*signingKey, len(keyConfigs)),
+	}
+	for _, kc := range keyConfigs {
+		key, err := loadSigningKey(kc)
+		if err != nil {
+			panic(fmt.Errorf("jwt key [%s]: %w", kc.Kid, err))
+		}
+		if _, exists := keySet.keys[key.kid]; exists {
+			panic(fmt.Errorf("jwt key [%s]: duplicated kid", kc.Kid))
+		}
+		keySet.keys[key.kid] = key
+	}
+
+	signingKid := cfg.Viper.GetString("service.auth.jwt.signing-kid")
+	signing, ok := keySet.keys[signingKid]
+	if !ok {
+		panic(fmt.Errorf("jwt signing-kid [%s] not found in service.auth.jwt.keys", signingKid))
+	}
+	if signing.private == nil {
+		panic(fmt.Errorf("jwt signing-kid [%s] has no private key", signingKid))
+	}
+	keySet.signing = signing
+
+	return keySet
+}
+
+func loadSigningKey(kc keyConfig) (*signingKey, error) {
+	if kc.Kid == "" {
+		return nil, fmt.Errorf("kid is empty")
+	}
+
+	key := &signingKey{kid: kc.Kid}
+	switch kc.Alg {
+	case jwt.SigningMethodHS256.Alg():
+		secret := kc.Secret
+		if kc.SecretEnv != "" {
+			secret = os.Getenv(kc.SecretEnv)
+		}
+		if secret == "" {
+			return nil, fmt.Errorf("secret is empty")
+		}
+		key.method = jwt.SigningMethodHS256
+		key.private = []byte(secret)
+		key.public = []byte(secret)
+	case jwt.SigningMethodRS256.Alg():
+		key.method = jwt.SigningMethodRS256
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.private = private
+			key.public = &private.PublicKey
+		}
+		if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if key.public, err = jwt.ParseRSAPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		}
+	case jwt.SigningMethodEdDSA.Alg():
+		key.method = jwt.SigningMethodEdDSA
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			private, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.private = private
+			key.public = private.(ed25519.PrivateKey).Public()
+		}
+		if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			if key.public, err = jwt.ParseEdPublicKeyFromPEM(pem); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("alg [%s] not supported", kc.Alg)
+	}
+
+	if key.public == nil {
+		return nil, fmt.Errorf("no key material configured")
+	}
+	return key, nil
+}
+
+func (k *KeySet) Sign(claims *Claims) (string, error) {
+	claims.Issuer = k.issuer
+	token := jwt.NewWithClaims(k.signing.method, claims)
+	token.Header["kid"] = k.signing.kid
+	return token.SignedString(k.signing.private)
+}
+
+func (k *KeySet) Parse(tokenString string, claims *Claims) error {
+	token, err := jwt.ParseWithClaims(tokenString, claims, k.keyFunc,
+		jwt.WithValidMethods(k.methods()),
+		jwt.WithIssuer(k.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return err
+	}
+	if !token.Valid {
+		return jwt.ErrTokenUnverifiable
+	}
+	return nil
+}
+
+func (k *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown kid [%s]", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("alg [%s] does not match kid [%s]", token.Method.Alg(), kid)
+	}
+	return key.public, nil
+}
+
+func (k *KeySet) methods() []string {
+	methods := make([]string, 0, len(k.keys))
+	for _, key := range k.keys {
+		methods = append(methods, key.method.Alg())
+	}
+	return methods
+}
+
+func (k *KeySet) JWKS() JWKS {
+	kids := make([]string, 0, len(k.keys))
+	for kid := range k.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(kids))}
+	for _, kid := range kids {
+		key := k.keys[kid]
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "RSA",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks.Keys = append(jwks.Keys, JWK{
+				Kty: "OKP",
+				Kid: kid,
+				Use: "sig",
+				Alg: key.method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return jwks
+}
@@ -0,0 +1,286 @@
+package http
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/spf13/viper"
+	"os"
+	"path/filepath"
+	"pixelPromo/config"
+	"testing"
+	"time"
+)
+
+type testKeys struct {
+	dir       string
+	rsa       *rsa.PrivateKey
+	ed        ed25519.PrivateKey
+	rsaPublic []byte
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	t.Helper()
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edDer, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPublicDer, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := &testKeys{
+		dir:       dir,
+		rsa:       rsaKey,
+		ed:        edKey,
+		rsaPublic: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPublicDer}),
+	}
+	writePEM(t, filepath.Join(dir, "rs.pem"), "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	writePEM(t, filepath.Join(dir, "ed.pem"), "PRIVATE KEY", edDer)
+	if err = os.WriteFile(filepath.Join(dir, "rs.pub.pem"), keys.rsaPublic, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (k *testKeys) keySet(t *testing.T, signingKid string) *KeySet {
+	t.Helper()
+	v := viper.New()
+	v.Set("service.auth.jwt.issuer", "pixelpromo")
+	v.Set("service.auth.jwt.signing-kid", signingKid)
+	v.Set("service.auth.jwt.keys", []map[string]any{
+		{"kid": "hs", "alg": "HS256", "secret": "hs-secret"},
+		{"kid": "rs", "alg": "RS256", "private-key-file": filepath.Join(k.dir, "rs.pem")},
+		{"kid": "ed", "alg": "EdDSA", "private-key-file": filepath.Join(k.dir, "ed.pem")},
+		{"kid": "rs-verify-only", "alg": "RS256", "public-key-file": filepath.Join(k.dir, "rs.pub.pem")},
+	})
+	return NewKeySet(&config.Config{Viper: v})
+}
+
+func testClaims(issuer string, expiresAt *jwt.NumericDate) *Claims {
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    issuer,
+			ExpiresAt: expiresAt,
+		},
+	}
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, claims *Claims, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestKeySetParse(t *testing.T) {
+	keys := newTestKeys(t)
+	keySet := keys.keySet(t, "rs")
+	inAnHour := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+		valid bool
+	}{
+		{
+			name: "signing key",
+			token: func(t *testing.T) string {
+				token, err := keySet.Sign(testClaims("", inAnHour))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			valid: true,
+		},
+		{
+			name: "key rotated out of signing",
+			token: func(t *testing.T) string {
+				token, err := keys.keySet(t, "ed").Sign(testClaims("", inAnHour))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			valid: true,
+		},
+		{
+			name: "hmac key",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodHS256, "hs", testClaims("pixelpromo", inAnHour), []byte("hs-secret"))
+			},
+			valid: true,
+		},
+		{
+			name: "verify only key",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodRS256, "rs-verify-only", testClaims("pixelpromo", inAnHour), keys.rsa)
+			},
+			valid: true,
+		},
+		{
+			name: "unknown kid",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodHS256, "retired", testClaims("pixelpromo", inAnHour), []byte("hs-secret"))
+			},
+		},
+		{
+			name: "missing kid",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodHS256, "", testClaims("pixelpromo", inAnHour), []byte("hs-secret"))
+			},
+		},
+		{
+			name: "wrong issuer",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodRS256, "rs", testClaims("someone-else", inAnHour), keys.rsa)
+			},
+		},
+		{
+			name: "missing issuer",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodRS256, "rs", testClaims("", inAnHour), keys.rsa)
+			},
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				expired := jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return signWith(t, jwt.SigningMethodRS256, "rs", testClaims("pixelpromo", expired), keys.rsa)
+			},
+		},
+		{
+			name: "missing expiration",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodRS256, "rs", testClaims("pixelpromo", nil), keys.rsa)
+			},
+		},
+		{
+			name: "alg none",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodNone, "rs", testClaims("pixelpromo", inAnHour), jwt.UnsafeAllowNoneSignatureType)
+			},
+		},
+		{
+			name: "hmac signed with the rsa public key",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodHS256, "rs", testClaims("pixelpromo", inAnHour), keys.rsaPublic)
+			},
+		},
+		{
+			name: "alg that does not match the kid",
+			token: func(t *testing.T) string {
+				return signWith(t, jwt.SigningMethodEdDSA, "rs", testClaims("pixelpromo", inAnHour), keys.ed)
+			},
+		},
+		{
+			name: "tampered signature",
+			token: func(t *testing.T) string {
+				token := signWith(t, jwt.SigningMethodHS256, "hs", testClaims("pixelpromo", inAnHour), []byte("hs-secret"))
+				return token[:len(token)-2] + "xx"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := &Claims{}
+			err := keySet.Parse(tt.token(t), claims)
+			if (err == nil) != tt.valid {
+				t.Fatalf("parse error = %v, want valid %v", err, tt.valid)
+			}
+			if tt.valid && claims.Subject != "user-1" {
+				t.Fatalf("subject = %q", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestKeySetSignUsesSigningKid(t *testing.T) {
+	keys := newTestKeys(t)
+
+	for _, kid := range []string{"hs", "rs", "ed"} {
+		t.Run(kid, func(t *testing.T) {
+			signed, err := keys.keySet(t, kid).Sign(testClaims("", jwt.NewNumericDate(time.Now().Add(time.Hour))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, _, err := jwt.NewParser().ParseUnverified(signed, &Claims{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if token.Header["kid"] != kid {
+				t.Fatalf("kid = %v", token.Header["kid"])
+			}
+			if issuer, _ := token.Claims.GetIssuer(); issuer != "pixelpromo" {
+				t.Fatalf("issuer = %q", issuer)
+			}
+		})
+	}
+}
+
+func TestKeySetJWKS(t *testing.T) {
+	jwks := newTestKeys(t).keySet(t, "rs").JWKS()
+
+	want := map[string]string{"ed": "OKP", "rs": "RSA", "rs-verify-only": "RSA"}
+	if len(jwks.Keys) != len(want) {
+		t.Fatalf("jwks has %d keys, want %d", len(jwks.Keys), len(want))
+	}
+	for _, key := range jwks.Keys {
+		if want[key.Kid] != key.Kty || key.Use != "sig" {
+			t.Fatalf("unexpected jwk %+v", key)
+		}
+	}
+}
+
+func TestLoadSigningKeyRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		kc   keyConfig
+	}{
+		{name: "empty kid", kc: keyConfig{Alg: "HS256", Secret: "secret"}},
+		{name: "unsupported alg", kc: keyConfig{Kid: "k", Alg: "HS512", Secret: "secret"}},
+		{name: "empty secret", kc: keyConfig{Kid: "k", Alg: "HS256"}},
+		{name: "secret env not set", kc: keyConfig{Kid: "k", Alg: "HS256", SecretEnv: "PP_TEST_UNSET_SECRET"}},
+		{name: "no key material", kc: keyConfig{Kid: "k", Alg: "RS256"}},
+		{name: "missing key file", kc: keyConfig{Kid: "k", Alg: "EdDSA", PrivateKeyFile: "/nonexistent.pem"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadSigningKey(tt.kc); err == nil {
+				t.Fatal("key loaded")
+			}
+		})
+	}
+}
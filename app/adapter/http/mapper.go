@@ -0,0 +1,181 @@
+package http
+
+import (
+	"pixelPromo/domain/model"
+)
+
+func (u createUserRequest) toModel() *model.User {
+	return &model.User{
+		Email:    u.Email,
+		Name:     u.Name,
+		Password: u.Password,
+	}
+}
+
+func (u updateUserRequest) toModel() *model.User {
+	return &model.User{
+		Id:       u.Id,
+		Email:    u.Email,
+		Name:     u.Name,
+		Password: u.Password,
+	}
+}
+
+func newUserResponse(user *model.User) *userResponse {
+	if user == nil {
+		return nil
+	}
+	return &userResponse{
+		Id:            user.Id,
+		Email:         user.Email,
+		Name:          user.Name,
+		PictureUrl:    user.PictureUrl,
+		TotalScore:    user.TotalScore,
+		Level:         user.Level,
+		Elo:           user.Elo,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		CreatedAt:     user.CreatedAt,
+	}
+}
+
+func (l loginRequest) toModel() *model.Login {
+	return &model.Login{Email: l.Email, Password: l.Password}
+}
+
+func (p passwordResetRequest) toModel() *model.PasswordReset {
+	return &model.PasswordReset{Token: p.Token, Password: p.Password}
+}
+
+func newSessionResponse(session *model.Session) *sessionResponse {
+	return &sessionResponse{
+		Id:         session.FamilyId,
+		UserId:     session.UserId,
+		Device:     session.Device,
+		Ip:         session.Ip,
+		CreatedAt:  session.CreatedAt,
+		LastUsedAt: session.LastUsedAt,
+		ExpiresAt:  session.ExpiresAt,
+	}
+}
+
+func (a apiKeyRequest) toModel() *model.ApiKeyRequest {
+	return &model.ApiKeyRequest{Name: a.Name, Scopes: a.Scopes, ExpiresAt: a.ExpiresAt}
+}
+
+func newApiKeyResponse(apiKey *model.ApiKey) *apiKeyResponse {
+	return &apiKeyResponse{
+		Id:         apiKey.Id,
+		UserId:     apiKey.UserId,
+		Name:       apiKey.Name,
+		Scopes:     apiKey.Scopes,
+		Revoked:    apiKey.Revoked,
+		CreatedAt:  apiKey.CreatedAt,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+	}
+}
+
+func newCreatedApiKeyResponse(apiKey *model.CreatedApiKey) *createdApiKeyResponse {
+	return &createdApiKeyResponse{
+		apiKeyResponse: *newApiKeyResponse(&apiKey.ApiKey),
+		Key:            apiKey.Key,
+	}
+}
+
+func (p createPromotionRequest) toModel() *model.Promotion {
+	return &model.Promotion{
+		Title:           p.Title,
+		OriginalPrice:   p.OriginalPrice,
+		DiscountedPrice: p.DiscountedPrice,
+		Platform:        p.Platform,
+		Link:            p.Link,
+		Categories:      p.Categories,
+		ExpiresAt:       p.ExpiresAt,
+	}
+}
+
+func (p updatePromotionRequest) toModel() *model.Promotion {
+	return &model.Promotion{
+		Id:              p.Id,
+		Title:           p.Title,
+		OriginalPrice:   p.OriginalPrice,
+		DiscountedPrice: p.DiscountedPrice,
+		Platform:        p.Platform,
+		Link:            p.Link,
+		Categories:      p.Categories,
+		ExpiresAt:       p.ExpiresAt,
+	}
+}
+
+func newPromotionResponse(promotion *model.Promotion) *promotionResponse {
+	return &promotionResponse{
+		Id:              promotion.Id,
+		UserId:          promotion.UserId,
+		Title:           promotion.Title,
+		OriginalPrice:   promotion.OriginalPrice,
+		DiscountedPrice: promotion.DiscountedPrice,
+		DiscountBadge:   promotion.DiscountBadge,
+		Platform:        promotion.Platform,
+		ImageUrl:        promotion.ImageUrl,
+		Link:            promotion.Link,
+		Categories:      promotion.Categories,
+		Popularity:      promotion.Popularity,
+		HotScore:        promotion.HotScore,
+		Status:          promotion.CurrentStatus(),
+		ExpiresAt:       promotion.ExpiresAt,
+		ExpiredReports:  promotion.ExpiredReports,
+		ProbablyExpired: promotion.IsProbablyExpired(),
+		CreatedAt:       promotion.CreatedAt,
+	}
+}
+
+func (p promotionStatusRequest) toModel() *model.PromotionStatusChange {
+	return &model.PromotionStatusChange{
+		Status:    p.Status,
+		ExpiresAt: p.ExpiresAt,
+	}
+}
+
+func (c categoryRequest) toModel() *model.Category {
+	return &model.Category{Name: c.Name}
+}
+
+func newCategoryResponse(category *model.Category) *categoryResponse {
+	return &categoryResponse{Name: category.Name}
+}
+
+func (i interactionRequest) toModel() *model.PromotionInteraction {
+	return &model.PromotionInteraction{
+		PromotionId:     i.PromotionId,
+		Comment:         i.Comment,
+		InteractionType: i.InteractionType,
+	}
+}
+
+func newInteractionResponse(interaction *model.PromotionInteraction) *interactionResponse {
+	return &interactionResponse{
+		Id:              interaction.Id,
+		PromotionId:     interaction.PromotionId,
+		OwnerUserId:     interaction.OwnerUserId,
+		UserId:          interaction.UserId,
+		Comment:         interaction.Comment,
+		InteractionType: interaction.InteractionType,
+		CreatedAt:       interaction.CreatedAt,
+	}
+}
+
+func mapSlice[M any, R any](items []M, mapper func(*M) *R) []R {
+	responses := make([]R, 0, len(items))
+	for i := range items {
+		responses = append(responses, *mapper(&items[i]))
+	}
+	return responses
+}
+
+func newPageResponse[M any, R any](page *model.Page[M], mapper func(*M) *R) *pageResponse[R] {
+	return &pageResponse[R]{
+		Items:      mapSlice(page.Items, mapper),
+		NextCursor: page.NextCursor,
+	}
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net"
+	"os"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newHTTPMetrics(registerer prometheus.Registerer) *httpMetrics {
+	m := &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "pixelpromo",
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "pixelpromo",
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+
+	registerer.MustRegister(m.requests, m.duration)
+	return m
+}
+
+// metricsAccess decides who may scrape /metrics, which exposes route names and
+// traffic volumes. Scrapers either send the configured bearer token or connect
+// from an allowed network, with neither configured nobody can scrape.
+type metricsAccess struct {
+	token    string
+	networks []*net.IPNet
+}
+
+func newMetricsAccess(cfg *config.Config) metricsAccess {
+	access := metricsAccess{
+		token: os.Getenv(cfg.Viper.GetString("service.http.metrics.token-env")),
+	}
+	for _, cidr := range cfg.Viper.GetStringSlice("service.http.metrics.allowed-cidrs") {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(fmt.Errorf("service.http.metrics.allowed-cidrs: %w", err))
+		}
+		access.networks = append(access.networks, network)
+	}
+	return access
+}
+
+func (a metricsAccess) allows(c *gin.Context) bool {
+	if a.token != "" {
+		token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if found && subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) == 1 {
+			return true
+		}
+	}
+
+	ip := net.ParseIP(c.ClientIP())
+	for _, network := range a.networks {
+		if ip != nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *router) metricsAccessMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !r.metricsAccess.allows(c) {
+			abortWithError(c, errs.Unauthenticated("invalid_metrics_token", "metrics require a valid token or an allowed network"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// metricsMiddleware labels by route template, never by raw path, so ids in the
+// URL do not blow up the series count.
+func (r *router) metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		r.metrics.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		r.metrics.duration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (r *Controller) GetMetrics(ctx *gin.Context) {
+	promhttp.HandlerFor(r.gatherer, promhttp.HandlerOpts{}).ServeHTTP(ctx.Writer, ctx.Request)
+}
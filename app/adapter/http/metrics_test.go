@@ -0,0 +1,111 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/config"
+	"strings"
+	"testing"
+)
+
+// scrape returns the registry in the text exposition format served by /metrics.
+func scrape(t *testing.T, gatherer prometheus.Gatherer) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/metrics", (&Controller{gatherer: gatherer}).GetMetrics)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("metrics status = %d", w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := prometheus.NewRegistry()
+	r := &router{metrics: newHTTPMetrics(registry)}
+
+	engine := gin.New()
+	engine.Use(r.metricsMiddleware())
+	engine.GET("/promotions/:id", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	engine.DELETE("/promotions/:id", func(ctx *gin.Context) { ctx.Status(http.StatusForbidden) })
+
+	requests := []struct {
+		method string
+		path   string
+	}{
+		{method: http.MethodGet, path: "/promotions/1"},
+		{method: http.MethodGet, path: "/promotions/2"},
+		{method: http.MethodDelete, path: "/promotions/1"},
+		{method: http.MethodGet, path: "/random/a"},
+		{method: http.MethodGet, path: "/random/b"},
+	}
+	for _, req := range requests {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(req.method, req.path, nil))
+	}
+
+	body := scrape(t, registry)
+	for _, line := range []string{
+		`pixelpromo_http_requests_total{method="GET",route="/promotions/:id",status="200"} 2`,
+		`pixelpromo_http_requests_total{method="DELETE",route="/promotions/:id",status="403"} 1`,
+		`pixelpromo_http_requests_total{method="GET",route="unmatched",status="404"} 2`,
+		`pixelpromo_http_request_duration_seconds_count{method="GET",route="/promotions/:id"} 2`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %s", line)
+		}
+	}
+	if strings.Contains(body, "/promotions/1") || strings.Contains(body, "/random") {
+		t.Fatalf("raw paths used as labels:\n%s", body)
+	}
+}
+
+func TestMetricsAccess(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		cidrs         []string
+		remoteAddr    string
+		authorization string
+		status        int
+	}{
+		{name: "allowed network", cidrs: []string{"10.0.0.0/8"}, remoteAddr: "10.1.2.3:4000", status: http.StatusOK},
+		{name: "other network", cidrs: []string{"10.0.0.0/8"}, remoteAddr: "203.0.113.7:4000", status: http.StatusUnauthorized},
+		{name: "valid token", token: "secret", remoteAddr: "203.0.113.7:4000", authorization: "Bearer secret", status: http.StatusOK},
+		{name: "wrong token", token: "secret", remoteAddr: "203.0.113.7:4000", authorization: "Bearer other", status: http.StatusUnauthorized},
+		{name: "token without bearer", token: "secret", remoteAddr: "203.0.113.7:4000", authorization: "secret", status: http.StatusUnauthorized},
+		{name: "nothing configured", remoteAddr: "127.0.0.1:4000", authorization: "Bearer ", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PP_TEST_METRICS_TOKEN", tt.token)
+			v := viper.New()
+			v.Set("service.http.metrics.token-env", "PP_TEST_METRICS_TOKEN")
+			v.Set("service.http.metrics.allowed-cidrs", tt.cidrs)
+			r := &router{metricsAccess: newMetricsAccess(&config.Config{Viper: v})}
+
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			engine.GET("/metrics", r.metricsAccessMiddleware(), func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
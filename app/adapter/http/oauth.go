@@ -0,0 +1,86 @@
+package http
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+	"net/http"
+	"net/url"
+	"pixelPromo/domain/errs"
+	"strconv"
+	"strings"
+)
+
+const oauthCookieMaxAge = 600
+
+func (r *Controller) BeginOAuth(ctx *gin.Context) {
+	provider, ok := r.providers[ctx.Param("provider")]
+	if !ok {
+		writeError(ctx, errs.NotFound("provider"))
+		return
+	}
+
+	state := oauth2.GenerateVerifier()
+	verifier := oauth2.GenerateVerifier()
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oauthCookieName(provider.Name()), state+"."+verifier, oauthCookieMaxAge, r.oauth.cookiePath, "", r.oauth.secureCookie, true)
+	ctx.Redirect(http.StatusFound, provider.AuthURL(state, verifier, r.oauthRedirectURL(provider.Name())))
+}
+
+func (r *Controller) OAuthCallback(ctx *gin.Context) {
+	provider, ok := r.providers[ctx.Param("provider")]
+	if !ok {
+		writeError(ctx, errs.NotFound("provider"))
+		return
+	}
+
+	cookieName := oauthCookieName(provider.Name())
+	cookie, err := ctx.Cookie(cookieName)
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(cookieName, "", -1, r.oauth.cookiePath, "", r.oauth.secureCookie, true)
+
+	state, verifier, found := strings.Cut(cookie, ".")
+	callbackState := ctx.Query("state")
+	if err != nil || !found || subtle.ConstantTimeCompare([]byte(state), []byte(callbackState)) != 1 {
+		writeError(ctx, errs.Unauthenticated("invalid_oauth_state", "invalid oauth state"))
+		return
+	}
+
+	identity, err := provider.Resolve(ctx, ctx.Request.URL.Query(), verifier, r.oauthRedirectURL(provider.Name()))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	user, err := r.handler.ExternalLogin(ctx, identity)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	response, err := r.createTokens(ctx, user, nil)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if r.oauth.successRedirectURL == "" {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+
+	fragment := url.Values{}
+	fragment.Set("token", response.Token)
+	fragment.Set("refreshToken", response.RefreshToken)
+	fragment.Set("expiresAt", strconv.FormatInt(response.ExpiresAt.Unix(), 10))
+	ctx.Redirect(http.StatusFound, r.oauth.successRedirectURL+"#"+fragment.Encode())
+}
+
+func (r *Controller) oauthRedirectURL(provider string) string {
+	return strings.TrimSuffix(r.oauth.redirectBaseURL, "/") + "/" + provider + "/callback"
+}
+
+func oauthCookieName(provider string) string {
+	return "pp_oauth_" + provider
+}
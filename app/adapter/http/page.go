@@ -0,0 +1,24 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"strconv"
+)
+
+func pageRequest(ctx *gin.Context) (*model.PageRequest, error) {
+	page := &model.PageRequest{
+		Cursor: ctx.Query("cursor"),
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.ParseInt(limit, 10, 32)
+		if err != nil || value <= 0 {
+			return nil, errs.Validation("query is invalid", errs.Field("limit", "invalid", "limit must be a positive number"))
+		}
+		page.Limit = int32(value)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,100 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"math"
+	"net/http"
+	"pixelPromo/domain/errs"
+	"strconv"
+	"strings"
+)
+
+const problemContentType = "application/problem+json"
+
+type problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	Errors   []errs.FieldError `json:"errors,omitempty"`
+}
+
+func writeError(ctx *gin.Context, err error) {
+	status := errorStatus(err)
+	body := problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: ctx.Request.URL.Path,
+		Code:     "internal_error",
+	}
+
+	if e, ok := errs.As(err); ok {
+		body.Detail = e.Message
+		body.Code = e.Code
+		body.Errors = e.Fields
+		if e.RetryAfter > 0 {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(e.RetryAfter.Seconds()))))
+		}
+	}
+
+	ctx.Header("Content-Type", problemContentType)
+	ctx.JSON(status, body)
+}
+
+func abortWithError(ctx *gin.Context, err error) {
+	writeError(ctx, err)
+	ctx.Abort()
+}
+
+func errorStatus(err error) int {
+	switch errs.KindOf(err) {
+	case errs.KindValidation:
+		return http.StatusBadRequest
+	case errs.KindUnauthenticated:
+		return http.StatusUnauthorized
+	case errs.KindForbidden:
+		return http.StatusForbidden
+	case errs.KindNotFound:
+		return http.StatusNotFound
+	case errs.KindConflict:
+		return http.StatusConflict
+	case errs.KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case errs.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func bindError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return errs.Validation("request body is invalid",
+			errs.Field(typeErr.Field, "invalid_type", fmt.Sprintf("%s must be a %s", typeErr.Field, typeErr.Type.String())))
+	}
+
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		field = strings.Trim(field, `"`)
+		return errs.Validation("request body is invalid",
+			errs.Field(field, "unknown_field", fmt.Sprintf("%s is not allowed", field)))
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return bodyTooLarge(maxBytesErr.Limit)
+	}
+
+	if errors.Is(err, io.EOF) {
+		return errs.Invalid("empty_body", "request body is empty")
+	}
+
+	return errs.Invalid("malformed_body", "request body is malformed")
+}
@@ -0,0 +1,207 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		status     int
+		code       string
+		detail     string
+		retryAfter string
+		fields     int
+	}{
+		{
+			name:   "validation",
+			err:    errs.Validation("user is invalid", errs.Required("name"), errs.Required("email")),
+			status: http.StatusBadRequest,
+			code:   "validation_failed",
+			detail: "user is invalid",
+			fields: 2,
+		},
+		{
+			name:   "unauthenticated",
+			err:    errs.Unauthenticated("invalid_token", "invalid token"),
+			status: http.StatusUnauthorized,
+			code:   "invalid_token",
+			detail: "invalid token",
+		},
+		{
+			name:   "forbidden",
+			err:    errs.Forbidden("not_owner", "resource belongs to another user"),
+			status: http.StatusForbidden,
+			code:   "not_owner",
+			detail: "resource belongs to another user",
+		},
+		{
+			name:   "not found",
+			err:    errs.NotFound("api key"),
+			status: http.StatusNotFound,
+			code:   "api_key_not_found",
+			detail: "api key not found",
+		},
+		{
+			name:   "conflict",
+			err:    errs.Conflict("email_taken", "email already in use"),
+			status: http.StatusConflict,
+			code:   "email_taken",
+			detail: "email already in use",
+		},
+		{
+			name:       "too many requests rounds retry after up",
+			err:        errs.TooManyRequests("login_locked", "too many failed login attempts", 1500*time.Millisecond),
+			status:     http.StatusTooManyRequests,
+			code:       "login_locked",
+			detail:     "too many failed login attempts",
+			retryAfter: "2",
+		},
+		{
+			name:   "too large",
+			err:    errs.TooLarge("body_too_large", "request body must have at most 65536 bytes"),
+			status: http.StatusRequestEntityTooLarge,
+			code:   "body_too_large",
+			detail: "request body must have at most 65536 bytes",
+		},
+		{
+			name:   "wrapped domain error",
+			err:    fmt.Errorf("create user: %w", errs.Conflict("email_taken", "email already in use")),
+			status: http.StatusConflict,
+			code:   "email_taken",
+			detail: "email already in use",
+		},
+		{
+			name:   "unknown errors hide their message",
+			err:    errors.New("dynamodb: connection reset"),
+			status: http.StatusInternalServerError,
+			code:   "internal_error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/users/1", nil)
+
+			writeError(ctx, tt.err)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.HasPrefix(w.Header().Get("Content-Type"), problemContentType) {
+				t.Fatalf("content type = %s", w.Header().Get("Content-Type"))
+			}
+			if w.Header().Get("Retry-After") != tt.retryAfter {
+				t.Fatalf("retry after = %q, want %q", w.Header().Get("Retry-After"), tt.retryAfter)
+			}
+
+			var body problem
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != tt.status || body.Code != tt.code || body.Detail != tt.detail || body.Instance != "/users/1" || len(body.Errors) != tt.fields {
+				t.Fatalf("body = %+v", body)
+			}
+		})
+	}
+}
+
+func TestBindError(t *testing.T) {
+	type target struct {
+		Limit int `json:"limit"`
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		code  string
+		field string
+	}{
+		{name: "empty body", body: "", code: "empty_body"},
+		{name: "malformed body", body: "{", code: "malformed_body"},
+		{name: "wrong type", body: `{"limit":"ten"}`, code: "validation_failed", field: "limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			ctx.Request.Header.Set("Content-Type", "application/json")
+
+			var value target
+			err := bindError(ctx.ShouldBindJSON(&value))
+
+			e, ok := errs.As(err)
+			if !ok || e.Kind != errs.KindValidation || e.Code != tt.code {
+				t.Fatalf("error = %#v, want %s", err, tt.code)
+			}
+			if tt.field != "" && (len(e.Fields) != 1 || e.Fields[0].Field != tt.field) {
+				t.Fatalf("fields = %+v", e.Fields)
+			}
+		})
+	}
+}
+
+// missingHandler finds nothing, as for ids that were never created
+type missingHandler struct {
+	port.Handler
+}
+
+func (missingHandler) GetUserById(context.Context, string) (*model.User, error) {
+	return nil, nil
+}
+
+func (missingHandler) GetPromotionById(context.Context, string) (*model.Promotion, error) {
+	return nil, nil
+}
+
+func TestMissingResource(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	controller := &Controller{handler: missingHandler{}}
+
+	tests := []struct {
+		name    string
+		route   string
+		handler gin.HandlerFunc
+		code    string
+	}{
+		{name: "user", route: "/users/:id", handler: controller.GetUserById, code: "user_not_found"},
+		{name: "promotion", route: "/promotions/:id", handler: controller.GetPromotionById, code: "promotion_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET(tt.route, tt.handler)
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.Replace(tt.route, ":id", "missing", 1), nil))
+
+			var body problem
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != http.StatusNotFound || body.Code != tt.code {
+				t.Fatalf("status = %d, body = %+v", w.Code, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"math"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	rateLimitKeyIP     = "ip"
+	rateLimitKeyUser   = "user"
+	rateLimitKeyApiKey = "api-key"
+)
+
+type rateLimitPolicy struct {
+	Name    string        `mapstructure:"name"`
+	Group   string        `mapstructure:"group"`
+	Methods []string      `mapstructure:"methods"`
+	Key     string        `mapstructure:"key"`
+	Limit   int           `mapstructure:"limit"`
+	Period  time.Duration `mapstructure:"period"`
+}
+
+type rateLimitConfig struct {
+	enabled  bool
+	policies []rateLimitPolicy
+}
+
+func newRateLimitConfig(cfg *config.Config) rateLimitConfig {
+	var policies []rateLimitPolicy
+	if err := cfg.Viper.UnmarshalKey("service.rate-limit.policies", &policies); err != nil {
+		panic(err)
+	}
+
+	for i, policy := range policies {
+		if policy.Name == "" || policy.Limit <= 0 || policy.Period <= 0 {
+			panic(fmt.Errorf("rate-limit policy [%d]: name, limit and period are required", i))
+		}
+		switch policy.Key {
+		case rateLimitKeyIP, rateLimitKeyUser, rateLimitKeyApiKey:
+		default:
+			panic(fmt.Errorf("rate-limit policy [%s]: key [%s] not supported", policy.Name, policy.Key))
+		}
+		for j, method := range policy.Methods {
+			policies[i].Methods[j] = strings.ToUpper(method)
+		}
+	}
+
+	return rateLimitConfig{
+		enabled:  cfg.Viper.GetBool("service.rate-limit.enabled"),
+		policies: policies,
+	}
+}
+
+// match returns the first policy whose group prefixes the route and whose
+// methods include the request method, so specific policies go first in config.
+func (rc rateLimitConfig) match(method, route string) *rateLimitPolicy {
+	for i, policy := range rc.policies {
+		group := strings.TrimSuffix(policy.Group, "/")
+		if route != group && !strings.HasPrefix(route, group+"/") {
+			continue
+		}
+		if len(policy.Methods) == 0 {
+			return &rc.policies[i]
+		}
+		for _, m := range policy.Methods {
+			if m == "*" || m == method {
+				return &rc.policies[i]
+			}
+		}
+	}
+	return nil
+}
+
+// key identifies the caller the policy is counted against, falling back to the
+// client address when the request carries no user or api key.
+func (p *rateLimitPolicy) key(c *gin.Context) string {
+	actor := model.ActorFromContext(c)
+	if actor != nil && p.Key == rateLimitKeyApiKey && actor.ApiKeyId != "" {
+		return fmt.Sprintf("%s:api-key:%s", p.Name, actor.ApiKeyId)
+	}
+	if actor != nil && p.Key != rateLimitKeyIP && actor.UserId != "" {
+		return fmt.Sprintf("%s:user:%s", p.Name, actor.UserId)
+	}
+	return fmt.Sprintf("%s:ip:%s", p.Name, c.ClientIP())
+}
+
+func (r *router) rateLimitMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !r.rateLimit.enabled {
+			c.Next()
+			return
+		}
+
+		policy := r.rateLimit.match(c.Request.Method, routePath(c))
+		if policy == nil {
+			c.Next()
+			return
+		}
+
+		limit := model.RateLimit{Limit: policy.Limit, Period: policy.Period}
+		decision, err := r.limiter.Allow(c, policy.key(c), limit)
+		if err != nil {
+			// A broken store must not take the API down with it.
+			r.requestLogger(c).Warn("rate limit unavailable, allowing request", config.F("policy", policy.Name), config.F("error", err.Error()))
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(decision.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.ResetAfter)))
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period)))
+
+		if !decision.Allowed {
+			abortWithError(c, errs.TooManyRequests("rate_limited", "too many requests, try again later", decision.RetryAfter))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
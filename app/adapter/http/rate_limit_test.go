@@ -0,0 +1,143 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/model"
+	"testing"
+	"time"
+)
+
+var testRateLimitPolicies = []rateLimitPolicy{
+	{Name: "auth", Group: "/auth", Methods: []string{"POST"}, Key: rateLimitKeyIP, Limit: 1, Period: time.Minute},
+	{Name: "promotions-write", Group: "/promotions", Methods: []string{"POST", "DELETE"}, Key: rateLimitKeyApiKey, Limit: 1, Period: time.Minute},
+	{Name: "default", Group: "/", Methods: []string{"*"}, Key: rateLimitKeyUser, Limit: 1, Period: time.Minute},
+}
+
+func TestRateLimitMatch(t *testing.T) {
+	rc := rateLimitConfig{enabled: true, policies: testRateLimitPolicies}
+
+	tests := []struct {
+		name   string
+		method string
+		route  string
+		want   string
+	}{
+		{name: "exact group", method: "POST", route: "/auth", want: "auth"},
+		{name: "nested route", method: "POST", route: "/auth/login", want: "auth"},
+		{name: "other method falls through", method: "GET", route: "/auth/login", want: "default"},
+		{name: "group is not a plain prefix", method: "POST", route: "/authors", want: "default"},
+		{name: "wildcard method", method: "PATCH", route: "/users/:id", want: "default"},
+		{name: "specific policy first", method: "DELETE", route: "/promotions/:id", want: "promotions-write"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := rc.match(tt.method, tt.route)
+			if policy == nil || policy.Name != tt.want {
+				t.Fatalf("policy = %+v, want %s", policy, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		actor *model.Actor
+		want  string
+	}{
+		{name: "ip policy ignores the user", key: rateLimitKeyIP, actor: &model.Actor{UserId: "user-1"}, want: "test:ip:10.0.0.1"},
+		{name: "user policy", key: rateLimitKeyUser, actor: &model.Actor{UserId: "user-1", ApiKeyId: "key-1"}, want: "test:user:user-1"},
+		{name: "api key policy", key: rateLimitKeyApiKey, actor: &model.Actor{UserId: "user-1", ApiKeyId: "key-1"}, want: "test:api-key:key-1"},
+		{name: "api key policy with a session", key: rateLimitKeyApiKey, actor: &model.Actor{UserId: "user-1"}, want: "test:user:user-1"},
+		{name: "anonymous falls back to ip", key: rateLimitKeyUser, want: "test:ip:10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			c, engine := gin.CreateTestContext(httptest.NewRecorder())
+			engine.ContextWithFallback = true
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			c.Request.RemoteAddr = "10.0.0.1:4000"
+			if tt.actor != nil {
+				(&router{log: nopLogger{}}).withActor(c, tt.actor)
+			}
+
+			policy := rateLimitPolicy{Name: "test", Key: tt.key}
+			if got := policy.key(c); got != tt.want {
+				t.Fatalf("key = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeLimiter struct {
+	decision *model.RateLimitDecision
+	err      error
+}
+
+func (l fakeLimiter) Allow(context.Context, string, model.RateLimit) (*model.RateLimitDecision, error) {
+	return l.decision, l.err
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		limiter    fakeLimiter
+		status     int
+		limit      string
+		retryAfter string
+	}{
+		{name: "disabled", limiter: fakeLimiter{err: errors.New("not called")}, status: http.StatusNoContent},
+		{
+			name:    "allowed",
+			enabled: true,
+			limiter: fakeLimiter{decision: &model.RateLimitDecision{Allowed: true, Limit: 1, ResetAfter: time.Minute}},
+			status:  http.StatusNoContent,
+			limit:   "1",
+		},
+		{
+			name:       "limited",
+			enabled:    true,
+			limiter:    fakeLimiter{decision: &model.RateLimitDecision{Limit: 1, RetryAfter: 1500 * time.Millisecond, ResetAfter: time.Minute}},
+			status:     http.StatusTooManyRequests,
+			limit:      "1",
+			retryAfter: "2",
+		},
+		{name: "store unavailable", enabled: true, limiter: fakeLimiter{err: errors.New("throttled")}, status: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			r := &router{
+				log:       nopLogger{},
+				limiter:   tt.limiter,
+				rateLimit: rateLimitConfig{enabled: tt.enabled, policies: testRateLimitPolicies},
+			}
+			engine := gin.New()
+			engine.ContextWithFallback = true
+			engine.Use(r.rateLimitMiddleware())
+			engine.POST("/auth/login", func(ctx *gin.Context) {
+				ctx.Status(http.StatusNoContent)
+			})
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/login", nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if w.Header().Get("RateLimit-Limit") != tt.limit || w.Header().Get("Retry-After") != tt.retryAfter {
+				t.Fatalf("headers = %v", w.Header())
+			}
+		})
+	}
+}
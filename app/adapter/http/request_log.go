@@ -0,0 +1,92 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"pixelPromo/config"
+	"pixelPromo/domain/model"
+	"regexp"
+	"time"
+)
+
+const requestIdHeader = "X-Request-ID"
+
+var requestIdPattern = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// requestMiddleware tags the request with an id and a scoped logger, then writes
+// a single access log line once the rest of the chain has finished.
+func (r *router) requestMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestId := c.GetHeader(requestIdHeader)
+		if !requestIdPattern.MatchString(requestId) {
+			requestId = newRequestId()
+		}
+		c.Header(requestIdHeader, requestId)
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		fields := []config.Field{config.F("requestId", requestId), config.F("route", route)}
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.IsValid() {
+			fields = append(fields, config.F("traceId", spanContext.TraceID().String()), config.F("spanId", spanContext.SpanID().String()))
+		}
+
+		log := r.log.With(fields...)
+		c.Request = c.Request.WithContext(config.WithLogger(c.Request.Context(), log))
+
+		c.Next()
+
+		fields = []config.Field{
+			config.F("method", c.Request.Method),
+			config.F("path", c.Request.URL.Path),
+			config.F("status", c.Writer.Status()),
+			config.F("latency", time.Since(start)),
+			config.F("clientIp", c.ClientIP()),
+			config.F("bytes", max(c.Writer.Size(), 0)),
+		}
+
+		// Failures are already logged with their cause at error level where they happened.
+		log = r.requestLogger(c)
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			log.Warn("request completed", fields...)
+			return
+		}
+		log.Info("request completed", fields...)
+	}
+}
+
+func (r *router) requestLogger(c *gin.Context) config.Logger {
+	return config.LoggerFromContext(c.Request.Context(), r.log)
+}
+
+// withActor stores the authenticated actor and adds its user id to the request logger.
+func (r *router) withActor(c *gin.Context, actor *model.Actor) {
+	ctx := model.WithActor(c.Request.Context(), actor)
+	log := r.requestLogger(c).With(config.F("userId", actor.UserId))
+	c.Request = c.Request.WithContext(config.WithLogger(ctx, log))
+}
+
+func (r *router) recoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		err := fmt.Errorf("panic: %v", recovered)
+		r.requestLogger(c).Error(err.Error())
+		abortWithError(c, err)
+	})
+}
+
+func newRequestId() string {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(raw)
+}
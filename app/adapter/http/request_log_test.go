@@ -0,0 +1,240 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/config"
+	"pixelPromo/domain/model"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type logEntry struct {
+	level  string
+	msg    string
+	fields map[string]any
+}
+
+// recordLogger keeps every entry with the fields added through With, so tests
+// can check what a request scoped logger carries.
+type recordLogger struct {
+	mu      *sync.Mutex
+	entries *[]logEntry
+	fields  []config.Field
+}
+
+func newRecordLogger() recordLogger {
+	return recordLogger{mu: &sync.Mutex{}, entries: &[]logEntry{}}
+}
+
+func (l recordLogger) log(level string, msg string, fields []config.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	entry := logEntry{level: level, msg: msg, fields: map[string]any{}}
+	for _, field := range append(append([]config.Field{}, l.fields...), fields...) {
+		entry.fields[field.Key] = field.Val
+	}
+	*l.entries = append(*l.entries, entry)
+}
+
+func (l recordLogger) Debug(msg string, fields ...config.Field) { l.log("debug", msg, fields) }
+func (l recordLogger) Info(msg string, fields ...config.Field)  { l.log("info", msg, fields) }
+func (l recordLogger) Warn(msg string, fields ...config.Field)  { l.log("warn", msg, fields) }
+func (l recordLogger) Error(msg string, fields ...config.Field) { l.log("error", msg, fields) }
+func (l recordLogger) Panic(msg string, fields ...config.Field) { l.log("panic", msg, fields) }
+func (l recordLogger) Fatal(msg string, fields ...config.Field) { l.log("fatal", msg, fields) }
+func (l recordLogger) Flush() error                             { return nil }
+
+func (l recordLogger) With(fields ...config.Field) config.Logger {
+	l.fields = append(append([]config.Field{}, l.fields...), fields...)
+	return l
+}
+
+func (l recordLogger) find(msg string) (logEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, entry := range *l.entries {
+		if entry.msg == msg {
+			return entry, true
+		}
+	}
+	return logEntry{}, false
+}
+
+func newLoggingEngine(log recordLogger) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := &router{log: log}
+
+	engine := gin.New()
+	engine.Use(r.requestMiddleware(), r.recoveryMiddleware())
+	engine.GET("/promotions/:id", func(ctx *gin.Context) {
+		config.LoggerFromContext(ctx.Request.Context(), nil).Debug("handler")
+		ctx.Status(http.StatusNoContent)
+	})
+	engine.GET("/me", func(ctx *gin.Context) {
+		r.withActor(ctx, &model.Actor{UserId: "user-1"})
+		config.LoggerFromContext(ctx.Request.Context(), nil).Debug("handler")
+		ctx.Status(http.StatusNoContent)
+	})
+	engine.GET("/failure", func(ctx *gin.Context) {
+		ctx.Status(http.StatusBadGateway)
+	})
+	engine.GET("/panic", func(*gin.Context) {
+		panic("boom")
+	})
+	return engine
+}
+
+func TestRequestId(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+	tests := []struct {
+		name   string
+		header string
+		// keep expects the incoming id to be echoed back
+		keep bool
+	}{
+		{name: "missing", header: ""},
+		{name: "valid", header: "req-1.a:b_c", keep: true},
+		{name: "with spaces", header: "req 1"},
+		{name: "log injection", header: "req-1\nlevel=error"},
+		{name: "too long", header: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := newRecordLogger()
+			engine := newLoggingEngine(log)
+
+			req := httptest.NewRequest(http.MethodGet, "/promotions/1", nil)
+			if tt.header != "" {
+				req.Header.Set(requestIdHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			requestId := w.Header().Get(requestIdHeader)
+			if tt.keep && requestId != tt.header {
+				t.Fatalf("request id = %q, want %q", requestId, tt.header)
+			}
+			if !tt.keep && !generated.MatchString(requestId) {
+				t.Fatalf("request id = %q, want a generated one", requestId)
+			}
+
+			handler, ok := log.find("handler")
+			if !ok || handler.fields["requestId"] != requestId || handler.fields["route"] != "/promotions/:id" {
+				t.Fatalf("handler log = %+v", handler)
+			}
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+		level  string
+		route  string
+		userId any
+	}{
+		{name: "success", path: "/promotions/1", status: http.StatusNoContent, level: "info", route: "/promotions/:id"},
+		{name: "authenticated", path: "/me", status: http.StatusNoContent, level: "info", route: "/me", userId: "user-1"},
+		{name: "unmatched route", path: "/missing", status: http.StatusNotFound, level: "info", route: "unmatched"},
+		{name: "server failure", path: "/failure", status: http.StatusBadGateway, level: "warn", route: "/failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := newRecordLogger()
+			engine := newLoggingEngine(log)
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			access, ok := log.find("request completed")
+			if !ok {
+				t.Fatal("no access log")
+			}
+			if access.level != tt.level || access.fields["status"] != tt.status || access.fields["route"] != tt.route ||
+				access.fields["method"] != http.MethodGet || access.fields["path"] != tt.path || access.fields["userId"] != tt.userId {
+				t.Fatalf("access log = %+v", access)
+			}
+			if access.fields["requestId"] != w.Header().Get(requestIdHeader) {
+				t.Fatalf("access log request id = %v", access.fields["requestId"])
+			}
+			if tt.userId != nil {
+				if handler, _ := log.find("handler"); handler.fields["userId"] != tt.userId {
+					t.Fatalf("handler log = %+v", handler)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	log := newRecordLogger()
+	engine := newLoggingEngine(log)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var body problem
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != "internal_error" || strings.Contains(w.Body.String(), "boom") {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+
+	logged, ok := log.find("panic: boom")
+	if !ok || logged.level != "error" || logged.fields["requestId"] != w.Header().Get(requestIdHeader) {
+		t.Fatalf("panic log = %+v", logged)
+	}
+	if access, _ := log.find("request completed"); access.level != "warn" {
+		t.Fatalf("access log = %+v", access)
+	}
+}
+
+func TestRequestLogTraceIds(t *testing.T) {
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+
+	tests := []struct {
+		name        string
+		spanContext trace.SpanContext
+		traceId     any
+		spanId      any
+	}{
+		{name: "without span"},
+		{
+			name:        "with span",
+			spanContext: trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceId, SpanID: spanId, TraceFlags: trace.FlagsSampled}),
+			traceId:     traceId.String(),
+			spanId:      spanId.String(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := newRecordLogger()
+			engine := newLoggingEngine(log)
+
+			req := httptest.NewRequest(http.MethodGet, "/promotions/1", nil)
+			req = req.WithContext(trace.ContextWithSpanContext(req.Context(), tt.spanContext))
+			engine.ServeHTTP(httptest.NewRecorder(), req)
+
+			for _, msg := range []string{"handler", "request completed"} {
+				entry, _ := log.find(msg)
+				if entry.fields["traceId"] != tt.traceId || entry.fields["spanId"] != tt.spanId {
+					t.Fatalf("%s log = %+v", msg, entry)
+				}
+			}
+		})
+	}
+}
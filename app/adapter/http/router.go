@@ -1,59 +1,161 @@
 package http
 
 import (
-	"github.com/dgrijalva/jwt-go"
+	"context"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
 	"strings"
 	"time"
 )
 
-var jwtKey = []byte("my_secret_key")
-
 type Router interface {
-	Run()
+	Start() error
+	Shutdown(context.Context) error
 }
 
 type router struct {
-	controller *Controller
+	controller    *Controller
+	keys          *KeySet
+	log           config.Logger
+	env           config.Env
+	server        serverConfig
+	metrics       *httpMetrics
+	tracing       gin.HandlerFunc
+	limiter       port.RateLimiter
+	rateLimit     rateLimitConfig
+	versions      []apiVersion
+	compression   compressionConfig
+	cachePolicies map[string]string
+	metricsAccess metricsAccess
+	httpServer    *http.Server
+}
+
+var apiKeyScopes = map[string]model.Scope{
+	"GET /promotions":                                 model.ScopeReadPromotions,
+	"GET /promotions/:id":                             model.ScopeReadPromotions,
+	"GET /promotions/favorites/:id":                   model.ScopeReadPromotions,
+	"GET /categories":                                 model.ScopeReadPromotions,
+	"GET /users/:id":                                  model.ScopeReadPromotions,
+	"GET /users/rank":                                 model.ScopeReadPromotions,
+	"GET /interactions/comments/:id":                  model.ScopeReadPromotions,
+	"GET /interactions/statistics/:id":                model.ScopeReadPromotions,
+	"GET /interactions/user-statistics/:id":           model.ScopeReadPromotions,
+	"GET /interactions/promotion-user-statistics":     model.ScopeReadPromotions,
+	"POST /promotions":                                model.ScopeWritePromotions,
+	"PATCH /promotions":                               model.ScopeWritePromotions,
+	"PATCH /promotions/:id/status":                    model.ScopeWritePromotions,
+	"POST /promotions/:id/expired-reports/resolution": model.ScopeWritePromotions,
+	"DELETE /promotions/:id":                          model.ScopeWritePromotions,
+	"POST /promotions/image/:id":                      model.ScopeWritePromotions,
+	"POST /interactions":                              model.ScopeInteract,
+	"DELETE /interactions/comments/:id":               model.ScopeInteract,
 }
 
 type Claims struct {
-	Username string `json:"username"`
-	jwt.StandardClaims
+	Email string     `json:"email"`
+	Role  model.Role `json:"role"`
+	jwt.RegisteredClaims
 }
 
 func NewRouter(
 	controller *Controller,
+	keys *KeySet,
+	cfg *config.Config,
+	log config.Logger,
+	registerer prometheus.Registerer,
+	tracerProvider trace.TracerProvider,
+	limiter port.RateLimiter,
 ) Router {
-	return &router{
-		controller: controller,
+	r := &router{
+		controller:    controller,
+		keys:          keys,
+		log:           log,
+		env:           cfg.Env,
+		server:        newServerConfig(cfg),
+		metrics:       newHTTPMetrics(registerer),
+		tracing:       otelgin.Middleware(cfg.Viper.GetString("service.telemetry.service-name"), otelgin.WithTracerProvider(tracerProvider)),
+		limiter:       limiter,
+		rateLimit:     newRateLimitConfig(cfg),
+		compression:   newCompressionConfig(cfg),
+		cachePolicies: newCachePolicies(cfg),
+		metricsAccess: newMetricsAccess(cfg),
 	}
-}
-
-func (r *router) Run() {
-	gin := gin.Default()
-	r.setup(gin)
-	gin.Run(":5050")
+	r.versions = []apiVersion{
+		newAPIVersion(cfg, "v1", "/v1", "", r.v1Routes),
+		newAPIVersion(cfg, "legacy", "", "/v1", r.v1Routes),
+	}
+	return r
 }
 
 func (r *router) setup(gin *gin.Engine) {
+	gin.ContextWithFallback = true
 
 	gin.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Porta do frontend
 		AllowMethods:     []string{"*"},
 		AllowHeaders:     []string{"*"},
+		ExposeHeaders:    []string{requestIdHeader, "ETag", "Last-Modified", "Deprecation", "Sunset", "Link", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	gin.Use(r.compressionMiddleware())
 
-	gin.GET("/health", r.controller.Health)
-	gin.POST("/auth", r.controller.Login)
-	gin.POST("/users", r.controller.CreateUser)
+	infraGroup := gin.Group("")
+	infraGroup.Use(r.bodyLimitMiddleware(), r.cacheMiddleware())
+	{
+		infraGroup.GET("/health", r.controller.Health)
+		infraGroup.GET("/health/live", r.controller.LiveHealth)
+		infraGroup.GET("/health/ready", r.controller.ReadyHealth)
+		infraGroup.GET("/metrics", r.metricsAccessMiddleware(), r.controller.GetMetrics)
+		infraGroup.GET("/openapi.json", r.controller.GetOpenAPI)
+		infraGroup.GET("/docs", r.controller.GetDocs)
+		infraGroup.GET("/.well-known/jwks.json", r.controller.GetJWKS)
+	}
+
+	for _, version := range r.versions {
+		version.routes(gin.Group(version.prefix, r.versionMiddleware(version), r.bodyLimitMiddleware(), r.cacheMiddleware()))
+	}
+
+	return
+}
+
+// v1Routes registers the v1 contract. A future v2 gets its own routes function and
+// DTOs, mounted next to it, while v1 keeps serving existing clients unchanged.
+func (r *router) v1Routes(api *gin.RouterGroup) {
+	publicGroup := api.Group("")
+	publicGroup.Use(r.rateLimitMiddleware())
+	{
+		publicGroup.POST("/auth", r.controller.Login)
+		publicGroup.POST("/auth/refresh", r.controller.RefreshSession)
+		publicGroup.POST("/auth/logout", r.controller.Logout)
+		publicGroup.GET("/auth/verify-email", r.controller.VerifyEmail)
+		publicGroup.GET("/auth/oauth/:provider", r.controller.BeginOAuth)
+		publicGroup.GET("/auth/oauth/:provider/callback", r.controller.OAuthCallback)
+		publicGroup.POST("/auth/forgot-password", r.controller.ForgotPassword)
+		publicGroup.POST("/auth/reset-password", r.controller.ResetPassword)
+		publicGroup.POST("/users", r.controller.CreateUser)
+	}
+
+	authGroup := api.Group("/auth")
+	authGroup.Use(r.authMiddleware(), r.rateLimitMiddleware())
+	{
+		authGroup.POST("/logout-all", r.controller.LogoutAll)
+		authGroup.GET("/sessions", r.controller.GetSessions)
+		authGroup.DELETE("/sessions/:id", r.controller.DeleteSession)
+		authGroup.POST("/verify-email/resend", r.controller.SendVerificationEmail)
+	}
 
-	userGroup := gin.Group("/users")
-	userGroup.Use(authMiddleware())
+	userGroup := api.Group("/users")
+	userGroup.Use(r.authMiddleware(), r.rateLimitMiddleware())
 	{
 		userGroup.POST("/picture/:id", r.controller.UpdateUserPicture)
 		userGroup.PATCH("", r.controller.UpdateUser)
@@ -62,62 +164,114 @@ func (r *router) setup(gin *gin.Engine) {
 		userGroup.GET("/rank", r.controller.GetUserRank)
 	}
 
-	promotionGroup := gin.Group("/promotions")
-	promotionGroup.Use(authMiddleware())
+	promotionGroup := api.Group("/promotions")
+	promotionGroup.Use(r.authMiddleware(), r.rateLimitMiddleware())
 	{
 		promotionGroup.POST("", r.controller.CreatePromotion)
 		promotionGroup.DELETE(":id", r.controller.DeletePromotion)
 		promotionGroup.PATCH("", r.controller.UpdatePromotion)
+		promotionGroup.PATCH("/:id/status", r.controller.UpdatePromotionStatus)
+		promotionGroup.POST("/:id/expired-reports/resolution", r.controller.ResolveExpiredReports)
 		promotionGroup.POST("/image/:id", r.controller.UpdatePromotionImage)
-		promotionGroup.GET("", r.controller.GetPromotions) // queryParams: []category, search
+		promotionGroup.GET("", r.controller.GetPromotions) // queryParams: []category, search, userId, sort, includeExpired
 		promotionGroup.GET(":id", r.controller.GetPromotionById)
 		promotionGroup.GET("/favorites/:id", r.controller.GetFavoritesPromotionsByUserId)
 	}
 
-	categoryGroup := gin.Group("/categories")
-	categoryGroup.Use(authMiddleware())
+	categoryGroup := api.Group("/categories")
+	categoryGroup.Use(r.authMiddleware(), r.rateLimitMiddleware())
 	{
 		categoryGroup.GET("", r.controller.GetCategories)
 	}
 
-	interactionGroup := gin.Group("/interactions")
-	interactionGroup.Use(authMiddleware())
+	interactionGroup := api.Group("/interactions")
+	interactionGroup.Use(r.authMiddleware(), r.rateLimitMiddleware())
 	{
 		interactionGroup.POST("", r.controller.CreateInteraction)
+		interactionGroup.DELETE("/comments/:id", r.controller.DeleteComment)
 		interactionGroup.GET("/comments/:id", r.controller.GetCommentsByPromotionId)
 		interactionGroup.GET("/statistics/:id", r.controller.GetInteractionStatisticsByPromotionId)
 		interactionGroup.GET("/user-statistics/:id", r.controller.GetInteractionStatisticsByUserId)
 		interactionGroup.GET("/promotion-user-statistics", r.controller.GetInteractionStatisticsByUserIdWithPromotionId)
 	}
 
-	return
+	apiKeyGroup := api.Group("/api-keys")
+	apiKeyGroup.Use(r.authMiddleware(), r.rateLimitMiddleware())
+	{
+		apiKeyGroup.POST("", r.controller.CreateApiKey)
+		apiKeyGroup.GET("", r.controller.GetApiKeys)
+		apiKeyGroup.DELETE(":id", r.controller.RevokeApiKey)
+	}
+
+	adminGroup := api.Group("/admin")
+	adminGroup.Use(r.authMiddleware(), r.rateLimitMiddleware(), policyMiddleware(model.RoleModerator, model.RoleAdmin))
+	{
+		adminGroup.GET("/users", r.controller.GetUsers)
+		adminGroup.PATCH("/users/:id/role", policyMiddleware(model.RoleAdmin), r.controller.UpdateUserRole)
+		adminGroup.DELETE("/users/:id", policyMiddleware(model.RoleAdmin), r.controller.DeleteUser)
+		adminGroup.DELETE("/promotions/:id", r.controller.DeletePromotion)
+		adminGroup.DELETE("/comments/:id", r.controller.DeleteComment)
+		adminGroup.POST("/categories", r.controller.CreateCategory)
+		adminGroup.DELETE("/categories/:name", r.controller.DeleteCategory)
+	}
 }
 
-func authMiddleware() gin.HandlerFunc {
+func (r *router) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString := c.GetHeader("Authorization")
+		if strings.HasPrefix(tokenString, "Bearer ") {
+			tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+		}
+		if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+			tokenString = apiKey
+		}
+
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing authorization token"})
-			c.Abort()
+			abortWithError(c, errs.Unauthenticated("missing_token", "missing authorization token"))
 			return
 		}
 
-		if strings.HasPrefix(tokenString, "Bearer ") {
-			tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+		var actor *model.Actor
+		if strings.HasPrefix(tokenString, "pp_") {
+			var err error
+			actor, err = r.controller.handler.AuthenticateApiKey(c, tokenString)
+			if err != nil {
+				abortWithError(c, err)
+				return
+			}
+
+			scope, ok := apiKeyScopes[c.Request.Method+" "+routePath(c)]
+			if !ok || !actor.HasScope(scope) {
+				abortWithError(c, errs.Forbidden("insufficient_scope", "insufficient scope"))
+				return
+			}
+		} else {
+			claims := &Claims{}
+			err := r.keys.Parse(tokenString, claims)
+			if err != nil || claims.Subject == "" {
+				abortWithError(c, errs.Unauthenticated("invalid_token", "invalid token"))
+				return
+			}
+
+			actor = &model.Actor{
+				UserId: claims.Subject,
+				Role:   claims.Role,
+			}
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+		r.withActor(c, actor)
+		c.Next()
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+func policyMiddleware(roles ...model.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := model.ActorFromContext(c)
+		if actor == nil || !actor.HasRole(roles...) {
+			abortWithError(c, errs.Forbidden("insufficient_role", "insufficient role"))
 			return
 		}
 
-		c.Set("username", claims.Username)
 		c.Next()
 	}
 }
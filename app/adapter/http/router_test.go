@@ -0,0 +1,110 @@
+package http
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/model"
+	"testing"
+	"time"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keySet := newTestKeys(t).keySet(t, "hs")
+
+	sign := func(subject string) string {
+		claims := testClaims("", jwt.NewNumericDate(time.Now().Add(time.Hour)))
+		claims.Subject = subject
+		token, err := keySet.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		userId        string
+	}{
+		{name: "bearer token", authorization: "Bearer " + sign("user-1"), status: http.StatusOK, userId: "user-1"},
+		{name: "token without scheme", authorization: sign("user-1"), status: http.StatusOK, userId: "user-1"},
+		{name: "missing token", status: http.StatusUnauthorized},
+		{name: "invalid token", authorization: "Bearer invalid", status: http.StatusUnauthorized},
+		{name: "token without subject", authorization: "Bearer " + sign(""), status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var actor *model.Actor
+			engine := gin.New()
+			r := &router{controller: &Controller{}, keys: keySet, log: nopLogger{}}
+			engine.GET("/", r.authMiddleware(), func(c *gin.Context) {
+				actor = model.ActorFromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			response := httptest.NewRecorder()
+			engine.ServeHTTP(response, request)
+
+			if response.Code != tt.status {
+				t.Fatalf("status = %d, want %d", response.Code, tt.status)
+			}
+			if tt.userId != "" && (actor == nil || actor.UserId != tt.userId) {
+				t.Fatalf("actor = %+v, want %s", actor, tt.userId)
+			}
+		})
+	}
+}
+
+func TestPolicyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keySet := newTestKeys(t).keySet(t, "hs")
+
+	tests := []struct {
+		name   string
+		role   model.Role
+		roles  []model.Role
+		status int
+	}{
+		{name: "admin on an admin route", role: model.RoleAdmin, roles: []model.Role{model.RoleAdmin}, status: http.StatusOK},
+		{name: "moderator on a staff route", role: model.RoleModerator, roles: []model.Role{model.RoleModerator, model.RoleAdmin}, status: http.StatusOK},
+		{name: "moderator on an admin route", role: model.RoleModerator, roles: []model.Role{model.RoleAdmin}, status: http.StatusForbidden},
+		{name: "user on a staff route", role: model.RoleUser, roles: []model.Role{model.RoleModerator, model.RoleAdmin}, status: http.StatusForbidden},
+		{name: "token without role", roles: []model.Role{model.RoleModerator, model.RoleAdmin}, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := testClaims("", jwt.NewNumericDate(time.Now().Add(time.Hour)))
+			claims.Role = tt.role
+			token, err := keySet.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			engine := gin.New()
+			engine.ContextWithFallback = true
+			r := &router{controller: &Controller{}, keys: keySet, log: nopLogger{}}
+			engine.GET("/", r.authMiddleware(), policyMiddleware(tt.roles...), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set("Authorization", "Bearer "+token)
+			response := httptest.NewRecorder()
+			engine.ServeHTTP(response, request)
+
+			if response.Code != tt.status {
+				t.Fatalf("status = %d, want %d", response.Code, tt.status)
+			}
+		})
+	}
+}
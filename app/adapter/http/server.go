@@ -0,0 +1,148 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+	"pixelPromo/config"
+	"time"
+)
+
+type serverConfig struct {
+	address           string
+	readHeaderTimeout time.Duration
+	readTimeout       time.Duration
+	writeTimeout      time.Duration
+	idleTimeout       time.Duration
+	shutdownTimeout   time.Duration
+	maxHeaderBytes    int
+	maxBodyBytes      int64
+	maxUploadBytes    int64
+	trustedProxies    []string
+	trustedPlatform   string
+	remoteIPHeaders   []string
+	tlsEnabled        bool
+	tlsCertFile       string
+	tlsKeyFile        string
+}
+
+func newServerConfig(cfg *config.Config) serverConfig {
+	return serverConfig{
+		address:           cfg.Viper.GetString("service.http.address"),
+		readHeaderTimeout: cfg.Viper.GetDuration("service.http.read-header-timeout"),
+		readTimeout:       cfg.Viper.GetDuration("service.http.read-timeout"),
+		writeTimeout:      cfg.Viper.GetDuration("service.http.write-timeout"),
+		idleTimeout:       cfg.Viper.GetDuration("service.http.idle-timeout"),
+		shutdownTimeout:   cfg.Viper.GetDuration("service.http.shutdown-timeout"),
+		maxHeaderBytes:    cfg.Viper.GetInt("service.http.max-header-bytes"),
+		maxBodyBytes:      cfg.Viper.GetInt64("service.http.max-body-bytes"),
+		maxUploadBytes:    cfg.Viper.GetInt64("service.http.max-upload-bytes"),
+		trustedProxies:    cfg.Viper.GetStringSlice("service.http.trusted-proxies"),
+		trustedPlatform:   cfg.Viper.GetString("service.http.trusted-platform"),
+		remoteIPHeaders:   cfg.Viper.GetStringSlice("service.http.remote-ip-headers"),
+		tlsEnabled:        cfg.Viper.GetBool("service.http.tls.enabled"),
+		tlsCertFile:       cfg.Viper.GetString("service.http.tls.cert-file"),
+		tlsKeyFile:        cfg.Viper.GetString("service.http.tls.key-file"),
+	}
+}
+
+func (r *router) newEngine() (*gin.Engine, error) {
+	if r.env != config.Local {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	engine := gin.New()
+	engine.Use(r.tracing, r.requestMiddleware(), r.metricsMiddleware(), r.recoveryMiddleware())
+
+	// Without trusted proxies ClientIP ignores forwarding headers, so lockouts and
+	// sessions see the real peer address instead of a client supplied value.
+	if err := engine.SetTrustedProxies(r.server.trustedProxies); err != nil {
+		return nil, fmt.Errorf("service.http.trusted-proxies: %w", err)
+	}
+	engine.TrustedPlatform = r.server.trustedPlatform
+	if len(r.server.remoteIPHeaders) > 0 {
+		engine.RemoteIPHeaders = r.server.remoteIPHeaders
+	}
+
+	r.setup(engine)
+	return engine, nil
+}
+
+func (r *router) Start() error {
+	engine, err := r.newEngine()
+	if err != nil {
+		return err
+	}
+
+	var tlsConfig *tls.Config
+	if r.server.tlsEnabled {
+		certificate, err := tls.LoadX509KeyPair(r.server.tlsCertFile, r.server.tlsKeyFile)
+		if err != nil {
+			return fmt.Errorf("service.http.tls: %w", err)
+		}
+		tlsConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{certificate},
+		}
+	}
+
+	listener, err := net.Listen("tcp", r.server.address)
+	if err != nil {
+		return err
+	}
+
+	r.httpServer = &http.Server{
+		Handler:           engine,
+		ReadHeaderTimeout: r.server.readHeaderTimeout,
+		ReadTimeout:       r.server.readTimeout,
+		WriteTimeout:      r.server.writeTimeout,
+		IdleTimeout:       r.server.idleTimeout,
+		MaxHeaderBytes:    r.server.maxHeaderBytes,
+		TLSConfig:         tlsConfig,
+	}
+
+	go func() {
+		var err error
+		if tlsConfig != nil {
+			err = r.httpServer.ServeTLS(listener, "", "")
+		} else {
+			err = r.httpServer.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			r.log.Error(err.Error())
+		}
+	}()
+
+	r.log.Info("http server started", config.F("address", listener.Addr().String()), config.F("tls", r.server.tlsEnabled))
+	return nil
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests until
+// shutdown-timeout (or the fx stop deadline) expires, then closes what is left.
+func (r *router) Shutdown(ctx context.Context) error {
+	if r.httpServer == nil {
+		return nil
+	}
+
+	if r.server.shutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.server.shutdownTimeout)
+		defer cancel()
+	}
+
+	err := r.httpServer.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		r.log.Warn("http server shutdown deadline exceeded, closing remaining connections")
+		return r.httpServer.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	r.log.Info("http server stopped")
+	return nil
+}
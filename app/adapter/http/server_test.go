@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace/noop"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/config"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...config.Field)      {}
+func (nopLogger) Info(string, ...config.Field)       {}
+func (nopLogger) Warn(string, ...config.Field)       {}
+func (nopLogger) Error(string, ...config.Field)      {}
+func (nopLogger) Panic(string, ...config.Field)      {}
+func (nopLogger) Fatal(string, ...config.Field)      {}
+func (nopLogger) With(...config.Field) config.Logger { return nopLogger{} }
+func (nopLogger) Flush() error                       { return nil }
+
+func newTestRouter(t *testing.T, settings map[string]any) *router {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	v := viper.New()
+	v.Set("service.http.shutdown-timeout", "1s")
+	for key, value := range settings {
+		v.Set(key, value)
+	}
+	cfg := &config.Config{Viper: v, Env: config.Local}
+	return NewRouter(&Controller{}, newTestKeys(t).keySet(t, "hs"), cfg, nopLogger{}, prometheus.NewRegistry(), noop.NewTracerProvider(), nil).(*router)
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		settings   map[string]any
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "forwarding headers ignored without trusted proxies",
+			remoteAddr: "10.0.0.1:4000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "forwarding headers from a trusted proxy",
+			settings:   map[string]any{"service.http.trusted-proxies": []string{"10.0.0.0/8"}},
+			remoteAddr: "10.0.0.1:4000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "1.2.3.4",
+		},
+		{
+			name:       "forwarding headers from an untrusted peer",
+			settings:   map[string]any{"service.http.trusted-proxies": []string{"10.0.0.0/8"}},
+			remoteAddr: "192.168.0.1:4000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4"},
+			want:       "192.168.0.1",
+		},
+		{
+			name:       "configured remote ip headers",
+			settings:   map[string]any{"service.http.trusted-proxies": []string{"10.0.0.0/8"}, "service.http.remote-ip-headers": []string{"X-Real-IP"}},
+			remoteAddr: "10.0.0.1:4000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4", "X-Real-IP": "5.6.7.8"},
+			want:       "5.6.7.8",
+		},
+		{
+			name:       "trusted platform header",
+			settings:   map[string]any{"service.http.trusted-platform": "CF-Connecting-IP"},
+			remoteAddr: "10.0.0.1:4000",
+			headers:    map[string]string{"CF-Connecting-IP": "9.9.9.9"},
+			want:       "9.9.9.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, err := newTestRouter(t, tt.settings).newEngine()
+			if err != nil {
+				t.Fatal(err)
+			}
+			engine.GET("/test/ip", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, ctx.ClientIP())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Body.String() != tt.want {
+				t.Fatalf("client ip = %s, want %s", w.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStartRejects(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+
+	tests := []struct {
+		name     string
+		settings map[string]any
+	}{
+		{name: "busy port", settings: map[string]any{"service.http.address": busy.Addr().String()}},
+		{name: "invalid trusted proxy", settings: map[string]any{"service.http.address": "127.0.0.1:0", "service.http.trusted-proxies": []string{"not a cidr"}}},
+		{name: "missing tls key pair", settings: map[string]any{"service.http.address": "127.0.0.1:0", "service.http.tls.enabled": true, "service.http.tls.cert-file": "missing.crt", "service.http.tls.key-file": "missing.key"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(t, tt.settings)
+			if err := r.Start(); err == nil {
+				_ = r.Shutdown(context.Background())
+				t.Fatal("server started")
+			}
+		})
+	}
+}
+
+func TestStartAndShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	r := newTestRouter(t, map[string]any{
+		"service.http.address":             address,
+		"service.http.read-header-timeout": "1s",
+	})
+	if err = r.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{Timeout: time.Second}
+	resp, err := client.Get("http://" + address + "/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	if err = r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.Get("http://" + address + "/openapi.json"); err == nil {
+		t.Fatal("server still serving after shutdown")
+	}
+}
+
+func TestShutdownBeforeStart(t *testing.T) {
+	if err := newTestRouter(t, nil).Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
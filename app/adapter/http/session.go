@@ -0,0 +1,138 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"pixelPromo/domain/model"
+	"strings"
+	"time"
+)
+
+func (r *Controller) RefreshSession(ctx *gin.Context) {
+	var request refreshTokenRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	user, refreshToken, err := r.handler.RefreshSession(ctx, request.RefreshToken, sessionClient(ctx))
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	r.issueTokens(ctx, user, &refreshToken)
+}
+
+func (r *Controller) Logout(ctx *gin.Context) {
+	var request refreshTokenRequest
+	err := bindJSON(ctx, &request)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	err = r.handler.RevokeSession(ctx, request.RefreshToken)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (r *Controller) LogoutAll(ctx *gin.Context) {
+	err := r.handler.RevokeAllSessions(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (r *Controller) GetSessions(ctx *gin.Context) {
+	sessions, err := r.handler.GetActiveSessions(ctx)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	if len(sessions) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, mapSlice(sessions, newSessionResponse))
+}
+
+func (r *Controller) DeleteSession(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	if len(strings.TrimSpace(id)) == 0 {
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	err := r.handler.RevokeUserSession(ctx, id)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (r *Controller) issueTokens(ctx *gin.Context, user *model.User, refreshToken *string) {
+	response, err := r.createTokens(ctx, user, refreshToken)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func (r *Controller) createTokens(ctx *gin.Context, user *model.User, refreshToken *string) (*tokenResponse, error) {
+	if refreshToken == nil {
+		token, err := r.handler.CreateSession(ctx, user, sessionClient(ctx))
+		if err != nil {
+			return nil, err
+		}
+		refreshToken = &token
+	}
+
+	now := time.Now()
+	expirationTime := now.Add(r.accessTokenTTL)
+	claims := &Claims{
+		Email: user.Email,
+		Role:  user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.Id,
+			ExpiresAt: jwt.NewNumericDate(expirationTime),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+
+	tokenString, err := r.keys.Sign(claims)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate token: %w", err)
+	}
+
+	return &tokenResponse{
+		Token:        tokenString,
+		RefreshToken: *refreshToken,
+		ExpiresAt:    expirationTime,
+		User:         newUserResponse(user),
+	}, nil
+}
+
+func sessionClient(ctx *gin.Context) *model.SessionClient {
+	return &model.SessionClient{
+		Device: ctx.Request.UserAgent(),
+		Ip:     ctx.ClientIP(),
+	}
+}
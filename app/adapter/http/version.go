@@ -0,0 +1,70 @@
+package http
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"pixelPromo/config"
+	"strings"
+	"time"
+)
+
+const routePrefixKey = "routePrefix"
+
+type apiVersion struct {
+	prefix       string
+	successor    string
+	deprecatedAt time.Time
+	sunsetAt     time.Time
+	routes       func(*gin.RouterGroup)
+}
+
+// newAPIVersion reads the deprecation schedule of a version from
+// service.http.versions.<name>; a version without deprecated-at is current.
+func newAPIVersion(cfg *config.Config, name, prefix, successor string, routes func(*gin.RouterGroup)) apiVersion {
+	version := apiVersion{
+		prefix:    prefix,
+		successor: successor,
+		routes:    routes,
+	}
+
+	var err error
+	if value := cfg.Viper.GetString(fmt.Sprintf("service.http.versions.%s.deprecated-at", name)); value != "" {
+		if version.deprecatedAt, err = time.Parse(time.RFC3339, value); err != nil {
+			panic(fmt.Errorf("service.http.versions.%s.deprecated-at: %w", name, err))
+		}
+	}
+	if value := cfg.Viper.GetString(fmt.Sprintf("service.http.versions.%s.sunset-at", name)); value != "" {
+		if version.sunsetAt, err = time.Parse(time.RFC3339, value); err != nil {
+			panic(fmt.Errorf("service.http.versions.%s.sunset-at: %w", name, err))
+		}
+	}
+	return version
+}
+
+// versionMiddleware records the version prefix so route based policies work on
+// every version, and announces deprecation (RFC 9745) and sunset (RFC 8594) dates
+// together with a link to the same resource on the successor version.
+func (r *router) versionMiddleware(version apiVersion) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(routePrefixKey, version.prefix)
+
+		if !version.deprecatedAt.IsZero() {
+			c.Header("Deprecation", fmt.Sprintf("@%d", version.deprecatedAt.Unix()))
+			if !version.sunsetAt.IsZero() {
+				c.Header("Sunset", version.sunsetAt.UTC().Format(http.TimeFormat))
+			}
+			if version.successor != "" {
+				successor := version.successor + strings.TrimPrefix(c.Request.URL.Path, version.prefix)
+				c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// routePath is the matched route without its version prefix, e.g. "/promotions/:id".
+func routePath(c *gin.Context) string {
+	return strings.TrimPrefix(c.FullPath(), c.GetString(routePrefixKey))
+}
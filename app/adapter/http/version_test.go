@@ -0,0 +1,103 @@
+package http
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/config"
+	"pixelPromo/domain/model"
+	"strings"
+	"testing"
+)
+
+func TestVersionMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    map[string]any
+		prefix      string
+		successor   string
+		path        string
+		deprecation string
+		sunset      string
+		link        string
+	}{
+		{name: "current version", prefix: "/v1", path: "/v1/promotions/1"},
+		{
+			name:        "deprecated version",
+			settings:    map[string]any{"service.http.versions.legacy.deprecated-at": "2026-10-18T00:00:00Z", "service.http.versions.legacy.sunset-at": "2027-04-30T00:00:00Z"},
+			successor:   "/v1",
+			path:        "/promotions/1",
+			deprecation: "@1792281600",
+			sunset:      "Fri, 30 Apr 2027 00:00:00 GMT",
+			link:        `</v1/promotions/1>; rel="successor-version"`,
+		},
+		{
+			name:        "deprecated without sunset",
+			settings:    map[string]any{"service.http.versions.legacy.deprecated-at": "2026-10-18T00:00:00Z"},
+			successor:   "/v1",
+			path:        "/promotions/1",
+			deprecation: "@1792281600",
+			link:        `</v1/promotions/1>; rel="successor-version"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			for key, value := range tt.settings {
+				v.Set(key, value)
+			}
+			name := "legacy"
+			if tt.prefix != "" {
+				name = strings.TrimPrefix(tt.prefix, "/")
+			}
+
+			gin.SetMode(gin.TestMode)
+			engine := gin.New()
+			version := newAPIVersion(&config.Config{Viper: v}, name, tt.prefix, tt.successor, nil)
+			engine.Group(tt.prefix, (&router{}).versionMiddleware(version)).GET("/promotions/:id", func(ctx *gin.Context) {
+				ctx.String(http.StatusOK, routePath(ctx))
+			})
+
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Body.String() != "/promotions/:id" {
+				t.Fatalf("route path = %s", w.Body.String())
+			}
+			if w.Header().Get("Deprecation") != tt.deprecation || w.Header().Get("Sunset") != tt.sunset || w.Header().Get("Link") != tt.link {
+				t.Fatalf("headers = %v", w.Header())
+			}
+		})
+	}
+}
+
+func TestNewAPIVersionRejectsInvalidDates(t *testing.T) {
+	for _, key := range []string{"deprecated-at", "sunset-at"} {
+		t.Run(key, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected a panic")
+				}
+			}()
+			v := viper.New()
+			v.Set("service.http.versions.v1."+key, "2026-10-18")
+			newAPIVersion(&config.Config{Viper: v}, "v1", "/v1", "", nil)
+		})
+	}
+}
+
+func TestUserResponseHidesStorageFields(t *testing.T) {
+	body, err := json.Marshal(newUserResponse(&model.User{Id: "user-1", Email: "user@mail.com", Password: "hash"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "hash") || strings.Contains(string(body), "password") {
+		t.Fatalf("body = %s", body)
+	}
+	if newUserResponse(nil) != nil {
+		t.Fatal("nil user mapped to a response")
+	}
+}
@@ -0,0 +1,47 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"pixelPromo/config"
+	"pixelPromo/domain/model"
+	"regexp"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._@-]`)
+
+type localMailer struct {
+	dir  string
+	from string
+	log  config.Logger
+}
+
+func newLocalMailer(cfg *config.Config, log config.Logger) *localMailer {
+	return &localMailer{
+		dir:  cfg.Viper.GetString("service.mail.local.dir"),
+		from: cfg.Viper.GetString("service.mail.from"),
+		log:  log,
+	}
+}
+
+func (m *localMailer) Send(ctx context.Context, email *model.Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(email.To, "_"))
+	path := filepath.Join(m.dir, fileName)
+	if err := os.WriteFile(path, buildMessage(m.from, email), 0o644); err != nil {
+		return err
+	}
+
+	m.log.Debug("email written to disk", config.F("path", path))
+	return nil
+}
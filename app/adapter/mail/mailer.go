@@ -0,0 +1,18 @@
+package mail
+
+import (
+	"pixelPromo/config"
+	"pixelPromo/domain/port"
+)
+
+func NewMailer(
+	cfg *config.Config,
+	log config.Logger,
+) port.Mailer {
+	switch cfg.Viper.GetString("service.mail.provider") {
+	case "smtp":
+		return newSMTPMailer(cfg)
+	default:
+		return newLocalMailer(cfg, log)
+	}
+}
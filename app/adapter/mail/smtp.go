@@ -0,0 +1,62 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"pixelPromo/config"
+	"pixelPromo/domain/model"
+	"time"
+)
+
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func newSMTPMailer(cfg *config.Config) *smtpMailer {
+	host := cfg.Viper.GetString("service.mail.smtp.host")
+	port := cfg.Viper.GetString("service.mail.smtp.port")
+
+	var auth smtp.Auth
+	if username := cfg.Viper.GetString("service.mail.smtp.username"); username != "" {
+		password := os.Getenv(cfg.Viper.GetString("service.mail.smtp.password-env"))
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpMailer{
+		addr: net.JoinHostPort(host, port),
+		from: cfg.Viper.GetString("service.mail.from"),
+		auth: auth,
+	}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, email *model.Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := smtp.SendMail(m.addr, m.auth, m.from, []string{email.To}, buildMessage(m.from, email))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func buildMessage(from string, email *model.Email) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", email.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(email.Body)
+	return msg.Bytes()
+}
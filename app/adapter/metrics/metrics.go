@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+)
+
+const namespace = "pixelpromo"
+
+// NewRegistry is the single registry behind /metrics. Adapters receive it as a
+// prometheus.Registerer and register their own collectors when they are built.
+func NewRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+func NewRegisterer(registry *prometheus.Registry) prometheus.Registerer {
+	return registry
+}
+
+func NewGatherer(registry *prometheus.Registry) prometheus.Gatherer {
+	return registry
+}
+
+type domainMetrics struct {
+	promotionsCreated prometheus.Counter
+	promotionStatus   *prometheus.CounterVec
+	interactions      *prometheus.CounterVec
+	logins            *prometheus.CounterVec
+	scorePoints       *prometheus.CounterVec
+}
+
+func NewDomainMetrics(registerer prometheus.Registerer) port.Metrics {
+	m := &domainMetrics{
+		promotionsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "promotions_created_total",
+			Help:      "Promotions created.",
+		}),
+		promotionStatus: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "promotion_status_changes_total",
+			Help:      "Promotion lifecycle transitions, by previous and new status.",
+		}, []string{"from", "to"}),
+		interactions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "interactions_total",
+			Help:      "Promotion interactions created or removed, by interaction type.",
+		}, []string{"type", "action"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts by method and result.",
+		}, []string{"method", "result", "reason"}),
+		scorePoints: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "score_points_total",
+			Help:      "User score points granted or revoked.",
+		}, []string{"direction"}),
+	}
+
+	registerer.MustRegister(m.promotionsCreated, m.promotionStatus, m.interactions, m.logins, m.scorePoints)
+	return m
+}
+
+func (m *domainMetrics) PromotionCreated() {
+	m.promotionsCreated.Inc()
+}
+
+func (m *domainMetrics) PromotionStatusChanged(from model.PromotionStatus, to model.PromotionStatus) {
+	m.promotionStatus.WithLabelValues(from.String(), to.String()).Inc()
+}
+
+func (m *domainMetrics) InteractionCreated(interactionType model.InteractionType) {
+	m.interactions.WithLabelValues(interactionType.String(), "created").Inc()
+}
+
+func (m *domainMetrics) InteractionRemoved(interactionType model.InteractionType) {
+	m.interactions.WithLabelValues(interactionType.String(), "removed").Inc()
+}
+
+func (m *domainMetrics) LoginSucceeded(method string) {
+	m.logins.WithLabelValues(method, "succeeded", "").Inc()
+}
+
+func (m *domainMetrics) LoginFailed(method string, reason string) {
+	m.logins.WithLabelValues(method, "failed", reason).Inc()
+}
+
+func (m *domainMetrics) ScorePointsGranted(points int) {
+	if points < 0 {
+		m.scorePoints.WithLabelValues("revoked").Add(float64(-points))
+		return
+	}
+	m.scorePoints.WithLabelValues("granted").Add(float64(points))
+}
@@ -0,0 +1,93 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"net/http/httptest"
+	"pixelPromo/domain/model"
+	"strings"
+	"testing"
+)
+
+func TestDomainMetrics(t *testing.T) {
+	tests := []struct {
+		name   string
+		record func(m *domainMetrics)
+		want   []string
+	}{
+		{
+			name:   "promotions created",
+			record: func(m *domainMetrics) { m.PromotionCreated(); m.PromotionCreated() },
+			want:   []string{`pixelpromo_promotions_created_total 2`},
+		},
+		{
+			name: "promotion status changes by transition",
+			record: func(m *domainMetrics) {
+				m.PromotionStatusChanged(model.PromotionActive, model.PromotionExpired)
+				m.PromotionStatusChanged(model.PromotionActive, model.PromotionExpired)
+				m.PromotionStatusChanged(model.PromotionSoldOut, model.PromotionActive)
+			},
+			want: []string{
+				`pixelpromo_promotion_status_changes_total{from="active",to="expired"} 2`,
+				`pixelpromo_promotion_status_changes_total{from="sold_out",to="active"} 1`,
+			},
+		},
+		{
+			name: "interactions by type and action",
+			record: func(m *domainMetrics) {
+				m.InteractionCreated(model.Like)
+				m.InteractionCreated(model.Like)
+				m.InteractionRemoved(model.Like)
+				m.InteractionCreated(model.Comment)
+			},
+			want: []string{
+				`pixelpromo_interactions_total{action="created",type="like"} 2`,
+				`pixelpromo_interactions_total{action="removed",type="like"} 1`,
+				`pixelpromo_interactions_total{action="created",type="comment"} 1`,
+			},
+		},
+		{
+			name: "logins by method, result and reason",
+			record: func(m *domainMetrics) {
+				m.LoginSucceeded("password")
+				m.LoginSucceeded("google")
+				m.LoginFailed("password", "invalid_credentials")
+				m.LoginFailed("password", "locked_out")
+			},
+			want: []string{
+				`pixelpromo_logins_total{method="password",reason="",result="succeeded"} 1`,
+				`pixelpromo_logins_total{method="google",reason="",result="succeeded"} 1`,
+				`pixelpromo_logins_total{method="password",reason="invalid_credentials",result="failed"} 1`,
+				`pixelpromo_logins_total{method="password",reason="locked_out",result="failed"} 1`,
+			},
+		},
+		{
+			name: "revoked points are counted apart",
+			record: func(m *domainMetrics) {
+				m.ScorePointsGranted(25)
+				m.ScorePointsGranted(5)
+				m.ScorePointsGranted(-10)
+			},
+			want: []string{
+				`pixelpromo_score_points_total{direction="granted"} 30`,
+				`pixelpromo_score_points_total{direction="revoked"} 10`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			tt.record(NewDomainMetrics(registry).(*domainMetrics))
+
+			w := httptest.NewRecorder()
+			promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+			for _, line := range tt.want {
+				if !strings.Contains(w.Body.String(), line+"\n") {
+					t.Errorf("missing %s in\n%s", line, w.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+	"net/http"
+	"net/url"
+	"os"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"strconv"
+)
+
+type oauth2Provider struct {
+	name        string
+	config      oauth2.Config
+	userInfoURL string
+	claims      claimMapping
+}
+
+type claimMapping struct {
+	Subject       string `mapstructure:"subject"`
+	Email         string `mapstructure:"email"`
+	EmailVerified string `mapstructure:"email-verified"`
+	Name          string `mapstructure:"name"`
+	Picture       string `mapstructure:"picture"`
+}
+
+func newOAuth2Provider(name string, v *viper.Viper) *oauth2Provider {
+	var claims claimMapping
+	if err := v.UnmarshalKey("claims", &claims); err != nil {
+		panic(fmt.Errorf("oauth provider [%s]: %w", name, err))
+	}
+	if claims.Subject == "" {
+		claims.Subject = "sub"
+	}
+
+	return &oauth2Provider{
+		name: name,
+		config: oauth2.Config{
+			ClientID:     v.GetString("client-id"),
+			ClientSecret: os.Getenv(v.GetString("client-secret-env")),
+			Scopes:       v.GetStringSlice("scopes"),
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  v.GetString("auth-url"),
+				TokenURL: v.GetString("token-url"),
+			},
+		},
+		userInfoURL: v.GetString("userinfo-url"),
+		claims:      claims,
+	}
+}
+
+func (p *oauth2Provider) Name() string {
+	return p.name
+}
+
+func (p *oauth2Provider) AuthURL(state string, verifier string, redirectURL string) string {
+	config := p.config
+	config.RedirectURL = redirectURL
+	return config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (p *oauth2Provider) Resolve(ctx context.Context, callback url.Values, verifier string, redirectURL string) (*model.ExternalIdentity, error) {
+	if providerErr := callback.Get("error"); providerErr != "" {
+		return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s authorization failed: %s", p.name, providerErr))
+	}
+
+	code := callback.Get("code")
+	if code == "" {
+		return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s authorization code is empty", p.name))
+	}
+
+	config := p.config
+	config.RedirectURL = redirectURL
+	token, err := config.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) {
+			return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s token exchange failed", p.name))
+		}
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.userInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := config.Client(ctx, token).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s userinfo returned status %d", p.name, resp.StatusCode)
+	}
+
+	var claims map[string]interface{}
+	if err = json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, err
+	}
+
+	identity := &model.ExternalIdentity{
+		Provider:      p.name,
+		Subject:       claimString(claims, p.claims.Subject),
+		Email:         claimString(claims, p.claims.Email),
+		EmailVerified: claimBool(claims, p.claims.EmailVerified),
+		Name:          claimString(claims, p.claims.Name),
+		PictureUrl:    claimString(claims, p.claims.Picture),
+	}
+	if identity.Subject == "" {
+		return nil, fmt.Errorf("%s userinfo has no subject", p.name)
+	}
+
+	return identity, nil
+}
+
+func claimString(claims map[string]interface{}, key string) string {
+	if key == "" {
+		return ""
+	}
+	switch value := claims[key].(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func claimBool(claims map[string]interface{}, key string) bool {
+	if key == "" {
+		return false
+	}
+	switch value := claims[key].(type) {
+	case bool:
+		return value
+	case string:
+		verified, _ := strconv.ParseBool(value)
+		return verified
+	default:
+		return false
+	}
+}
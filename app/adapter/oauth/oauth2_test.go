@@ -0,0 +1,172 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+func newTestOAuth2Server(t *testing.T, userInfo map[string]any) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("code") != "code-1" || r.PostForm.Get("code_verifier") != "verifier-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token":"access-1","token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(userInfo)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestOAuth2Provider(serverURL string, claims map[string]string) *oauth2Provider {
+	v := viper.New()
+	v.Set("client-id", "client-1")
+	v.Set("auth-url", serverURL+"/authorize")
+	v.Set("token-url", serverURL+"/token")
+	v.Set("userinfo-url", serverURL+"/userinfo")
+	v.Set("scopes", []string{"openid", "email"})
+	v.Set("claims", claims)
+	return newOAuth2Provider("test", v)
+}
+
+func TestOAuth2Resolve(t *testing.T) {
+	googleClaims := map[string]string{"subject": "sub", "email": "email", "email-verified": "email_verified", "name": "name", "picture": "picture"}
+	discordClaims := map[string]string{"subject": "id", "email": "email", "email-verified": "verified", "name": "global_name"}
+
+	tests := []struct {
+		name     string
+		claims   map[string]string
+		userInfo map[string]any
+		callback url.Values
+		verifier string
+		want     *model.ExternalIdentity
+		// unauthorized expects a rejection the user caused rather than a provider failure
+		unauthorized bool
+	}{
+		{
+			name:     "standard claims",
+			claims:   googleClaims,
+			userInfo: map[string]any{"sub": "g-1", "email": "user@mail.com", "email_verified": true, "name": "User", "picture": "https://pic"},
+			callback: url.Values{"code": {"code-1"}},
+			verifier: "verifier-1",
+			want:     &model.ExternalIdentity{Provider: "test", Subject: "g-1", Email: "user@mail.com", EmailVerified: true, Name: "User", PictureUrl: "https://pic"},
+		},
+		{
+			name:     "mapped claims with numeric id and string flag",
+			claims:   discordClaims,
+			userInfo: map[string]any{"id": 583231, "email": "user@mail.com", "verified": "true", "global_name": "User"},
+			callback: url.Values{"code": {"code-1"}},
+			verifier: "verifier-1",
+			want:     &model.ExternalIdentity{Provider: "test", Subject: "583231", Email: "user@mail.com", EmailVerified: true, Name: "User"},
+		},
+		{
+			name:     "unverified email",
+			claims:   googleClaims,
+			userInfo: map[string]any{"sub": "g-1", "email": "user@mail.com"},
+			callback: url.Values{"code": {"code-1"}},
+			verifier: "verifier-1",
+			want:     &model.ExternalIdentity{Provider: "test", Subject: "g-1", Email: "user@mail.com"},
+		},
+		{
+			name:         "provider error",
+			claims:       googleClaims,
+			callback:     url.Values{"error": {"access_denied"}},
+			verifier:     "verifier-1",
+			unauthorized: true,
+		},
+		{
+			name:         "missing code",
+			claims:       googleClaims,
+			callback:     url.Values{},
+			verifier:     "verifier-1",
+			unauthorized: true,
+		},
+		{
+			name:         "wrong pkce verifier",
+			claims:       googleClaims,
+			callback:     url.Values{"code": {"code-1"}},
+			verifier:     "verifier-2",
+			unauthorized: true,
+		},
+		{
+			name:     "userinfo without subject",
+			claims:   googleClaims,
+			userInfo: map[string]any{"email": "user@mail.com"},
+			callback: url.Values{"code": {"code-1"}},
+			verifier: "verifier-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestOAuth2Server(t, tt.userInfo)
+			provider := newTestOAuth2Provider(server.URL, tt.claims)
+
+			identity, err := provider.Resolve(context.Background(), tt.callback, tt.verifier, "https://app/callback")
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("identity = %+v, want an error", identity)
+				}
+				if (errs.KindOf(err) == errs.KindUnauthenticated) != tt.unauthorized {
+					t.Fatalf("error = %v, unauthorized %v", err, tt.unauthorized)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *identity != *tt.want {
+				t.Fatalf("identity = %+v, want %+v", identity, tt.want)
+			}
+		})
+	}
+}
+
+func TestOAuth2AuthURL(t *testing.T) {
+	provider := newTestOAuth2Provider("https://idp", nil)
+
+	authURL, err := url.Parse(provider.AuthURL("state-1", "verifier-1", "https://app/callback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := authURL.Query()
+	want := map[string]string{
+		"client_id":             "client-1",
+		"state":                 "state-1",
+		"redirect_uri":          "https://app/callback",
+		"code_challenge_method": "S256",
+		"response_type":         "code",
+	}
+	for key, value := range want {
+		if query.Get(key) != value {
+			t.Errorf("%s = %q, want %q", key, query.Get(key), value)
+		}
+	}
+	if challenge := query.Get("code_challenge"); challenge == "" || challenge == "verifier-1" {
+		t.Errorf("code_challenge = %q", challenge)
+	}
+}
@@ -0,0 +1,151 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const openIDNamespace = "http://specs.openid.net/auth/2.0"
+const openIDIdentifierSelect = "http://specs.openid.net/auth/2.0/identifier_select"
+
+type openIDProvider struct {
+	name       string
+	endpoint   string
+	claimedId  *regexp.Regexp
+	realm      string
+	apiKey     string
+	profileURL string
+	client     *http.Client
+}
+
+func newOpenIDProvider(name string, v *viper.Viper) *openIDProvider {
+	return &openIDProvider{
+		name:       name,
+		endpoint:   v.GetString("endpoint"),
+		claimedId:  regexp.MustCompile(v.GetString("claimed-id-pattern")),
+		realm:      v.GetString("realm"),
+		apiKey:     os.Getenv(v.GetString("api-key-env")),
+		profileURL: v.GetString("profile-url"),
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (p *openIDProvider) Name() string {
+	return p.name
+}
+
+func (p *openIDProvider) AuthURL(state string, _ string, redirectURL string) string {
+	params := url.Values{}
+	params.Set("openid.ns", openIDNamespace)
+	params.Set("openid.mode", "checkid_setup")
+	params.Set("openid.return_to", returnTo(redirectURL, state))
+	params.Set("openid.realm", p.realm)
+	params.Set("openid.identity", openIDIdentifierSelect)
+	params.Set("openid.claimed_id", openIDIdentifierSelect)
+	return p.endpoint + "?" + params.Encode()
+}
+
+func (p *openIDProvider) Resolve(ctx context.Context, callback url.Values, _ string, redirectURL string) (*model.ExternalIdentity, error) {
+	if callback.Get("openid.mode") != "id_res" {
+		return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s authorization failed", p.name))
+	}
+	if callback.Get("openid.op_endpoint") != p.endpoint {
+		return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s endpoint mismatch", p.name))
+	}
+	if callback.Get("openid.return_to") != returnTo(redirectURL, callback.Get("state")) {
+		return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s return_to mismatch", p.name))
+	}
+
+	params := url.Values{}
+	for key, values := range callback {
+		if strings.HasPrefix(key, "openid.") {
+			params[key] = values
+		}
+	}
+	params.Set("openid.mode", "check_authentication")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(string(body), "is_valid:true") {
+		return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s assertion is invalid", p.name))
+	}
+
+	match := p.claimedId.FindStringSubmatch(callback.Get("openid.claimed_id"))
+	if len(match) < 2 {
+		return nil, errs.Unauthenticated("oauth_failed", fmt.Sprintf("%s claimed id is invalid", p.name))
+	}
+
+	identity := &model.ExternalIdentity{
+		Provider: p.name,
+		Subject:  match[1],
+		Name:     fmt.Sprintf("%s_%s", p.name, match[1]),
+	}
+	p.fillProfile(ctx, identity)
+
+	return identity, nil
+}
+
+func (p *openIDProvider) fillProfile(ctx context.Context, identity *model.ExternalIdentity) {
+	if p.apiKey == "" || p.profileURL == "" {
+		return
+	}
+
+	params := url.Values{}
+	params.Set("key", p.apiKey)
+	params.Set("steamids", identity.Subject)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.profileURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	var summary struct {
+		Response struct {
+			Players []struct {
+				PersonaName string `json:"personaname"`
+				AvatarFull  string `json:"avatarfull"`
+			} `json:"players"`
+		} `json:"response"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&summary); err != nil || len(summary.Response.Players) == 0 {
+		return
+	}
+
+	identity.Name = summary.Response.Players[0].PersonaName
+	identity.PictureUrl = summary.Response.Players[0].AvatarFull
+}
+
+func returnTo(redirectURL string, state string) string {
+	return redirectURL + "?state=" + url.QueryEscape(state)
+}
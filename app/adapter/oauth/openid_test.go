@@ -0,0 +1,120 @@
+package oauth
+
+import (
+	"context"
+	"github.com/spf13/viper"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"pixelPromo/domain/errs"
+	"testing"
+)
+
+func newTestOpenIDProvider(t *testing.T, valid bool) *openIDProvider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("openid.mode") != "check_authentication" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if valid {
+			_, _ = w.Write([]byte("ns:http://specs.openid.net/auth/2.0\nis_valid:true\n"))
+			return
+		}
+		_, _ = w.Write([]byte("ns:http://specs.openid.net/auth/2.0\nis_valid:false\n"))
+	}))
+	t.Cleanup(server.Close)
+
+	v := viper.New()
+	v.Set("endpoint", server.URL)
+	v.Set("realm", "https://app")
+	v.Set("claimed-id-pattern", `^https://steamcommunity\.com/openid/id/(\d+)$`)
+	return newOpenIDProvider("steam", v)
+}
+
+func TestOpenIDResolve(t *testing.T) {
+	const redirectURL = "https://app/auth/oauth/steam/callback"
+
+	callback := func(p *openIDProvider, change func(values url.Values)) url.Values {
+		values := url.Values{
+			"state":              {"state-1"},
+			"openid.mode":        {"id_res"},
+			"openid.op_endpoint": {p.endpoint},
+			"openid.return_to":   {returnTo(redirectURL, "state-1")},
+			"openid.claimed_id":  {"https://steamcommunity.com/openid/id/76561197960287930"},
+			"openid.sig":         {"signature"},
+		}
+		if change != nil {
+			change(values)
+		}
+		return values
+	}
+
+	tests := []struct {
+		name   string
+		valid  bool
+		change func(values url.Values)
+		// subject is the expected steam id, empty when the callback is rejected
+		subject string
+	}{
+		{name: "valid assertion", valid: true, subject: "76561197960287930"},
+		{name: "assertion rejected by the provider"},
+		{
+			name:   "cancelled",
+			valid:  true,
+			change: func(values url.Values) { values.Set("openid.mode", "cancel") },
+		},
+		{
+			name:   "other endpoint",
+			valid:  true,
+			change: func(values url.Values) { values.Set("openid.op_endpoint", "https://evil/openid") },
+		},
+		{
+			name:   "state swapped",
+			valid:  true,
+			change: func(values url.Values) { values.Set("state", "state-2") },
+		},
+		{
+			name:   "claimed id outside the pattern",
+			valid:  true,
+			change: func(values url.Values) { values.Set("openid.claimed_id", "https://evil/openid/id/1") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newTestOpenIDProvider(t, tt.valid)
+
+			identity, err := provider.Resolve(context.Background(), callback(provider, tt.change), "", redirectURL)
+			if tt.subject == "" {
+				if errs.KindOf(err) != errs.KindUnauthenticated {
+					t.Fatalf("error = %v, want unauthorized", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if identity.Provider != "steam" || identity.Subject != tt.subject || identity.Email != "" || identity.EmailVerified {
+				t.Fatalf("identity = %+v", identity)
+			}
+		})
+	}
+}
+
+func TestOpenIDAuthURL(t *testing.T) {
+	provider := newTestOpenIDProvider(t, true)
+
+	authURL, err := url.Parse(provider.AuthURL("state-1", "", "https://app/callback"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := authURL.Query()
+	if query.Get("openid.mode") != "checkid_setup" || query.Get("openid.realm") != "https://app" {
+		t.Fatalf("query = %v", query)
+	}
+	if query.Get("openid.return_to") != "https://app/callback?state=state-1" {
+		t.Fatalf("return_to = %q", query.Get("openid.return_to"))
+	}
+}
@@ -0,0 +1,39 @@
+package oauth
+
+import (
+	"fmt"
+	"pixelPromo/config"
+	"pixelPromo/domain/port"
+	"sort"
+)
+
+func NewIdentityProviders(
+	cfg *config.Config,
+) []port.IdentityProvider {
+	providersCfg := cfg.Viper.GetStringMap("service.oauth.providers")
+
+	names := make([]string, 0, len(providersCfg))
+	for name := range providersCfg {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	providers := make([]port.IdentityProvider, 0, len(names))
+	for _, name := range names {
+		key := fmt.Sprintf("service.oauth.providers.%s", name)
+		if !cfg.Viper.GetBool(key + ".enabled") {
+			continue
+		}
+
+		switch providerType := cfg.Viper.GetString(key + ".type"); providerType {
+		case "oauth2":
+			providers = append(providers, newOAuth2Provider(name, cfg.Viper.Sub(key)))
+		case "openid":
+			providers = append(providers, newOpenIDProvider(name, cfg.Viper.Sub(key)))
+		default:
+			panic(fmt.Errorf("oauth provider [%s]: type [%s] not supported", name, providerType))
+		}
+	}
+
+	return providers
+}
@@ -0,0 +1,50 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+const repositoryAttempts = 3
+
+type repositoryLimiter struct {
+	rp port.Repository
+}
+
+func newRepositoryLimiter(rp port.Repository) *repositoryLimiter {
+	return &repositoryLimiter{rp: rp}
+}
+
+// Allow reads the bucket, takes a token and writes it back conditionally on the
+// bucket being unchanged, retrying when another instance won the race.
+func (l *repositoryLimiter) Allow(ctx context.Context, key string, limit model.RateLimit) (*model.RateLimitDecision, error) {
+	for attempt := 0; attempt < repositoryAttempts; attempt++ {
+		bucket, err := l.rp.GetRateLimitBucket(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if bucket == nil {
+			bucket = &model.RateLimitBucket{Key: key}
+		}
+
+		previousUpdatedAt := bucket.UpdatedAt
+		decision := bucket.Take(limit, time.Now())
+		if !decision.Allowed {
+			return &decision, nil
+		}
+
+		err = l.rp.PutRateLimitBucket(ctx, bucket, previousUpdatedAt)
+		if errors.Is(err, port.ErrConditionFailed) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &decision, nil
+	}
+
+	return &model.RateLimitDecision{Limit: limit.Limit, RetryAfter: time.Second}, nil
+}
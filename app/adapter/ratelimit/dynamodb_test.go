@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"testing"
+	"time"
+)
+
+// fakeBucketRepository applies the same condition as the DynamoDB put: the
+// bucket must still carry the updatedAt that was read.
+type fakeBucketRepository struct {
+	port.Repository
+
+	buckets map[string]model.RateLimitBucket
+	// conflicts makes the next puts fail as if another instance wrote first
+	conflicts int
+	puts      int
+}
+
+func (r *fakeBucketRepository) GetRateLimitBucket(_ context.Context, key string) (*model.RateLimitBucket, error) {
+	bucket, ok := r.buckets[key]
+	if !ok {
+		return nil, nil
+	}
+	return &bucket, nil
+}
+
+func (r *fakeBucketRepository) PutRateLimitBucket(_ context.Context, bucket *model.RateLimitBucket, previousUpdatedAt time.Time) error {
+	r.puts++
+	if r.conflicts > 0 {
+		r.conflicts--
+		return port.ErrConditionFailed
+	}
+	if !r.buckets[bucket.Key].UpdatedAt.Equal(previousUpdatedAt) {
+		return port.ErrConditionFailed
+	}
+	r.buckets[bucket.Key] = *bucket
+	return nil
+}
+
+func TestRepositoryLimiter(t *testing.T) {
+	limit := model.RateLimit{Limit: 2, Period: time.Minute}
+
+	tests := []struct {
+		name      string
+		requests  int
+		conflicts int
+		want      []bool
+		puts      int
+	}{
+		{name: "allows the burst", requests: 2, want: []bool{true, true}, puts: 2},
+		{name: "denies without writing", requests: 3, want: []bool{true, true, false}, puts: 2},
+		{name: "retries a lost race", requests: 1, conflicts: 2, want: []bool{true}, puts: 3},
+		{name: "gives up after the attempts", requests: 1, conflicts: repositoryAttempts, want: []bool{false}, puts: repositoryAttempts},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := &fakeBucketRepository{buckets: map[string]model.RateLimitBucket{}, conflicts: tt.conflicts}
+			limiter := newRepositoryLimiter(rp)
+
+			for i := 0; i < tt.requests; i++ {
+				decision, err := limiter.Allow(context.Background(), "user#1", limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if decision.Allowed != tt.want[i] {
+					t.Fatalf("request %d allowed = %v", i+1, decision.Allowed)
+				}
+				if !decision.Allowed && decision.RetryAfter <= 0 {
+					t.Fatalf("denied request %d has no retry after", i+1)
+				}
+			}
+			if rp.puts != tt.puts {
+				t.Fatalf("puts = %d, want %d", rp.puts, tt.puts)
+			}
+		})
+	}
+}
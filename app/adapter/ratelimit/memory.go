@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"sync"
+	"time"
+)
+
+const memorySweepInterval = time.Minute
+
+type memoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*model.RateLimitBucket
+	lastSweep time.Time
+}
+
+func newMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{
+		buckets:   make(map[string]*model.RateLimitBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *memoryLimiter) Allow(_ context.Context, key string, limit model.RateLimit) (*model.RateLimitDecision, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+
+	bucket, ok := m.buckets[key]
+	if !ok {
+		bucket = &model.RateLimitBucket{Key: key}
+		m.buckets[key] = bucket
+	}
+
+	decision := bucket.Take(limit, now)
+	return &decision, nil
+}
+
+// sweep drops buckets that have refilled completely, which behave exactly like
+// a missing bucket, so memory only grows with recently active keys.
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < memorySweepInterval {
+		return
+	}
+
+	for key, bucket := range m.buckets {
+		if now.Unix() > bucket.Ttl {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
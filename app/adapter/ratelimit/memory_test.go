@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	limit := model.RateLimit{Limit: 3, Period: time.Minute}
+
+	tests := []struct {
+		name string
+		keys []string
+		want []bool
+	}{
+		{name: "allows the burst", keys: []string{"a", "a", "a"}, want: []bool{true, true, true}},
+		{name: "denies past the burst", keys: []string{"a", "a", "a", "a"}, want: []bool{true, true, true, false}},
+		{name: "keys are independent", keys: []string{"a", "a", "a", "b", "a"}, want: []bool{true, true, true, true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newMemoryLimiter()
+			for i, key := range tt.keys {
+				decision, err := limiter.Allow(context.Background(), key, limit)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if decision.Allowed != tt.want[i] {
+					t.Fatalf("request %d for %s allowed = %v", i+1, key, decision.Allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	limiter := newMemoryLimiter()
+	limit := model.RateLimit{Limit: 1, Period: time.Second}
+
+	if _, err := limiter.Allow(context.Background(), "stale", limit); err != nil {
+		t.Fatal(err)
+	}
+	limiter.buckets["stale"].Ttl = time.Now().Add(-time.Minute).Unix()
+	limiter.lastSweep = time.Now().Add(-2 * memorySweepInterval)
+
+	if _, err := limiter.Allow(context.Background(), "fresh", limit); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := limiter.buckets["stale"]; ok {
+		t.Fatal("refilled bucket was not swept")
+	}
+	if _, ok := limiter.buckets["fresh"]; !ok {
+		t.Fatal("active bucket was swept")
+	}
+}
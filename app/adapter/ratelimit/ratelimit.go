@@ -0,0 +1,20 @@
+package ratelimit
+
+import (
+	"fmt"
+	"pixelPromo/config"
+	"pixelPromo/domain/port"
+)
+
+// NewRateLimiter picks the bucket store from service.rate-limit.store: memory keeps
+// buckets in this process only, dynamodb shares them between instances.
+func NewRateLimiter(cfg *config.Config, rp port.Repository) port.RateLimiter {
+	switch store := cfg.Viper.GetString("service.rate-limit.store"); store {
+	case "memory", "":
+		return newMemoryLimiter()
+	case "dynamodb":
+		return newRepositoryLimiter(rp)
+	default:
+		panic(fmt.Errorf("service.rate-limit.store [%s] not supported", store))
+	}
+}
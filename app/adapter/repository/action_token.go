@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+)
+
+func (r repository) CreateActionToken(ctx context.Context, token *model.ActionToken) error {
+	item, err := attributevalue.MarshalMap(token)
+	if err != nil {
+		return err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.action-token")
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+	return err
+}
+
+func (r repository) GetActionTokenById(ctx context.Context, id string) (*model.ActionToken, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.action-token")
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Item == nil {
+		return nil, nil
+	}
+
+	var token model.ActionToken
+	err = attributevalue.UnmarshalMap(result.Item, &token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r repository) ConsumeActionToken(ctx context.Context, id string) error {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.action-token")
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET used = :true"),
+		ConditionExpression: aws.String("attribute_exists(id) AND used = :false"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":true":  &types.AttributeValueMemberBOOL{Value: true},
+			":false": &types.AttributeValueMemberBOOL{Value: false},
+		},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+func (r repository) CreateOrUpdateApiKey(ctx context.Context, apiKey *model.ApiKey) error {
+	item, err := attributevalue.MarshalMap(apiKey)
+	if err != nil {
+		return err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.api-key")
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+	return err
+}
+
+// TouchApiKey only moves lastUsedAt, and never on a revoked key, so it cannot
+// undo a revocation that landed after the key was read.
+func (r repository) TouchApiKey(ctx context.Context, id string, usedAt time.Time) error {
+	lastUsedAt, err := attributevalue.Marshal(usedAt)
+	if err != nil {
+		return err
+	}
+
+	return r.updateApiKey(ctx, id, "SET lastUsedAt = :lastUsedAt", "attribute_exists(id) AND revoked = :false", map[string]types.AttributeValue{
+		":lastUsedAt": lastUsedAt,
+		":false":      &types.AttributeValueMemberBOOL{Value: false},
+	})
+}
+
+func (r repository) RevokeApiKeyById(ctx context.Context, id string) error {
+	return r.updateApiKey(ctx, id, "SET revoked = :true", "attribute_exists(id)", map[string]types.AttributeValue{
+		":true": &types.AttributeValueMemberBOOL{Value: true},
+	})
+}
+
+func (r repository) updateApiKey(ctx context.Context, id string, update string, condition string, values map[string]types.AttributeValue) error {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.api-key")
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
+func (r repository) GetApiKeyById(ctx context.Context, id string) (*model.ApiKey, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.api-key")
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Item == nil {
+		return nil, nil
+	}
+
+	var apiKey model.ApiKey
+	err = attributevalue.UnmarshalMap(result.Item, &apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r repository) GetApiKeysByUserId(ctx context.Context, userId string) ([]model.ApiKey, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.api-key")
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("UserIdIndex"),
+		KeyConditionExpression: aws.String("userId = :userId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":userId": &types.AttributeValueMemberS{Value: userId},
+		},
+	})
+
+	var apiKeys []model.ApiKey
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageKeys []model.ApiKey
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageKeys)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, pageKeys...)
+	}
+
+	return apiKeys, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
@@ -10,6 +11,7 @@ import (
 	"pixelPromo/config"
 	"pixelPromo/domain/model"
 	"pixelPromo/domain/port"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -103,6 +105,36 @@ func (r repository) GetInteractionsByPromotionId(ctx context.Context, id string)
 	return interactions, nil
 }
 
+func (r repository) GetInteractionsByPromotionIdAndType(ctx context.Context, promotionId string, interactionType model.InteractionType) ([]model.PromotionInteraction, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion-interaction")
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String("PromotionIdIndex"),
+		KeyConditionExpression: aws.String("promotionId = :promotionId"),
+		FilterExpression:       aws.String("interactionType = :interactionType"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":promotionId":     &types.AttributeValueMemberS{Value: promotionId},
+			":interactionType": &types.AttributeValueMemberS{Value: string(interactionType)},
+		},
+	})
+
+	var interactions []model.PromotionInteraction
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageInteractions []model.PromotionInteraction
+		if err = attributevalue.UnmarshalListOfMaps(page.Items, &pageInteractions); err != nil {
+			return nil, err
+		}
+		interactions = append(interactions, pageInteractions...)
+	}
+
+	return interactions, nil
+}
+
 func (r repository) GetInteractionsByUserId(ctx context.Context, id string) ([]model.PromotionInteraction, error) {
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion-interaction")
 	result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
@@ -151,57 +183,42 @@ func (r repository) GetInteractionsByUserIdWithPromotionId(ctx context.Context,
 	return interactions, nil
 }
 
-func (r repository) GetInteractionsByTypeWithPromotionId(ctx context.Context, interactionType model.InteractionType, id string) ([]model.PromotionInteraction, error) {
-	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion-interaction")
-	result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
-		TableName:        aws.String(tableName),
-		FilterExpression: aws.String("promotionId = :promotionId AND interactionType = :interactionType"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":promotionId":     &types.AttributeValueMemberS{Value: id},
-			":interactionType": &types.AttributeValueMemberS{Value: interactionType.String()},
-		},
-	})
-	if err != nil {
-		return nil, err
-	}
-	if result == nil || result.Items == nil {
-		return nil, nil
-	}
-	var interactions []model.PromotionInteraction
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &interactions)
-	if err != nil {
-		return nil, err
-	}
+func (r repository) GetInteractionsByTypeWithPromotionId(ctx context.Context, interactionType model.InteractionType, id string, page *model.PageRequest) ([]model.PromotionInteraction, string, error) {
+	return r.getInteractionsByTypeWith(ctx, interactionType, "promotionId", id, page)
+}
 
-	return interactions, nil
+func (r repository) GetInteractionsByTypeWithUserId(ctx context.Context, interactionType model.InteractionType, id string, page *model.PageRequest) ([]model.PromotionInteraction, string, error) {
+	return r.getInteractionsByTypeWith(ctx, interactionType, "userId", id, page)
 }
 
-func (r repository) GetInteractionsByTypeWithUserId(ctx context.Context, interactionType model.InteractionType, id string) ([]model.PromotionInteraction, error) {
+func (r repository) getInteractionsByTypeWith(ctx context.Context, interactionType model.InteractionType, attribute string, id string, page *model.PageRequest) ([]model.PromotionInteraction, string, error) {
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion-interaction")
-	result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+	items, nextCursor, err := r.scanPage(ctx, &dynamodb.ScanInput{
 		TableName:        aws.String(tableName),
-		FilterExpression: aws.String("userId = :userId AND interactionType = :interactionType"),
+		FilterExpression: aws.String("#key = :value AND interactionType = :interactionType"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": attribute,
+		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":userId":          &types.AttributeValueMemberS{Value: id},
+			":value":           &types.AttributeValueMemberS{Value: id},
 			":interactionType": &types.AttributeValueMemberS{Value: interactionType.String()},
 		},
-	})
+	}, page.Limit, page.Cursor, []string{"id"})
 	if err != nil {
-		return nil, err
-	}
-	if result == nil || result.Items == nil {
-		return nil, nil
+		return nil, "", err
 	}
+
 	var interactions []model.PromotionInteraction
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &interactions)
+	err = attributevalue.UnmarshalListOfMaps(items, &interactions)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return interactions, nil
+	return interactions, nextCursor, nil
 }
 
 func (r repository) CreateOrUpdateUser(ctx context.Context, user *model.User) error {
+	user.UpdatedAt = time.Now()
 	item, err := attributevalue.MarshalMap(user)
 	if err != nil {
 		return err
@@ -216,6 +233,59 @@ func (r repository) CreateOrUpdateUser(ctx context.Context, user *model.User) er
 	return err
 }
 
+// UpdateUserPassword only sets the password, so it neither recreates a user
+// deleted meanwhile nor reverts a concurrent profile edit.
+func (r repository) UpdateUserPassword(ctx context.Context, id string, password string) error {
+	return r.updateUser(ctx, id, "SET password = :password", "attribute_exists(id)", map[string]types.AttributeValue{
+		":password": &types.AttributeValueMemberS{Value: password},
+	})
+}
+
+// VerifyUserEmail only lands while the user still has the address the token
+// was sent to.
+func (r repository) VerifyUserEmail(ctx context.Context, id string, email string) error {
+	return r.updateUser(ctx, id, "SET emailVerified = :true", "attribute_exists(id) AND email = :email", map[string]types.AttributeValue{
+		":true":  &types.AttributeValueMemberBOOL{Value: true},
+		":email": &types.AttributeValueMemberS{Value: email},
+	})
+}
+
+// ResetUnverifiedUser drops the password and marks the email verified, only
+// while the account is still unverified and registered with that email.
+func (r repository) ResetUnverifiedUser(ctx context.Context, id string, email string) error {
+	return r.updateUser(ctx, id, "SET password = :empty, emailVerified = :true", "attribute_exists(id) AND emailVerified = :false AND email = :email", map[string]types.AttributeValue{
+		":empty": &types.AttributeValueMemberS{Value: ""},
+		":true":  &types.AttributeValueMemberBOOL{Value: true},
+		":false": &types.AttributeValueMemberBOOL{Value: false},
+		":email": &types.AttributeValueMemberS{Value: email},
+	})
+}
+
+func (r repository) updateUser(ctx context.Context, id string, update string, condition string, values map[string]types.AttributeValue) error {
+	updatedAt, err := attributevalue.Marshal(time.Now())
+	if err != nil {
+		return err
+	}
+	values[":updatedAt"] = updatedAt
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user")
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:          aws.String(update + ", updatedAt = :updatedAt"),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
 func (r repository) CreateOrUpdateUserScore(ctx context.Context, score *model.UserScore) error {
 	item, err := attributevalue.MarshalMap(score)
 	if err != nil {
@@ -230,6 +300,32 @@ func (r repository) CreateOrUpdateUserScore(ctx context.Context, score *model.Us
 	return err
 }
 
+// AddUserScore adds points to totalScore atomically and stores the elo and level
+// derived from the user as read, concurrent grants never lose points.
+func (r repository) AddUserScore(ctx context.Context, user *model.User, points int) error {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user")
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: user.Id},
+		},
+		UpdateExpression:         aws.String("ADD totalScore :points SET elo = :elo, #level = :level"),
+		ConditionExpression:      aws.String("attribute_exists(id)"),
+		ExpressionAttributeNames: map[string]string{"#level": "level"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":points": &types.AttributeValueMemberN{Value: strconv.Itoa(points)},
+			":elo":    &types.AttributeValueMemberS{Value: user.Elo},
+			":level":  &types.AttributeValueMemberN{Value: strconv.Itoa(user.Level)},
+		},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
 func (r repository) GetAllUserScoreByTimeWithUserId(ctx context.Context, userId string, createdAt time.Time) ([]model.UserScore, error) {
 	createdAtISO := createdAt.Format(time.RFC3339)
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-score")
@@ -264,7 +360,7 @@ func (r repository) GetAllUserScoreByTime(ctx context.Context, createdAt time.Ti
 	createdAtISO := createdAt.Format(time.RFC3339)
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-score")
 
-	result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+	paginator := dynamodb.NewScanPaginator(r.client, &dynamodb.ScanInput{
 		TableName:        aws.String(tableName),
 		IndexName:        aws.String("CreatedAtIndex"),
 		FilterExpression: aws.String("createdAt > :createdAt"),
@@ -272,18 +368,20 @@ func (r repository) GetAllUserScoreByTime(ctx context.Context, createdAt time.Ti
 			":createdAt": &types.AttributeValueMemberS{Value: createdAtISO},
 		},
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to scan table %s using index %s: %w", tableName, "CreatedAtIndex", err)
-	}
-
-	if len(result.Items) == 0 {
-		return nil, nil
-	}
 
 	var scores []model.UserScore
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &scores)
-	if err != nil {
-		return nil, err
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table %s using index %s: %w", tableName, "CreatedAtIndex", err)
+		}
+
+		var pageScores []model.UserScore
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageScores)
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, pageScores...)
 	}
 
 	return scores, nil
@@ -304,7 +402,7 @@ func (r repository) GetUserById(ctx context.Context, id string) (*model.User, er
 		return nil, nil
 	}
 	var user model.User
-	err = attributevalue.UnmarshalMap(result.Item, &user)
+	err = unmarshalUser(result.Item, &user)
 	if err != nil {
 		return nil, err
 	}
@@ -312,58 +410,45 @@ func (r repository) GetUserById(ctx context.Context, id string) (*model.User, er
 	return &user, nil
 }
 
-func (r repository) GetAllUsers(ctx context.Context) ([]model.User, error) {
+func (r repository) GetAllUsers(ctx context.Context, page *model.PageRequest) ([]model.User, string, error) {
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user")
-	result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+	items, nextCursor, err := r.scanPage(ctx, &dynamodb.ScanInput{
 		TableName: aws.String(tableName),
-	})
-
+	}, page.Limit, page.Cursor, []string{"id"})
 	if err != nil {
-		return nil, err
-	}
-	if result == nil || result.Items == nil || len(result.Items) == 0 {
-		return nil, nil
+		return nil, "", err
 	}
 
-	var users []model.User
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &users)
-	if err != nil {
-		return nil, err
+	users := make([]model.User, len(items))
+	for i, item := range items {
+		if err = unmarshalUser(item, &users[i]); err != nil {
+			return nil, "", err
+		}
 	}
 
-	return users, nil
+	return users, nextCursor, nil
 }
-func (r repository) GetUserByEmailAndPassword(ctx context.Context, email string, password string) (*model.User, error) {
-	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user")
-	result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
-		TableName:        aws.String(tableName),
-		FilterExpression: aws.String("email = :email AND password = :password"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":email":    &types.AttributeValueMemberS{Value: email},
-			":password": &types.AttributeValueMemberS{Value: password},
-		},
-	})
 
-	if err != nil {
-		return nil, err
+// unmarshalUser treats users stored before email verification existed as
+// verified, they signed up when no verification was asked of anyone.
+func unmarshalUser(item attributeMap, user *model.User) error {
+	if err := attributevalue.UnmarshalMap(item, user); err != nil {
+		return err
 	}
-	if result == nil || result.Items == nil || len(result.Items) == 0 {
-		return nil, nil
+	if _, ok := item["emailVerified"]; !ok {
+		user.EmailVerified = true
 	}
-
-	var users []model.User
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &users)
-	if err != nil {
-		return nil, err
-	}
-
-	return &users[0], nil
+	return nil
 }
 func (r repository) CreateOrUpdatePromotion(ctx context.Context, promotion *model.Promotion) error {
+	promotion.UpdatedAt = time.Now()
 	item, err := attributevalue.MarshalMap(promotion)
 	if err != nil {
 		return err
 	}
+	for attribute, value := range promotionSortKeys(promotion) {
+		item[attribute] = value
+	}
 
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
 	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
@@ -386,15 +471,27 @@ func (r repository) DeletePromotion(ctx context.Context, promotionId string) err
 }
 
 func (r repository) DeleteUser(ctx context.Context, userId string) error {
+	user, err := r.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
 
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user")
-	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
-		Key: map[string]types.AttributeValue{
-			"id": &types.AttributeValueMemberS{Value: userId},
+	items := []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName: aws.String(tableName),
+				Key: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: userId},
+				},
+			},
 		},
-		TableName: aws.String(tableName),
-	})
-	return err
+	}
+	if user != nil && user.Email != "" {
+		items = append(items, r.userEmailDelete(user.Email, userId))
+	}
+
+	return r.transactWrite(ctx, items)
 }
 
 func (r repository) GetPromotionById(ctx context.Context, id string) (*model.Promotion, error) {
@@ -420,11 +517,18 @@ func (r repository) GetPromotionById(ctx context.Context, id string) (*model.Pro
 	return &promotion, nil
 }
 
-func (r repository) GetPromotionsWithParams(ctx context.Context, query *model.PromotionQuery) ([]model.Promotion, error) {
+func (r repository) GetPromotionsWithParams(ctx context.Context, query *model.PromotionQuery) ([]model.Promotion, string, error) {
 	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
 
+	index, ok := promotionSortIndexes[query.Sort]
+	if !ok {
+		index = promotionSortIndexes[model.SortNewest]
+	}
+
 	var filterExprs []string
-	exprAttrValues := map[string]types.AttributeValue{}
+	exprAttrValues := map[string]types.AttributeValue{
+		":feed": &types.AttributeValueMemberS{Value: promotionFeed},
+	}
 
 	if query.UserId != "" {
 		userIdExpr := "userId = :userId"
@@ -447,45 +551,34 @@ func (r repository) GetPromotionsWithParams(ctx context.Context, query *model.Pr
 		}
 	}
 
-	filterExpr := ""
-	if len(filterExprs) > 0 {
-		filterExpr = strings.Join(filterExprs, " AND ")
+	visibilityExpr, err := promotionVisibilityFilter(query, time.Now(), exprAttrValues)
+	if err != nil {
+		return nil, "", err
 	}
+	filterExprs = append(filterExprs, visibilityExpr)
 
-	limit := &query.Limit
-	if query.Limit == 0 {
-		limit = nil
+	queryInput := dynamodb.QueryInput{
+		TableName:                 aws.String(tableName),
+		IndexName:                 aws.String(index.name),
+		KeyConditionExpression:    aws.String("feed = :feed"),
+		FilterExpression:          aws.String(strings.Join(filterExprs, " AND ")),
+		ExpressionAttributeNames:  map[string]string{"#status": "status"},
+		ExpressionAttributeValues: exprAttrValues,
+		ScanIndexForward:          aws.Bool(index.ascending),
 	}
 
-	var scanInput dynamodb.ScanInput
-	if len(filterExprs) > 0 {
-		scanInput = dynamodb.ScanInput{
-			TableName:                 aws.String(tableName),
-			FilterExpression:          aws.String(filterExpr),
-			ExpressionAttributeValues: exprAttrValues,
-			Limit:                     limit,
-		}
-	} else {
-		scanInput = dynamodb.ScanInput{
-			TableName: aws.String(tableName),
-			Limit:     limit,
-		}
-	}
-
-	result, err := r.client.Scan(ctx, &scanInput)
+	items, nextCursor, err := r.queryPage(ctx, &queryInput, query.Limit, query.Cursor, []string{"id", "feed", index.attribute})
 	if err != nil {
-		return nil, err
-	}
-	if result == nil || result.Items == nil {
-		return nil, nil
+		return nil, "", err
 	}
+
 	var promotions []model.Promotion
-	err = attributevalue.UnmarshalListOfMaps(result.Items, &promotions)
+	err = attributevalue.UnmarshalListOfMaps(items, &promotions)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	return promotions, nil
+	return promotions, nextCursor, nil
 }
 
 func (r repository) GetPromotionsByCategory(ctx context.Context, category string) ([]model.Promotion, error) {
@@ -533,3 +626,29 @@ func (r repository) GetCategories(ctx context.Context) ([]model.Category, error)
 
 	return categories, nil
 }
+
+func (r repository) CreateOrUpdateCategory(ctx context.Context, category *model.Category) error {
+	item, err := attributevalue.MarshalMap(category)
+	if err != nil {
+		return err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.category")
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+	return err
+}
+
+func (r repository) DeleteCategory(ctx context.Context, name string) error {
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.category")
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		Key: map[string]types.AttributeValue{
+			"name": &types.AttributeValueMemberS{Value: name},
+		},
+		TableName: aws.String(tableName),
+	})
+	return err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"pixelPromo/domain/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+func (r repository) CheckHealth(ctx context.Context) []model.DependencyHealth {
+	tables := r.cfg.Viper.GetStringMapString("aws.dynamodb.tables")
+
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]model.DependencyHealth, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = r.checkTable(ctx, name, tables[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (r repository) checkTable(ctx context.Context, name string, tableName string) model.DependencyHealth {
+	health := model.DependencyHealth{
+		Name:   name,
+		Kind:   "dynamodb-table",
+		Status: model.HealthUp,
+	}
+
+	start := time.Now()
+	result, err := r.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{
+		TableName: aws.String(tableName),
+	})
+	health.LatencyMs = time.Since(start).Milliseconds()
+
+	switch {
+	case err != nil:
+		health.Status = model.HealthDown
+		health.Reason = tableErrorReason(ctx, err)
+		health.Err = err
+	case result.Table == nil || (result.Table.TableStatus != types.TableStatusActive && result.Table.TableStatus != types.TableStatusUpdating):
+		health.Status = model.HealthDown
+		health.Reason = "not_active"
+	}
+	return health
+}
+
+func tableErrorReason(ctx context.Context, err error) string {
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "not_found"
+	}
+	if ctx.Err() != nil {
+		return "timeout"
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "AccessDeniedException" || apiErr.ErrorCode() == "UnrecognizedClientException") {
+		return "access_denied"
+	}
+	return "unavailable"
+}
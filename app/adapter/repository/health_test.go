@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"testing"
+)
+
+func TestTableErrorReason(t *testing.T) {
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want string
+	}{
+		{name: "missing table", ctx: context.Background(), err: &types.ResourceNotFoundException{}, want: "not_found"},
+		{name: "timed out", ctx: expired, err: context.Canceled, want: "timeout"},
+		{name: "access denied", ctx: context.Background(), err: &smithy.GenericAPIError{Code: "AccessDeniedException"}, want: "access_denied"},
+		{name: "unknown credentials", ctx: context.Background(), err: &smithy.GenericAPIError{Code: "UnrecognizedClientException"}, want: "access_denied"},
+		{name: "other", ctx: context.Background(), err: errors.New("connection refused"), want: "unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tableErrorReason(tt.ctx, tt.err); got != tt.want {
+				t.Fatalf("reason = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
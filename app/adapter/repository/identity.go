@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+)
+
+func (r repository) CreateOrUpdateUserIdentity(ctx context.Context, identity *model.UserIdentity) error {
+	item, err := attributevalue.MarshalMap(identity)
+	if err != nil {
+		return err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-identity")
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+	return err
+}
+
+func (r repository) GetUserIdentityById(ctx context.Context, id string) (*model.UserIdentity, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-identity")
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Item == nil {
+		return nil, nil
+	}
+
+	var identity model.UserIdentity
+	err = attributevalue.UnmarshalMap(result.Item, &identity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"strconv"
+	"time"
+)
+
+func (r repository) GetLoginAttempt(ctx context.Context, id string) (*model.LoginAttempt, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.login-attempt")
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Item == nil {
+		return nil, nil
+	}
+
+	var attempt model.LoginAttempt
+	err = attributevalue.UnmarshalMap(result.Item, &attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r repository) IncrementLoginFailures(ctx context.Context, id string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.login-attempt")
+	ttl := strconv.FormatInt(now.Add(window).Unix(), 10)
+
+	result, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("ADD failures :one SET windowStart = if_not_exists(windowStart, :now), #ttl = :ttl"),
+		ConditionExpression: aws.String("attribute_not_exists(id) OR windowStart > :windowFloor"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": "ttl",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one":         &types.AttributeValueMemberN{Value: "1"},
+			":now":         &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Unix(), 10)},
+			":windowFloor": &types.AttributeValueMemberN{Value: strconv.FormatInt(now.Add(-window).Unix(), 10)},
+			":ttl":         &types.AttributeValueMemberN{Value: ttl},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		attempt := model.LoginAttempt{
+			Id:          id,
+			Failures:    1,
+			WindowStart: now,
+			Ttl:         now.Add(window).Unix(),
+		}
+		return &attempt, r.putLoginAttempt(ctx, &attempt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var attempt model.LoginAttempt
+	err = attributevalue.UnmarshalMap(result.Attributes, &attempt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r repository) LockLoginAttempt(ctx context.Context, id string, until time.Time, expiresAt time.Time) error {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.login-attempt")
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression: aws.String("SET lockedUntil = :lockedUntil, #ttl = :ttl"),
+		ExpressionAttributeNames: map[string]string{
+			"#ttl": "ttl",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":lockedUntil": &types.AttributeValueMemberN{Value: strconv.FormatInt(until.Unix(), 10)},
+			":ttl":         &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)},
+		},
+	})
+	return err
+}
+
+func (r repository) DeleteLoginAttempt(ctx context.Context, id string) error {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.login-attempt")
+	_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	return err
+}
+
+func (r repository) putLoginAttempt(ctx context.Context, attempt *model.LoginAttempt) error {
+	item, err := attributevalue.MarshalMap(attempt)
+	if err != nil {
+		return err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.login-attempt")
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+	return err
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/errs"
+)
+
+type attributeMap = map[string]types.AttributeValue
+
+type pageFetcher func(ctx context.Context, startKey attributeMap, pageSize int32) ([]attributeMap, attributeMap, error)
+
+// readPage keeps fetching DynamoDB pages until limit items survived the filter
+// or the table is exhausted. When it stops in the middle of a page the cursor
+// points at the last returned item, so nothing in between is skipped.
+func (r repository) readPage(ctx context.Context, limit int32, cursor string, keyAttributes []string, fetch pageFetcher) ([]attributeMap, string, error) {
+	startKey, err := decodeCursor(cursor, keyAttributes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pageSize := r.cfg.Viper.GetInt32("aws.dynamodb.page-size")
+	if pageSize < limit {
+		pageSize = limit
+	}
+
+	var items []attributeMap
+	for {
+		pageItems, lastKey, err := fetch(ctx, startKey, pageSize)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for i, pageItem := range pageItems {
+			items = append(items, pageItem)
+			if int32(len(items)) < limit {
+				continue
+			}
+			if i == len(pageItems)-1 && lastKey == nil {
+				return items, "", nil
+			}
+
+			key := make(attributeMap, len(keyAttributes))
+			for _, attribute := range keyAttributes {
+				key[attribute] = pageItem[attribute]
+			}
+			nextCursor, err := encodeCursor(key)
+			return items, nextCursor, err
+		}
+
+		if lastKey == nil {
+			return items, "", nil
+		}
+		startKey = lastKey
+	}
+}
+
+func (r repository) scanPage(ctx context.Context, input *dynamodb.ScanInput, limit int32, cursor string, keyAttributes []string) ([]attributeMap, string, error) {
+	return r.readPage(ctx, limit, cursor, keyAttributes, func(ctx context.Context, startKey attributeMap, pageSize int32) ([]attributeMap, attributeMap, error) {
+		input.ExclusiveStartKey = startKey
+		input.Limit = aws.Int32(pageSize)
+		result, err := r.client.Scan(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Items, result.LastEvaluatedKey, nil
+	})
+}
+
+func (r repository) queryPage(ctx context.Context, input *dynamodb.QueryInput, limit int32, cursor string, keyAttributes []string) ([]attributeMap, string, error) {
+	return r.readPage(ctx, limit, cursor, keyAttributes, func(ctx context.Context, startKey attributeMap, pageSize int32) ([]attributeMap, attributeMap, error) {
+		input.ExclusiveStartKey = startKey
+		input.Limit = aws.Int32(pageSize)
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result.Items, result.LastEvaluatedKey, nil
+	})
+}
+
+func encodeCursor(key attributeMap) (string, error) {
+	var values map[string]interface{}
+	if err := attributevalue.UnmarshalMap(key, &values); err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(cursor string, keyAttributes []string) (attributeMap, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	invalid := errs.Invalid("invalid_cursor", "cursor is invalid")
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var values map[string]interface{}
+	if err = json.Unmarshal(raw, &values); err != nil || len(values) != len(keyAttributes) {
+		return nil, invalid
+	}
+	for _, attribute := range keyAttributes {
+		if _, ok := values[attribute]; !ok {
+			return nil, invalid
+		}
+	}
+
+	key, err := attributevalue.MarshalMap(values)
+	if err != nil {
+		return nil, invalid
+	}
+	return key, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/spf13/viper"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        attributeMap
+		attributes []string
+	}{
+		{
+			name:       "string key",
+			key:        attributeMap{"id": &types.AttributeValueMemberS{Value: "42"}},
+			attributes: []string{"id"},
+		},
+		{
+			name: "index key",
+			key: attributeMap{
+				"id":         &types.AttributeValueMemberS{Value: "42"},
+				"feed":       &types.AttributeValueMemberS{Value: "promotion"},
+				"sortNewest": &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05.000000000Z#42"},
+			},
+			attributes: []string{"id", "feed", "sortNewest"},
+		},
+		{
+			name: "numeric key",
+			key: attributeMap{
+				"id":        &types.AttributeValueMemberS{Value: "42"},
+				"expiresAt": &types.AttributeValueMemberN{Value: "1700000000"},
+			},
+			attributes: []string{"id", "expiresAt"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := encodeCursor(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.ContainsAny(cursor, "+/=") {
+				t.Fatalf("cursor %q is not url safe", cursor)
+			}
+
+			key, err := decodeCursor(cursor, tt.attributes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(key, tt.key) {
+				t.Fatalf("decoded %#v, want %#v", key, tt.key)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	valid, err := encodeCursor(attributeMap{"id": &types.AttributeValueMemberS{Value: "42"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		cursor     string
+		attributes []string
+	}{
+		{name: "not base64", cursor: "%%%", attributes: []string{"id"}},
+		{name: "not json", cursor: "bm90IGpzb24", attributes: []string{"id"}},
+		{name: "missing attribute", cursor: valid, attributes: []string{"userId"}},
+		{name: "extra attribute", cursor: valid, attributes: []string{"id", "createdAt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.cursor, tt.attributes)
+			if e, ok := errs.As(err); !ok || e.Code != "invalid_cursor" {
+				t.Fatalf("error = %v, want invalid_cursor", err)
+			}
+		})
+	}
+
+	if key, err := decodeCursor("", []string{"id"}); key != nil || err != nil {
+		t.Fatalf("empty cursor = %v, %v", key, err)
+	}
+}
+
+func TestReadPage(t *testing.T) {
+	tests := []struct {
+		name     string
+		items    int
+		limit    int32
+		pageSize int32
+		// keep simulates a FilterExpression, DynamoDB evaluates pageSize items
+		// and only returns the ones that match
+		keep func(i int) bool
+	}{
+		{name: "exact pages", items: 9, limit: 3, pageSize: 3, keep: func(int) bool { return true }},
+		{name: "limit inside a page", items: 10, limit: 3, pageSize: 4, keep: func(int) bool { return true }},
+		{name: "filtered pages", items: 20, limit: 2, pageSize: 3, keep: func(i int) bool { return i%3 == 0 }},
+		{name: "page size below limit", items: 7, limit: 5, pageSize: 2, keep: func(int) bool { return true }},
+		{name: "nothing matches", items: 6, limit: 2, pageSize: 2, keep: func(int) bool { return false }},
+		{name: "empty table", items: 0, limit: 2, pageSize: 2, keep: func(int) bool { return true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := viper.New()
+			v.Set("aws.dynamodb.page-size", tt.pageSize)
+			r := repository{cfg: &config.Config{Viper: v}}
+
+			var table []attributeMap
+			var want []string
+			for i := 0; i < tt.items; i++ {
+				id := fmt.Sprintf("%03d", i)
+				table = append(table, attributeMap{
+					"id":    &types.AttributeValueMemberS{Value: id},
+					"match": &types.AttributeValueMemberBOOL{Value: tt.keep(i)},
+				})
+				if tt.keep(i) {
+					want = append(want, id)
+				}
+			}
+
+			fetch := func(_ context.Context, startKey attributeMap, pageSize int32) ([]attributeMap, attributeMap, error) {
+				start := 0
+				if startKey != nil {
+					last := startKey["id"].(*types.AttributeValueMemberS).Value
+					for start < len(table) && table[start]["id"].(*types.AttributeValueMemberS).Value <= last {
+						start++
+					}
+				}
+				end := min(start+int(pageSize), len(table))
+
+				var matched []attributeMap
+				for _, item := range table[start:end] {
+					if item["match"].(*types.AttributeValueMemberBOOL).Value {
+						matched = append(matched, item)
+					}
+				}
+				if end == len(table) {
+					return matched, nil, nil
+				}
+				return matched, attributeMap{"id": table[end-1]["id"]}, nil
+			}
+
+			var got []string
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > tt.items+1 {
+					t.Fatal("pagination does not terminate")
+				}
+
+				items, next, err := r.readPage(context.Background(), tt.limit, cursor, []string{"id"}, fetch)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if int32(len(items)) > tt.limit {
+					t.Fatalf("page has %d items, limit %d", len(items), tt.limit)
+				}
+				for _, item := range items {
+					got = append(got, item["id"].(*types.AttributeValueMemberS).Value)
+				}
+				if next == "" {
+					break
+				}
+				cursor = next
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
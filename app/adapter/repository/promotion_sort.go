@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"math"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+// Every promotion shares the same feed partition so each sort order is a single
+// ordered GSI. Sort keys are zero padded strings ending with the newest key, which
+// itself ends with the promotion id, so ties always resolve the same way.
+const promotionFeed = "promotion"
+
+const promotionNewestLayout = "2006-01-02T15:04:05.000000000Z"
+
+type promotionSortIndex struct {
+	name      string
+	attribute string
+	ascending bool
+}
+
+var promotionSortIndexes = map[model.PromotionSort]promotionSortIndex{
+	model.SortNewest:   {name: "FeedNewestIndex", attribute: "sortNewest"},
+	model.SortDiscount: {name: "FeedDiscountIndex", attribute: "sortDiscount"},
+	model.SortPrice:    {name: "FeedPriceIndex", attribute: "sortPrice", ascending: true},
+	model.SortPopular:  {name: "FeedPopularIndex", attribute: "sortPopular"},
+	model.SortHot:      {name: "FeedHotIndex", attribute: "sortHot"},
+}
+
+func promotionSortKeys(promotion *model.Promotion) attributeMap {
+	newest := newestSortKey(promotion)
+
+	return attributeMap{
+		"feed":         &types.AttributeValueMemberS{Value: promotionFeed},
+		"sortNewest":   &types.AttributeValueMemberS{Value: newest},
+		"sortDiscount": &types.AttributeValueMemberS{Value: fmt.Sprintf("%06.2f#%s", math.Max(promotion.DiscountBadge, 0), newest)},
+		"sortPrice":    &types.AttributeValueMemberS{Value: fmt.Sprintf("%015.2f#%s", math.Max(promotion.DiscountedPrice, 0), newest)},
+		"sortPopular":  popularSortKey(promotion, newest),
+		"sortHot":      hotSortKey(promotion, newest),
+	}
+}
+
+func newestSortKey(promotion *model.Promotion) string {
+	return fmt.Sprintf("%s#%s", promotion.CreatedAt.UTC().Format(promotionNewestLayout), promotion.Id)
+}
+
+func popularSortKey(promotion *model.Promotion, newest string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: fmt.Sprintf("%010d#%s", max(promotion.Popularity, 0), newest)}
+}
+
+func hotSortKey(promotion *model.Promotion, newest string) types.AttributeValue {
+	return &types.AttributeValueMemberS{Value: fmt.Sprintf("%020.6f#%s", math.Max(promotion.HotScore, 0), newest)}
+}
+
+func (r repository) UpdatePromotionPopularity(ctx context.Context, promotion *model.Promotion, previous int) error {
+	promotion.UpdatedAt = time.Now()
+	updatedAt, err := attributevalue.Marshal(promotion.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	newest := newestSortKey(promotion)
+
+	condition := "attribute_exists(id) AND popularity = :previous"
+	if previous == 0 {
+		condition = "attribute_exists(id) AND (attribute_not_exists(popularity) OR popularity = :previous)"
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: promotion.Id},
+		},
+		UpdateExpression:    aws.String("SET popularity = :popularity, hotScore = :hotScore, sortPopular = :sortPopular, sortHot = :sortHot, updatedAt = :updatedAt"),
+		ConditionExpression: aws.String(condition),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":popularity":  &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", promotion.Popularity)},
+			":hotScore":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%f", promotion.HotScore)},
+			":sortPopular": popularSortKey(promotion, newest),
+			":sortHot":     hotSortKey(promotion, newest),
+			":previous":    &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", previous)},
+			":updatedAt":   updatedAt,
+		},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
+// GetPromotionsWithoutSortKeys returns the promotions written before the feed
+// indexes existed, they are missing from every listing until backfilled.
+func (r repository) GetPromotionsWithoutSortKeys(ctx context.Context) ([]model.Promotion, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
+	paginator := dynamodb.NewScanPaginator(r.client, &dynamodb.ScanInput{
+		TableName:        aws.String(tableName),
+		FilterExpression: aws.String("attribute_not_exists(feed)"),
+	})
+
+	var promotions []model.Promotion
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var pagePromotions []model.Promotion
+		if err = attributevalue.UnmarshalListOfMaps(page.Items, &pagePromotions); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, pagePromotions...)
+	}
+
+	return promotions, nil
+}
+
+func (r repository) BackfillPromotionSortKeys(ctx context.Context, promotion *model.Promotion) error {
+	values := attributeMap{
+		":popularity": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", promotion.Popularity)},
+		":hotScore":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%f", promotion.HotScore)},
+	}
+	for attribute, value := range promotionSortKeys(promotion) {
+		values[":"+attribute] = value
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: promotion.Id},
+		},
+		UpdateExpression:          aws.String("SET popularity = :popularity, hotScore = :hotScore, feed = :feed, sortNewest = :sortNewest, sortDiscount = :sortDiscount, sortPrice = :sortPrice, sortPopular = :sortPopular, sortHot = :sortHot"),
+		ConditionExpression:       aws.String("attribute_exists(id) AND attribute_not_exists(feed)"),
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"testing"
+	"time"
+)
+
+func TestPromotionSortKeysOrder(t *testing.T) {
+	base := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		attribute string
+		// first sorts before second in ascending string order
+		first, second model.Promotion
+	}{
+		{
+			name:      "newest by creation time",
+			attribute: "sortNewest",
+			first:     model.Promotion{Id: "9", CreatedAt: base},
+			second:    model.Promotion{Id: "1", CreatedAt: base.Add(time.Nanosecond)},
+		},
+		{
+			name:      "newest ties by id",
+			attribute: "sortNewest",
+			first:     model.Promotion{Id: "1", CreatedAt: base},
+			second:    model.Promotion{Id: "2", CreatedAt: base},
+		},
+		{
+			name:      "discount is numeric, not lexical",
+			attribute: "sortDiscount",
+			first:     model.Promotion{Id: "1", DiscountBadge: 9, CreatedAt: base},
+			second:    model.Promotion{Id: "2", DiscountBadge: 10, CreatedAt: base},
+		},
+		{
+			name:      "discount ties by creation time",
+			attribute: "sortDiscount",
+			first:     model.Promotion{Id: "2", DiscountBadge: 50, CreatedAt: base},
+			second:    model.Promotion{Id: "1", DiscountBadge: 50, CreatedAt: base.Add(time.Second)},
+		},
+		{
+			name:      "price keeps cents",
+			attribute: "sortPrice",
+			first:     model.Promotion{Id: "1", DiscountedPrice: 99.98, CreatedAt: base},
+			second:    model.Promotion{Id: "2", DiscountedPrice: 99.99, CreatedAt: base},
+		},
+		{
+			name:      "price is numeric, not lexical",
+			attribute: "sortPrice",
+			first:     model.Promotion{Id: "1", DiscountedPrice: 900, CreatedAt: base},
+			second:    model.Promotion{Id: "2", DiscountedPrice: 1000, CreatedAt: base},
+		},
+		{
+			name:      "negative price sorts as zero",
+			attribute: "sortPrice",
+			first:     model.Promotion{Id: "1", DiscountedPrice: -5, CreatedAt: base},
+			second:    model.Promotion{Id: "2", DiscountedPrice: 0.01, CreatedAt: base},
+		},
+		{
+			name:      "popularity is numeric, not lexical",
+			attribute: "sortPopular",
+			first:     model.Promotion{Id: "1", Popularity: 9, CreatedAt: base},
+			second:    model.Promotion{Id: "2", Popularity: 10, CreatedAt: base},
+		},
+		{
+			name:      "hot score keeps decimals",
+			attribute: "sortHot",
+			first:     model.Promotion{Id: "1", HotScore: 37720.1234, CreatedAt: base},
+			second:    model.Promotion{Id: "2", HotScore: 37720.1235, CreatedAt: base},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := promotionSortKeys(&tt.first)[tt.attribute].(*types.AttributeValueMemberS).Value
+			second := promotionSortKeys(&tt.second)[tt.attribute].(*types.AttributeValueMemberS).Value
+			if first >= second {
+				t.Fatalf("%q does not sort before %q", first, second)
+			}
+		})
+	}
+}
+
+func TestPromotionSortKeysShareFeed(t *testing.T) {
+	keys := promotionSortKeys(&model.Promotion{Id: "1", CreatedAt: time.Now()})
+
+	if keys["feed"].(*types.AttributeValueMemberS).Value != promotionFeed {
+		t.Fatalf("feed = %v", keys["feed"])
+	}
+	for _, index := range promotionSortIndexes {
+		if _, ok := keys[index.attribute]; !ok {
+			t.Fatalf("index %s has no %s key", index.name, index.attribute)
+		}
+	}
+}
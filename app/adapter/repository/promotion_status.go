@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusExpiresIndex is sparse: only promotions with an expiresAt are projected,
+// so the sweeper reads due promotions without scanning the open-ended ones.
+const promotionStatusExpiresIndex = "StatusExpiresIndex"
+
+// promotionVisibilityFilter hides ended promotions from listings. Promotions
+// stored before statuses existed have no status and count as active.
+func promotionVisibilityFilter(query *model.PromotionQuery, now time.Time, values attributeMap) (string, error) {
+	values[":active"] = &types.AttributeValueMemberS{Value: model.PromotionActive.String()}
+
+	if query.IncludeExpired {
+		values[":expired"] = &types.AttributeValueMemberS{Value: model.PromotionExpired.String()}
+		values[":soldOut"] = &types.AttributeValueMemberS{Value: model.PromotionSoldOut.String()}
+		return "(attribute_not_exists(#status) OR #status IN (:active, :expired, :soldOut))", nil
+	}
+
+	nowValue, err := attributevalue.Marshal(now.UTC().Truncate(time.Second))
+	if err != nil {
+		return "", err
+	}
+	values[":now"] = nowValue
+	return "(attribute_not_exists(#status) OR #status = :active) AND (attribute_not_exists(expiresAt) OR expiresAt > :now)", nil
+}
+
+func (r repository) GetPromotionsDueToExpire(ctx context.Context, now time.Time, limit int32) ([]model.Promotion, error) {
+	nowValue, err := attributevalue.Marshal(now.UTC().Truncate(time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String(promotionStatusExpiresIndex),
+		KeyConditionExpression: aws.String("#status = :active AND expiresAt <= :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":active": &types.AttributeValueMemberS{Value: model.PromotionActive.String()},
+			":now":    nowValue,
+		},
+		Limit: aws.Int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var promotions []model.Promotion
+	err = attributevalue.UnmarshalListOfMaps(result.Items, &promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+// UpdatePromotion replaces the promotion only while it still has the status that
+// was read, so an edit cannot bring back a promotion removed or expired meanwhile.
+func (r repository) UpdatePromotion(ctx context.Context, promotion *model.Promotion, previous model.PromotionStatus) error {
+	promotion.UpdatedAt = time.Now()
+	item, err := attributevalue.MarshalMap(promotion)
+	if err != nil {
+		return err
+	}
+	for attribute, value := range promotionSortKeys(promotion) {
+		item[attribute] = value
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(tableName),
+		Item:                     item,
+		ConditionExpression:      aws.String(promotionStatusCondition(previous)),
+		ExpressionAttributeNames: map[string]string{"#status": "status"},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":previous": &types.AttributeValueMemberS{Value: previous.String()},
+		},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
+// promotionStatusCondition expects #status and :previous, promotions stored
+// before the lifecycle existed have no status and count as active.
+func promotionStatusCondition(previous model.PromotionStatus) string {
+	if previous == model.PromotionActive {
+		return "attribute_exists(id) AND (attribute_not_exists(#status) OR #status = :previous)"
+	}
+	return "attribute_exists(id) AND #status = :previous"
+}
+
+func (r repository) UpdatePromotionStatus(ctx context.Context, promotion *model.Promotion, previous model.PromotionStatus) error {
+	promotion.UpdatedAt = time.Now()
+	updatedAt, err := attributevalue.Marshal(promotion.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	names := map[string]string{
+		"#status": "status",
+		"#ttl":    "ttl",
+	}
+	values := map[string]types.AttributeValue{
+		":status":    &types.AttributeValueMemberS{Value: promotion.Status.String()},
+		":previous":  &types.AttributeValueMemberS{Value: previous.String()},
+		":updatedAt": updatedAt,
+	}
+
+	set := []string{"#status = :status", "updatedAt = :updatedAt"}
+
+	if promotion.ExpiresAt != nil {
+		expiresAt, err := attributevalue.Marshal(promotion.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		set = append(set, "expiresAt = :expiresAt")
+		values[":expiresAt"] = expiresAt
+	}
+
+	// ttl archives ended promotions, reactivating one must clear it again
+	remove := ""
+	if promotion.Ttl > 0 {
+		set = append(set, "#ttl = :ttl")
+		values[":ttl"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(promotion.Ttl, 10)}
+	} else {
+		remove = " REMOVE #ttl"
+	}
+
+	update := "SET " + strings.Join(set, ", ") + remove
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: promotion.Id},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(promotionStatusCondition(previous)),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
+func (r repository) UpdatePromotionExpiredReports(ctx context.Context, promotion *model.Promotion, previous float64) error {
+	promotion.UpdatedAt = time.Now()
+	updatedAt, err := attributevalue.Marshal(promotion.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]types.AttributeValue{
+		":expiredReports": &types.AttributeValueMemberN{Value: strconv.FormatFloat(promotion.ExpiredReports, 'f', -1, 64)},
+		":updatedAt":      updatedAt,
+		":previous":       &types.AttributeValueMemberN{Value: strconv.FormatFloat(previous, 'f', -1, 64)},
+	}
+
+	update := "SET expiredReports = :expiredReports, updatedAt = :updatedAt REMOVE expiredFlaggedAt"
+	if promotion.ExpiredFlaggedAt != nil {
+		flaggedAt, err := attributevalue.Marshal(promotion.ExpiredFlaggedAt)
+		if err != nil {
+			return err
+		}
+		values[":expiredFlaggedAt"] = flaggedAt
+		update = "SET expiredReports = :expiredReports, expiredFlaggedAt = :expiredFlaggedAt, updatedAt = :updatedAt"
+	}
+
+	condition := "attribute_exists(id) AND expiredReports = :previous"
+	if previous == 0 {
+		condition = "attribute_exists(id) AND (attribute_not_exists(expiredReports) OR expiredReports = :previous)"
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.promotion")
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: promotion.Id},
+		},
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
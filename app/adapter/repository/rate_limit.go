@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+func (r repository) GetRateLimitBucket(ctx context.Context, key string) (*model.RateLimitBucket, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.rate-limit")
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"key": &types.AttributeValueMemberS{Value: key},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Item == nil {
+		return nil, nil
+	}
+
+	var bucket model.RateLimitBucket
+	err = attributevalue.UnmarshalMap(result.Item, &bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	return &bucket, nil
+}
+
+// PutRateLimitBucket only succeeds if the stored bucket was not changed since
+// previousUpdatedAt was read (zero meaning it did not exist).
+func (r repository) PutRateLimitBucket(ctx context.Context, bucket *model.RateLimitBucket, previousUpdatedAt time.Time) error {
+	item, err := attributevalue.MarshalMap(bucket)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:                aws.String(r.cfg.Viper.GetString("aws.dynamodb.tables.rate-limit")),
+		Item:                     item,
+		ConditionExpression:      aws.String("attribute_not_exists(#key)"),
+		ExpressionAttributeNames: map[string]string{"#key": "key"},
+	}
+	if !previousUpdatedAt.IsZero() {
+		previous, err := attributevalue.Marshal(previousUpdatedAt)
+		if err != nil {
+			return err
+		}
+		input.ConditionExpression = aws.String("updatedAt = :previous")
+		input.ExpressionAttributeNames = nil
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{":previous": previous}
+	}
+
+	_, err = r.client.PutItem(ctx, input)
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+func (r repository) CreateOrUpdateSession(ctx context.Context, session *model.Session) error {
+	item, err := attributevalue.MarshalMap(session)
+	if err != nil {
+		return err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-session")
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(tableName),
+		Item:      item,
+	})
+	return err
+}
+
+func (r repository) RotateSession(ctx context.Context, id string, usedAt time.Time) error {
+	lastUsedAt, err := attributevalue.Marshal(usedAt)
+	if err != nil {
+		return err
+	}
+
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-session")
+	_, err = r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET rotated = :true, lastUsedAt = :lastUsedAt"),
+		ConditionExpression: aws.String("attribute_exists(id) AND rotated = :false AND revoked = :false"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":true":       &types.AttributeValueMemberBOOL{Value: true},
+			":false":      &types.AttributeValueMemberBOOL{Value: false},
+			":lastUsedAt": lastUsedAt,
+		},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
+// RevokeSessionById only flips revoked, so a rotation racing with it can never
+// be undone by writing back an older copy of the session.
+func (r repository) RevokeSessionById(ctx context.Context, id string) error {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-session")
+	_, err := r.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+		UpdateExpression:    aws.String("SET revoked = :true"),
+		ConditionExpression: aws.String("attribute_exists(id)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":true": &types.AttributeValueMemberBOOL{Value: true},
+		},
+	})
+
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return port.ErrConditionFailed
+	}
+	return err
+}
+
+func (r repository) GetSessionById(ctx context.Context, id string) (*model.Session, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-session")
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(tableName),
+		ConsistentRead: aws.Bool(true),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Item == nil {
+		return nil, nil
+	}
+
+	var session model.Session
+	err = attributevalue.UnmarshalMap(result.Item, &session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r repository) GetSessionsByFamilyId(ctx context.Context, familyId string) ([]model.Session, error) {
+	return r.querySessions(ctx, "FamilyIdIndex", "familyId", familyId)
+}
+
+func (r repository) GetSessionsByUserId(ctx context.Context, userId string) ([]model.Session, error) {
+	return r.querySessions(ctx, "UserIdIndex", "userId", userId)
+}
+
+func (r repository) querySessions(ctx context.Context, indexName string, attribute string, value string) ([]model.Session, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-session")
+	paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+		TableName:              aws.String(tableName),
+		IndexName:              aws.String(indexName),
+		KeyConditionExpression: aws.String("#key = :value"),
+		ProjectionExpression:   aws.String("id"),
+		ExpressionAttributeNames: map[string]string{
+			"#key": attribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":value": &types.AttributeValueMemberS{Value: value},
+		},
+	})
+
+	var sessions []model.Session
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var pageSessions []model.Session
+		err = attributevalue.UnmarshalListOfMaps(page.Items, &pageSessions)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, pageSessions...)
+	}
+
+	// The indexes are eventually consistent, so a rotation or revocation that
+	// just landed may be missing from their copies; only the ids are trusted.
+	current := make([]model.Session, 0, len(sessions))
+	for _, session := range sessions {
+		fresh, err := r.GetSessionById(ctx, session.Id)
+		if err != nil {
+			return nil, err
+		}
+		if fresh != nil {
+			current = append(current, *fresh)
+		}
+	}
+
+	return current, nil
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+func (r repository) CreateUser(ctx context.Context, user *model.User) error {
+	user.UpdatedAt = time.Now()
+	userItem, err := attributevalue.MarshalMap(user)
+	if err != nil {
+		return err
+	}
+
+	items := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(r.cfg.Viper.GetString("aws.dynamodb.tables.user")),
+				Item:                userItem,
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			},
+		},
+	}
+	if user.Email != "" {
+		// records the email of a legacy user first, so the transaction conflicts
+		if _, err = r.GetUserByEmail(ctx, user.Email); err != nil {
+			return err
+		}
+
+		emailItem, err := r.userEmailPut(user)
+		if err != nil {
+			return err
+		}
+		items = append(items, emailItem)
+	}
+
+	return r.transactWrite(ctx, items)
+}
+
+func (r repository) UpdateUserEmail(ctx context.Context, user *model.User, previousEmail string) error {
+	user.UpdatedAt = time.Now()
+	userItem, err := attributevalue.MarshalMap(user)
+	if err != nil {
+		return err
+	}
+
+	if _, err = r.GetUserByEmail(ctx, user.Email); err != nil {
+		return err
+	}
+
+	emailItem, err := r.userEmailPut(user)
+	if err != nil {
+		return err
+	}
+
+	items := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(r.cfg.Viper.GetString("aws.dynamodb.tables.user")),
+				Item:                userItem,
+				ConditionExpression: aws.String("attribute_exists(id)"),
+			},
+		},
+		emailItem,
+	}
+	if previousEmail != "" {
+		items = append(items, r.userEmailDelete(previousEmail, user.Id))
+	}
+
+	return r.transactWrite(ctx, items)
+}
+
+func (r repository) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	userEmail, err := r.getUserEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if userEmail == nil {
+		return r.migrateUserEmail(ctx, email)
+	}
+
+	return r.GetUserById(ctx, userEmail.UserId)
+}
+
+// migrateUserEmail covers users created before pp-user-email existed. It finds
+// them with the old scan by email and records the email, so the next lookup
+// for that user goes straight to the uniqueness table.
+func (r repository) migrateUserEmail(ctx context.Context, email string) (*model.User, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user")
+	paginator := dynamodb.NewScanPaginator(r.client, &dynamodb.ScanInput{
+		TableName:            aws.String(tableName),
+		FilterExpression:     aws.String("email = :email"),
+		ProjectionExpression: aws.String("id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":email": &types.AttributeValueMemberS{Value: email},
+		},
+	})
+
+	var userEmail *model.UserEmail
+	for userEmail == nil && paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Items {
+			var user model.User
+			if err = attributevalue.UnmarshalMap(item, &user); err != nil {
+				return nil, err
+			}
+			userEmail = &model.UserEmail{Email: email, UserId: user.Id}
+			break
+		}
+	}
+	if userEmail == nil {
+		return nil, nil
+	}
+
+	item, err := attributevalue.MarshalMap(userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.cfg.Viper.GetString("aws.dynamodb.tables.user-email")),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(email)"),
+	})
+
+	// another request recorded the email first, trust whatever it stored
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		userEmail, err = r.getUserEmail(ctx, email)
+		if err != nil || userEmail == nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return r.GetUserById(ctx, userEmail.UserId)
+}
+
+func (r repository) getUserEmail(ctx context.Context, email string) (*model.UserEmail, error) {
+	tableName := r.cfg.Viper.GetString("aws.dynamodb.tables.user-email")
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(tableName),
+		Key: map[string]types.AttributeValue{
+			"email": &types.AttributeValueMemberS{Value: email},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || result.Item == nil {
+		return nil, nil
+	}
+
+	var userEmail model.UserEmail
+	err = attributevalue.UnmarshalMap(result.Item, &userEmail)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userEmail, nil
+}
+
+func (r repository) userEmailPut(user *model.User) (types.TransactWriteItem, error) {
+	item, err := attributevalue.MarshalMap(model.UserEmail{
+		Email:  user.Email,
+		UserId: user.Id,
+	})
+	if err != nil {
+		return types.TransactWriteItem{}, err
+	}
+
+	return types.TransactWriteItem{
+		Put: &types.Put{
+			TableName:           aws.String(r.cfg.Viper.GetString("aws.dynamodb.tables.user-email")),
+			Item:                item,
+			ConditionExpression: aws.String("attribute_not_exists(email)"),
+		},
+	}, nil
+}
+
+func (r repository) userEmailDelete(email string, userId string) types.TransactWriteItem {
+	return types.TransactWriteItem{
+		Delete: &types.Delete{
+			TableName: aws.String(r.cfg.Viper.GetString("aws.dynamodb.tables.user-email")),
+			Key: map[string]types.AttributeValue{
+				"email": &types.AttributeValueMemberS{Value: email},
+			},
+			ConditionExpression: aws.String("attribute_not_exists(email) OR userId = :userId"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":userId": &types.AttributeValueMemberS{Value: userId},
+			},
+		},
+	}
+}
+
+func (r repository) transactWrite(ctx context.Context, items []types.TransactWriteItem) error {
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+
+	var canceledErr *types.TransactionCanceledException
+	if errors.As(err, &canceledErr) {
+		for _, reason := range canceledErr.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return port.ErrConditionFailed
+			}
+		}
+	}
+	return err
+}
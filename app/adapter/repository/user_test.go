@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+func TestUnmarshalUserEmailVerified(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified types.AttributeValue
+		want     bool
+	}{
+		{name: "stored before verification existed", want: true},
+		{name: "verified", verified: &types.AttributeValueMemberBOOL{Value: true}, want: true},
+		{name: "not verified", verified: &types.AttributeValueMemberBOOL{Value: false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := attributeMap{
+				"id":    &types.AttributeValueMemberS{Value: "user-1"},
+				"email": &types.AttributeValueMemberS{Value: "user@mail.com"},
+			}
+			if tt.verified != nil {
+				item["emailVerified"] = tt.verified
+			}
+
+			var user model.User
+			if err := unmarshalUser(item, &user); err != nil {
+				t.Fatal(err)
+			}
+			if user.Id != "user-1" || user.EmailVerified != tt.want {
+				t.Fatalf("user = %+v, want emailVerified %v", user, tt.want)
+			}
+		})
+	}
+}
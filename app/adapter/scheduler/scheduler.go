@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"context"
+	"pixelPromo/config"
+	"pixelPromo/domain/port"
+	"sync"
+	"time"
+)
+
+type Scheduler interface {
+	Start() error
+	Shutdown(context.Context) error
+}
+
+type scheduler struct {
+	handler  port.Handler
+	log      config.Logger
+	enabled  bool
+	interval time.Duration
+	backfill bool
+	cancel   context.CancelFunc
+	running  sync.WaitGroup
+}
+
+func NewScheduler(cfg *config.Config, handler port.Handler, log config.Logger) Scheduler {
+	return &scheduler{
+		handler:  handler,
+		log:      log,
+		enabled:  cfg.Viper.GetBool("service.promotion.expiration.sweeper.enabled"),
+		interval: cfg.Viper.GetDuration("service.promotion.expiration.sweeper.interval"),
+		backfill: cfg.Viper.GetBool("service.promotion.sort.backfill-on-start"),
+	}
+}
+
+func (s *scheduler) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	if s.backfill {
+		s.running.Add(1)
+		go s.backfillPromotionSortKeys(ctx)
+	}
+
+	if !s.enabled || s.interval <= 0 {
+		s.log.Info("promotion sweeper disabled")
+		return nil
+	}
+
+	s.running.Add(1)
+	go s.run(ctx)
+	return nil
+}
+
+func (s *scheduler) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *scheduler) run(ctx context.Context) {
+	defer s.running.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.expirePromotions(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// expirePromotions drains every due batch, so a backlog left by downtime is
+// cleared in one tick instead of one batch per interval.
+func (s *scheduler) expirePromotions(ctx context.Context) {
+	for ctx.Err() == nil {
+		expired, err := s.handler.ExpirePromotions(ctx)
+		if err != nil || expired == 0 {
+			return
+		}
+	}
+}
+
+func (s *scheduler) backfillPromotionSortKeys(ctx context.Context) {
+	defer s.running.Done()
+	_, _ = s.handler.BackfillPromotionSortKeys(ctx)
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"github.com/spf13/viper"
+	"pixelPromo/config"
+	"pixelPromo/domain/port"
+	"sync"
+	"testing"
+	"time"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...config.Field)      {}
+func (nopLogger) Info(string, ...config.Field)       {}
+func (nopLogger) Warn(string, ...config.Field)       {}
+func (nopLogger) Error(string, ...config.Field)      {}
+func (nopLogger) Panic(string, ...config.Field)      {}
+func (nopLogger) Fatal(string, ...config.Field)      {}
+func (nopLogger) With(...config.Field) config.Logger { return nopLogger{} }
+func (nopLogger) Flush() error                       { return nil }
+
+// sweepHandler returns the queued batch sizes in order and 0 once drained.
+type sweepHandler struct {
+	port.Handler
+	mu      sync.Mutex
+	batches []int
+	err     error
+	calls   int
+	// backfills counts the sort key backfills run on start
+	backfills int
+}
+
+func (h *sweepHandler) ExpirePromotions(context.Context) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.calls++
+	if h.err != nil {
+		return 0, h.err
+	}
+	if len(h.batches) == 0 {
+		return 0, nil
+	}
+	expired := h.batches[0]
+	h.batches = h.batches[1:]
+	return expired, nil
+}
+
+func (h *sweepHandler) BackfillPromotionSortKeys(context.Context) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.backfills++
+	return 0, nil
+}
+
+func (h *sweepHandler) callCount() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+func newTestScheduler(handler port.Handler, enabled bool, backfill bool) *scheduler {
+	v := viper.New()
+	v.Set("service.promotion.expiration.sweeper.enabled", enabled)
+	v.Set("service.promotion.sort.backfill-on-start", backfill)
+	v.Set("service.promotion.expiration.sweeper.interval", "1h")
+	return NewScheduler(&config.Config{Viper: v}, handler, nopLogger{}).(*scheduler)
+}
+
+func TestExpirePromotionsDrainsBacklog(t *testing.T) {
+	tests := []struct {
+		name    string
+		batches []int
+		err     error
+		calls   int
+	}{
+		{name: "nothing due", calls: 1},
+		{name: "backlog of full batches", batches: []int{2, 2, 1}, calls: 4},
+		{name: "stops on error", batches: []int{2}, err: errors.New("throttled"), calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &sweepHandler{batches: tt.batches, err: tt.err}
+			newTestScheduler(handler, true, false).expirePromotions(context.Background())
+
+			if handler.calls != tt.calls {
+				t.Fatalf("calls = %d, want %d", handler.calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestSchedulerLifecycle(t *testing.T) {
+	tests := []struct {
+		name     string
+		enabled  bool
+		backfill bool
+		// sweeps expects a first sweep right after Start
+		sweeps bool
+	}{
+		{name: "enabled", enabled: true, sweeps: true},
+		{name: "disabled", enabled: false},
+		{name: "backfill with the sweeper", enabled: true, backfill: true, sweeps: true},
+		{name: "backfill without the sweeper", enabled: false, backfill: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &sweepHandler{}
+			s := newTestScheduler(handler, tt.enabled, tt.backfill)
+			if err := s.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			deadline := time.Now().Add(time.Second)
+			for tt.sweeps && handler.callCount() == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := s.Shutdown(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if swept := handler.callCount() > 0; swept != tt.sweeps {
+				t.Fatalf("swept = %v, want %v", swept, tt.sweeps)
+			}
+			// Shutdown waits for the backfill, so it has run by now
+			if backfilled := handler.backfills > 0; backfilled != tt.backfill {
+				t.Fatalf("backfilled = %v, want %v", backfilled, tt.backfill)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"pixelPromo/domain/model"
+	"sort"
+	"sync"
+	"time"
+)
+
+func (b bucketS3Storage) CheckHealth(ctx context.Context) []model.DependencyHealth {
+	buckets := b.cfg.Viper.GetStringMapString("aws.s3.buckets")
+
+	names := make([]string, 0, len(buckets))
+	for name := range buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]model.DependencyHealth, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			results[i] = b.checkBucket(ctx, name, buckets[name])
+		}(i, name)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (b bucketS3Storage) checkBucket(ctx context.Context, name string, bucketName string) model.DependencyHealth {
+	health := model.DependencyHealth{
+		Name:   name,
+		Kind:   "s3-bucket",
+		Status: model.HealthUp,
+	}
+
+	start := time.Now()
+	_, err := b.api.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(bucketName),
+	})
+	health.LatencyMs = time.Since(start).Milliseconds()
+
+	if err != nil {
+		health.Status = model.HealthDown
+		health.Reason = bucketErrorReason(ctx, err)
+		health.Err = err
+	}
+	return health
+}
+
+func bucketErrorReason(ctx context.Context, err error) string {
+	if ctx.Err() != nil {
+		return "timeout"
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.ErrorCode() {
+		case "NotFound", "NoSuchBucket":
+			return "not_found"
+		case "Forbidden", "AccessDenied":
+			return "access_denied"
+		}
+	}
+	return "unavailable"
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/smithy-go"
+	"testing"
+)
+
+func TestBucketErrorReason(t *testing.T) {
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want string
+	}{
+		{name: "missing bucket", ctx: context.Background(), err: &smithy.GenericAPIError{Code: "NotFound"}, want: "not_found"},
+		{name: "no such bucket", ctx: context.Background(), err: &smithy.GenericAPIError{Code: "NoSuchBucket"}, want: "not_found"},
+		{name: "forbidden", ctx: context.Background(), err: &smithy.GenericAPIError{Code: "Forbidden"}, want: "access_denied"},
+		{name: "timed out", ctx: expired, err: context.Canceled, want: "timeout"},
+		{name: "other", ctx: context.Background(), err: errors.New("connection refused"), want: "unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bucketErrorReason(tt.ctx, tt.err); got != tt.want {
+				t.Fatalf("reason = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
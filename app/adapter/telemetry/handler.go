@@ -0,0 +1,314 @@
+package telemetry
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+)
+
+type tracedHandler struct {
+	next   port.Handler
+	tracer trace.Tracer
+}
+
+// TraceHandler decorates the service so every port.Handler call gets its own
+// span, child of the HTTP request span.
+func TraceHandler(handler port.Handler, tracerProvider trace.TracerProvider) port.Handler {
+	return &tracedHandler{
+		next:   handler,
+		tracer: tracerProvider.Tracer(instrumentationName),
+	}
+}
+
+func (h *tracedHandler) start(ctx context.Context, method string) (context.Context, trace.Span) {
+	return h.tracer.Start(ctx, "Handler."+method)
+}
+
+// end marks the span as failed only for internal errors; domain errors such as
+// validation or not found are expected outcomes and are recorded as attributes.
+func end(span trace.Span, err error) {
+	if err != nil {
+		kind := errs.KindOf(err)
+		span.SetAttributes(attribute.String("error.kind", string(kind)))
+		if kind == errs.KindInternal {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+func (h *tracedHandler) CheckReadiness(ctx context.Context) (report *model.HealthReport, err error) {
+	ctx, span := h.start(ctx, "CheckReadiness")
+	defer func() { end(span, err) }()
+	return h.next.CheckReadiness(ctx)
+}
+
+func (h *tracedHandler) CreateInteraction(ctx context.Context, interaction *model.PromotionInteraction) (err error) {
+	ctx, span := h.start(ctx, "CreateInteraction")
+	defer func() { end(span, err) }()
+	return h.next.CreateInteraction(ctx, interaction)
+}
+
+func (h *tracedHandler) GetCommentsByPromotionId(ctx context.Context, promotionId string, page *model.PageRequest) (result *model.Page[model.PromotionInteraction], err error) {
+	ctx, span := h.start(ctx, "GetCommentsByPromotionId")
+	defer func() { end(span, err) }()
+	return h.next.GetCommentsByPromotionId(ctx, promotionId, page)
+}
+
+func (h *tracedHandler) GetInteractionStatisticsByPromotionId(ctx context.Context, promotionId string) (result map[string]int, err error) {
+	ctx, span := h.start(ctx, "GetInteractionStatisticsByPromotionId")
+	defer func() { end(span, err) }()
+	return h.next.GetInteractionStatisticsByPromotionId(ctx, promotionId)
+}
+
+func (h *tracedHandler) GetInteractionStatisticsByUserId(ctx context.Context, userId string) (result map[string]int, err error) {
+	ctx, span := h.start(ctx, "GetInteractionStatisticsByUserId")
+	defer func() { end(span, err) }()
+	return h.next.GetInteractionStatisticsByUserId(ctx, userId)
+}
+
+func (h *tracedHandler) GetInteractionStatisticsByUserIdWithPromotionId(ctx context.Context, userId string, promotionId string) (result map[string]bool, err error) {
+	ctx, span := h.start(ctx, "GetInteractionStatisticsByUserIdWithPromotionId")
+	defer func() { end(span, err) }()
+	return h.next.GetInteractionStatisticsByUserIdWithPromotionId(ctx, userId, promotionId)
+}
+
+func (h *tracedHandler) CreateUser(ctx context.Context, user *model.User) (err error) {
+	ctx, span := h.start(ctx, "CreateUser")
+	defer func() { end(span, err) }()
+	return h.next.CreateUser(ctx, user)
+}
+
+func (h *tracedHandler) UpdateUserPicture(ctx context.Context, id string, image io.Reader) (err error) {
+	ctx, span := h.start(ctx, "UpdateUserPicture")
+	defer func() { end(span, err) }()
+	return h.next.UpdateUserPicture(ctx, id, image)
+}
+
+func (h *tracedHandler) UpdateUser(ctx context.Context, user *model.User) (err error) {
+	ctx, span := h.start(ctx, "UpdateUser")
+	defer func() { end(span, err) }()
+	return h.next.UpdateUser(ctx, user)
+}
+
+func (h *tracedHandler) DeleteUser(ctx context.Context, id string) (err error) {
+	ctx, span := h.start(ctx, "DeleteUser")
+	defer func() { end(span, err) }()
+	return h.next.DeleteUser(ctx, id)
+}
+
+func (h *tracedHandler) GetUserById(ctx context.Context, id string) (user *model.User, err error) {
+	ctx, span := h.start(ctx, "GetUserById")
+	defer func() { end(span, err) }()
+	return h.next.GetUserById(ctx, id)
+}
+
+func (h *tracedHandler) GetUserRank(ctx context.Context, page *model.PageRequest) (result *model.Page[model.User], err error) {
+	ctx, span := h.start(ctx, "GetUserRank")
+	defer func() { end(span, err) }()
+	return h.next.GetUserRank(ctx, page)
+}
+
+func (h *tracedHandler) Login(ctx context.Context, login *model.Login, client *model.SessionClient) (user *model.User, err error) {
+	ctx, span := h.start(ctx, "Login")
+	defer func() { end(span, err) }()
+	return h.next.Login(ctx, login, client)
+}
+
+func (h *tracedHandler) ExternalLogin(ctx context.Context, identity *model.ExternalIdentity) (user *model.User, err error) {
+	ctx, span := h.start(ctx, "ExternalLogin")
+	defer func() { end(span, err) }()
+	return h.next.ExternalLogin(ctx, identity)
+}
+
+func (h *tracedHandler) CreateSession(ctx context.Context, user *model.User, client *model.SessionClient) (token string, err error) {
+	ctx, span := h.start(ctx, "CreateSession")
+	defer func() { end(span, err) }()
+	return h.next.CreateSession(ctx, user, client)
+}
+
+func (h *tracedHandler) RefreshSession(ctx context.Context, refreshToken string, client *model.SessionClient) (user *model.User, token string, err error) {
+	ctx, span := h.start(ctx, "RefreshSession")
+	defer func() { end(span, err) }()
+	return h.next.RefreshSession(ctx, refreshToken, client)
+}
+
+func (h *tracedHandler) RevokeSession(ctx context.Context, id string) (err error) {
+	ctx, span := h.start(ctx, "RevokeSession")
+	defer func() { end(span, err) }()
+	return h.next.RevokeSession(ctx, id)
+}
+
+func (h *tracedHandler) RevokeUserSession(ctx context.Context, id string) (err error) {
+	ctx, span := h.start(ctx, "RevokeUserSession")
+	defer func() { end(span, err) }()
+	return h.next.RevokeUserSession(ctx, id)
+}
+
+func (h *tracedHandler) RevokeAllSessions(ctx context.Context) (err error) {
+	ctx, span := h.start(ctx, "RevokeAllSessions")
+	defer func() { end(span, err) }()
+	return h.next.RevokeAllSessions(ctx)
+}
+
+func (h *tracedHandler) GetActiveSessions(ctx context.Context) (result []model.Session, err error) {
+	ctx, span := h.start(ctx, "GetActiveSessions")
+	defer func() { end(span, err) }()
+	return h.next.GetActiveSessions(ctx)
+}
+
+func (h *tracedHandler) CreateApiKey(ctx context.Context, request *model.ApiKeyRequest) (key *model.CreatedApiKey, err error) {
+	ctx, span := h.start(ctx, "CreateApiKey")
+	defer func() { end(span, err) }()
+	return h.next.CreateApiKey(ctx, request)
+}
+
+func (h *tracedHandler) GetApiKeys(ctx context.Context) (result []model.ApiKey, err error) {
+	ctx, span := h.start(ctx, "GetApiKeys")
+	defer func() { end(span, err) }()
+	return h.next.GetApiKeys(ctx)
+}
+
+func (h *tracedHandler) RevokeApiKey(ctx context.Context, id string) (err error) {
+	ctx, span := h.start(ctx, "RevokeApiKey")
+	defer func() { end(span, err) }()
+	return h.next.RevokeApiKey(ctx, id)
+}
+
+func (h *tracedHandler) AuthenticateApiKey(ctx context.Context, key string) (actor *model.Actor, err error) {
+	ctx, span := h.start(ctx, "AuthenticateApiKey")
+	defer func() { end(span, err) }()
+	return h.next.AuthenticateApiKey(ctx, key)
+}
+
+func (h *tracedHandler) SendVerificationEmail(ctx context.Context) (err error) {
+	ctx, span := h.start(ctx, "SendVerificationEmail")
+	defer func() { end(span, err) }()
+	return h.next.SendVerificationEmail(ctx)
+}
+
+func (h *tracedHandler) VerifyEmail(ctx context.Context, token string) (err error) {
+	ctx, span := h.start(ctx, "VerifyEmail")
+	defer func() { end(span, err) }()
+	return h.next.VerifyEmail(ctx, token)
+}
+
+func (h *tracedHandler) ForgotPassword(ctx context.Context, email string) (err error) {
+	ctx, span := h.start(ctx, "ForgotPassword")
+	defer func() { end(span, err) }()
+	return h.next.ForgotPassword(ctx, email)
+}
+
+func (h *tracedHandler) ResetPassword(ctx context.Context, reset *model.PasswordReset) (err error) {
+	ctx, span := h.start(ctx, "ResetPassword")
+	defer func() { end(span, err) }()
+	return h.next.ResetPassword(ctx, reset)
+}
+
+func (h *tracedHandler) CreatePromotion(ctx context.Context, promotion *model.Promotion) (err error) {
+	ctx, span := h.start(ctx, "CreatePromotion")
+	defer func() { end(span, err) }()
+	return h.next.CreatePromotion(ctx, promotion)
+}
+
+func (h *tracedHandler) DeletePromotion(ctx context.Context, id string) (err error) {
+	ctx, span := h.start(ctx, "DeletePromotion")
+	defer func() { end(span, err) }()
+	return h.next.DeletePromotion(ctx, id)
+}
+
+func (h *tracedHandler) UpdatePromotion(ctx context.Context, promotion *model.Promotion) (err error) {
+	ctx, span := h.start(ctx, "UpdatePromotion")
+	defer func() { end(span, err) }()
+	return h.next.UpdatePromotion(ctx, promotion)
+}
+
+func (h *tracedHandler) UpdatePromotionImage(ctx context.Context, id string, image io.Reader) (err error) {
+	ctx, span := h.start(ctx, "UpdatePromotionImage")
+	defer func() { end(span, err) }()
+	return h.next.UpdatePromotionImage(ctx, id, image)
+}
+
+func (h *tracedHandler) UpdatePromotionStatus(ctx context.Context, id string, change *model.PromotionStatusChange) (promotion *model.Promotion, err error) {
+	ctx, span := h.start(ctx, "UpdatePromotionStatus")
+	defer func() { end(span, err) }()
+	return h.next.UpdatePromotionStatus(ctx, id, change)
+}
+
+func (h *tracedHandler) ExpirePromotions(ctx context.Context) (expired int, err error) {
+	ctx, span := h.start(ctx, "ExpirePromotions")
+	defer func() { end(span, err) }()
+	return h.next.ExpirePromotions(ctx)
+}
+
+func (h *tracedHandler) BackfillPromotionSortKeys(ctx context.Context) (backfilled int, err error) {
+	ctx, span := h.start(ctx, "BackfillPromotionSortKeys")
+	defer func() { end(span, err) }()
+	return h.next.BackfillPromotionSortKeys(ctx)
+}
+
+func (h *tracedHandler) ResolveExpiredReports(ctx context.Context, id string, resolution model.ExpiredReportResolution) (promotion *model.Promotion, err error) {
+	ctx, span := h.start(ctx, "ResolveExpiredReports")
+	defer func() { end(span, err) }()
+	return h.next.ResolveExpiredReports(ctx, id, resolution)
+}
+
+func (h *tracedHandler) GetPromotionById(ctx context.Context, id string) (promotion *model.Promotion, err error) {
+	ctx, span := h.start(ctx, "GetPromotionById")
+	defer func() { end(span, err) }()
+	return h.next.GetPromotionById(ctx, id)
+}
+
+func (h *tracedHandler) GetFavoritesPromotionsByUserId(ctx context.Context, userId string, page *model.PageRequest) (result *model.Page[model.Promotion], err error) {
+	ctx, span := h.start(ctx, "GetFavoritesPromotionsByUserId")
+	defer func() { end(span, err) }()
+	return h.next.GetFavoritesPromotionsByUserId(ctx, userId, page)
+}
+
+func (h *tracedHandler) GetPromotions(ctx context.Context, query *model.PromotionQuery) (result *model.Page[model.Promotion], err error) {
+	ctx, span := h.start(ctx, "GetPromotions")
+	defer func() { end(span, err) }()
+	return h.next.GetPromotions(ctx, query)
+}
+
+func (h *tracedHandler) GetCategories(ctx context.Context) (result []model.Category, err error) {
+	ctx, span := h.start(ctx, "GetCategories")
+	defer func() { end(span, err) }()
+	return h.next.GetCategories(ctx)
+}
+
+func (h *tracedHandler) GetUsers(ctx context.Context, page *model.PageRequest) (result *model.Page[model.User], err error) {
+	ctx, span := h.start(ctx, "GetUsers")
+	defer func() { end(span, err) }()
+	return h.next.GetUsers(ctx, page)
+}
+
+func (h *tracedHandler) UpdateUserRole(ctx context.Context, id string, role model.Role) (err error) {
+	ctx, span := h.start(ctx, "UpdateUserRole")
+	defer func() { end(span, err) }()
+	return h.next.UpdateUserRole(ctx, id, role)
+}
+
+func (h *tracedHandler) DeleteComment(ctx context.Context, id string) (err error) {
+	ctx, span := h.start(ctx, "DeleteComment")
+	defer func() { end(span, err) }()
+	return h.next.DeleteComment(ctx, id)
+}
+
+func (h *tracedHandler) CreateCategory(ctx context.Context, category *model.Category) (err error) {
+	ctx, span := h.start(ctx, "CreateCategory")
+	defer func() { end(span, err) }()
+	return h.next.CreateCategory(ctx, category)
+}
+
+func (h *tracedHandler) DeleteCategory(ctx context.Context, name string) (err error) {
+	ctx, span := h.start(ctx, "DeleteCategory")
+	defer func() { end(span, err) }()
+	return h.next.DeleteCategory(ctx, name)
+}
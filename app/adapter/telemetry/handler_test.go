@@ -0,0 +1,71 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"testing"
+)
+
+type fakeHandler struct {
+	port.Handler
+	err error
+}
+
+func (h fakeHandler) GetUserById(context.Context, string) (*model.User, error) {
+	return nil, h.err
+}
+
+func TestTraceHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		kind   string
+		status codes.Code
+		events int
+	}{
+		{name: "success", status: codes.Unset},
+		{name: "domain error", err: errs.NotFound("user"), kind: "not_found", status: codes.Unset},
+		{name: "internal error", err: errors.New("boom"), kind: "internal", status: codes.Error, events: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			handler := TraceHandler(fakeHandler{err: tt.err}, tracerProvider)
+
+			if _, err := handler.GetUserById(context.Background(), "user-1"); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("spans = %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "Handler.GetUserById" {
+				t.Fatalf("span name = %s", span.Name())
+			}
+			if span.Status().Code != tt.status || len(span.Events()) != tt.events {
+				t.Fatalf("status = %v, events = %v", span.Status(), span.Events())
+			}
+
+			var kind string
+			for _, attr := range span.Attributes() {
+				if attr.Key == attribute.Key("error.kind") {
+					kind = attr.Value.AsString()
+				}
+			}
+			if kind != tt.kind {
+				t.Fatalf("error.kind = %q, want %q", kind, tt.kind)
+			}
+		})
+	}
+}
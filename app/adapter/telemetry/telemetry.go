@@ -0,0 +1,83 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+	"pixelPromo/config"
+)
+
+const instrumentationName = "pixelPromo"
+
+// NewTracerProvider builds the provider selected by service.telemetry.exporter
+// and installs it, together with the W3C trace context and baggage propagators,
+// as the global default.
+func NewTracerProvider(lifecycle fx.Lifecycle, cfg *config.Config) (trace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		tracerProvider := noop.NewTracerProvider()
+		otel.SetTracerProvider(tracerProvider)
+		return tracerProvider, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.Viper.GetString("service.telemetry.service-name")),
+		semconv.DeploymentEnvironment(string(cfg.Env)),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.Viper.GetFloat64("service.telemetry.sample-ratio")))),
+	)
+	otel.SetTracerProvider(tracerProvider)
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return tracerProvider.Shutdown(ctx)
+		},
+	})
+
+	return tracerProvider, nil
+}
+
+func newExporter(cfg *config.Config) (sdktrace.SpanExporter, error) {
+	switch exporter := cfg.Viper.GetString("service.telemetry.exporter"); exporter {
+	case "otlp":
+		options := []otlptracehttp.Option{}
+		if endpoint := cfg.Viper.GetString("service.telemetry.otlp.endpoint"); endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if cfg.Viper.GetBool("service.telemetry.otlp.insecure") {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(context.Background(), options...)
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "none", "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("service.telemetry.exporter [%s] not supported", exporter)
+	}
+}
@@ -0,0 +1,63 @@
+package telemetry
+
+import (
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx/fxtest"
+	"pixelPromo/config"
+	"testing"
+)
+
+func newTestConfig(settings map[string]any) *config.Config {
+	v := viper.New()
+	v.Set("service.telemetry.service-name", "pixelpromo-test")
+	v.Set("service.telemetry.sample-ratio", 1.0)
+	for key, value := range settings {
+		v.Set(key, value)
+	}
+	return &config.Config{Viper: v, Env: config.Local}
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	tests := []struct {
+		name     string
+		exporter string
+		noop     bool
+		wantErr  bool
+	}{
+		{name: "unset", exporter: "", noop: true},
+		{name: "none", exporter: "none", noop: true},
+		{name: "stdout", exporter: "stdout"},
+		{name: "otlp", exporter: "otlp"},
+		{name: "unsupported", exporter: "zipkin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lifecycle := fxtest.NewLifecycle(t)
+			cfg := newTestConfig(map[string]any{
+				"service.telemetry.exporter":      tt.exporter,
+				"service.telemetry.otlp.endpoint": "127.0.0.1:4318",
+				"service.telemetry.otlp.insecure": true,
+			})
+
+			tracerProvider, err := NewTracerProvider(lifecycle, cfg)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, isNoop := tracerProvider.(noop.TracerProvider); isNoop != tt.noop {
+				t.Fatalf("tracer provider = %T", tracerProvider)
+			}
+
+			lifecycle.RequireStart()
+			lifecycle.RequireStop()
+		})
+	}
+}
@@ -5,17 +5,33 @@ import (
 	"go.uber.org/fx"
 	"pixelPromo/adapter/aws"
 	"pixelPromo/adapter/http"
+	"pixelPromo/adapter/mail"
+	"pixelPromo/adapter/metrics"
+	"pixelPromo/adapter/oauth"
+	"pixelPromo/adapter/ratelimit"
 	"pixelPromo/adapter/repository"
+	"pixelPromo/adapter/scheduler"
 	"pixelPromo/adapter/storage"
+	"pixelPromo/adapter/telemetry"
 	"pixelPromo/config"
 	"pixelPromo/domain/service"
 )
 
 var AdapterModule = fx.Module("adapter",
 	fx.Provide(
+		metrics.NewRegistry,
+		metrics.NewRegisterer,
+		metrics.NewGatherer,
+		metrics.NewDomainMetrics,
+		telemetry.NewTracerProvider,
 		aws.NewConfigAWS,
 		storage.NewBucketS3Storage,
+		mail.NewMailer,
+		oauth.NewIdentityProviders,
 		repository.NewDynamoDBRepository,
+		ratelimit.NewRateLimiter,
+		scheduler.NewScheduler,
+		http.NewKeySet,
 		http.NewRouter,
 		http.NewController,
 	),
@@ -38,21 +54,31 @@ var Module = fx.Options(
 	AdapterModule,
 	ServiceModule,
 	ConfigModule,
+	fx.Decorate(telemetry.TraceHandler),
 	fx.Invoke(bootstrap),
 )
 
 func bootstrap(
 	lifecycle fx.Lifecycle,
 	router http.Router,
+	scheduler scheduler.Scheduler,
 ) {
 
 	lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
-			go router.Run()
-			return nil
+			return router.Start()
 		},
 		OnStop: func(ctx context.Context) error {
-			return nil
+			return router.Shutdown(ctx)
+		},
+	})
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			return scheduler.Start()
+		},
+		OnStop: func(ctx context.Context) error {
+			return scheduler.Shutdown(ctx)
 		},
 	})
 
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -38,6 +39,7 @@ type Logger interface {
 	Error(msg string, fields ...Field)
 	Panic(msg string, fields ...Field)
 	Fatal(msg string, fields ...Field)
+	With(fields ...Field) Logger
 	Flush() error
 }
 
@@ -78,14 +80,39 @@ func (l logger) Fatal(msg string, fields ...Field) {
 	l.Log(levelFatal, msg, fields...)
 }
 
+func (l logger) With(fields ...Field) Logger {
+	return logger{
+		lg: l.lg.With(zapFields(fields)...),
+	}
+}
+
 func (l logger) Flush() error {
 	return l.lg.Sync()
 }
 
 func (l logger) Log(lvl level, msg string, fields ...Field) {
+	l.lg.WithOptions(zap.AddCallerSkip(2)).Log(zapcore.Level(lvl), msg, zapFields(fields)...)
+}
+
+func zapFields(fields []Field) []zap.Field {
 	zapFields := make([]zap.Field, len(fields))
 	for i, field := range fields {
 		zapFields[i] = zap.Any(field.Key, field.Val)
 	}
-	l.lg.WithOptions(zap.AddCallerSkip(2)).Log(zapcore.Level(lvl), msg, zapFields...)
+	return zapFields
+}
+
+type loggerKey struct{}
+
+func WithLogger(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, log)
+}
+
+// LoggerFromContext returns the request scoped logger stored in ctx, or fallback
+// when ctx does not belong to a request.
+func LoggerFromContext(ctx context.Context, fallback Logger) Logger {
+	if log, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return log
+	}
+	return fallback
 }
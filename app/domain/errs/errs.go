@@ -0,0 +1,102 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Kind string
+
+const (
+	KindValidation      Kind = "validation"
+	KindUnauthenticated Kind = "unauthenticated"
+	KindForbidden       Kind = "forbidden"
+	KindNotFound        Kind = "not_found"
+	KindConflict        Kind = "conflict"
+	KindTooManyRequests Kind = "too_many_requests"
+	KindTooLarge        Kind = "too_large"
+	KindInternal        Kind = "internal"
+)
+
+type Error struct {
+	Kind       Kind
+	Code       string
+	Message    string
+	Fields     []FieldError
+	RetryAfter time.Duration
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return e.Message
+	}
+
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Message, strings.Join(messages, ", "))
+}
+
+func Field(field string, code string, message string) FieldError {
+	return FieldError{Field: field, Code: code, Message: message}
+}
+
+func Required(field string) FieldError {
+	return Field(field, "required", fmt.Sprintf("%s is empty", field))
+}
+
+func Validation(message string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: "validation_failed", Message: message, Fields: fields}
+}
+
+func Invalid(code string, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func Unauthenticated(code string, message string) *Error {
+	return &Error{Kind: KindUnauthenticated, Code: code, Message: message}
+}
+
+func Forbidden(code string, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func NotFound(resource string) *Error {
+	return &Error{Kind: KindNotFound, Code: fmt.Sprintf("%s_not_found", strings.ReplaceAll(resource, " ", "_")), Message: fmt.Sprintf("%s not found", resource)}
+}
+
+func Conflict(code string, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func TooManyRequests(code string, message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindTooManyRequests, Code: code, Message: message, RetryAfter: retryAfter}
+}
+
+func TooLarge(code string, message string) *Error {
+	return &Error{Kind: KindTooLarge, Code: code, Message: message}
+}
+
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
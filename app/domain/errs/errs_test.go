@@ -0,0 +1,75 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     *Error
+		kind    Kind
+		code    string
+		message string
+	}{
+		{
+			name:    "validation without fields",
+			err:     Invalid("empty_body", "user is empty"),
+			kind:    KindValidation,
+			code:    "empty_body",
+			message: "user is empty",
+		},
+		{
+			name:    "validation lists its fields",
+			err:     Validation("user is invalid", Required("name"), Field("email", "invalid", "email is invalid")),
+			kind:    KindValidation,
+			code:    "validation_failed",
+			message: "user is invalid: name is empty, email is invalid",
+		},
+		{
+			name:    "not found code from a resource with spaces",
+			err:     NotFound("owner user"),
+			kind:    KindNotFound,
+			code:    "owner_user_not_found",
+			message: "owner user not found",
+		},
+		{
+			name:    "conflict",
+			err:     Conflict("email_taken", "email already in use"),
+			kind:    KindConflict,
+			code:    "email_taken",
+			message: "email already in use",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err.Kind != tt.kind || tt.err.Code != tt.code || tt.err.Error() != tt.message {
+				t.Fatalf("error = %+v (%q)", tt.err, tt.err.Error())
+			}
+		})
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{name: "domain error", err: Forbidden("not_owner", "resource belongs to another user"), want: KindForbidden},
+		{name: "wrapped domain error", err: fmt.Errorf("delete: %w", Unauthenticated("missing_actor", "authentication required")), want: KindUnauthenticated},
+		{name: "plain error", err: errors.New("boom"), want: KindInternal},
+		{name: "nil", want: KindInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.want {
+				t.Fatalf("kind = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
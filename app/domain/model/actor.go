@@ -0,0 +1,46 @@
+package model
+
+import "context"
+
+type Actor struct {
+	UserId   string
+	Role     Role
+	ApiKeyId string
+	Scopes   []Scope
+}
+
+func (a *Actor) HasRole(roles ...Role) bool {
+	role := a.Role
+	if role == "" {
+		role = RoleUser
+	}
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Actor) HasScope(scope Scope) bool {
+	if a.ApiKeyId == "" {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type actorKey struct{}
+
+func WithActor(ctx context.Context, actor *Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) *Actor {
+	actor, _ := ctx.Value(actorKey{}).(*Actor)
+	return actor
+}
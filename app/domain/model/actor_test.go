@@ -0,0 +1,71 @@
+package model
+
+import (
+	"context"
+	"testing"
+)
+
+func TestActorHasRole(t *testing.T) {
+	tests := []struct {
+		name  string
+		role  Role
+		roles []Role
+		want  bool
+	}{
+		{name: "listed role", role: RoleAdmin, roles: []Role{RoleModerator, RoleAdmin}, want: true},
+		{name: "unlisted role", role: RoleModerator, roles: []Role{RoleAdmin}},
+		{name: "empty role is a user", role: "", roles: []Role{RoleUser}, want: true},
+		{name: "empty role is not staff", role: "", roles: []Role{RoleModerator, RoleAdmin}},
+		{name: "no roles", role: RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor := &Actor{UserId: "user-1", Role: tt.role}
+			if got := actor.HasRole(tt.roles...); got != tt.want {
+				t.Fatalf("HasRole = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	if actor := ActorFromContext(context.Background()); actor != nil {
+		t.Fatalf("actor = %+v", actor)
+	}
+
+	actor := &Actor{UserId: "user-1", Role: RoleAdmin}
+	if got := ActorFromContext(WithActor(context.Background(), actor)); got != actor {
+		t.Fatalf("actor = %+v", got)
+	}
+}
+
+func TestRoleIsValid(t *testing.T) {
+	for role, want := range map[Role]bool{RoleUser: true, RoleModerator: true, RoleAdmin: true, "": false, "root": false} {
+		if role.IsValid() != want {
+			t.Errorf("%q valid = %v, want %v", role, !want, want)
+		}
+	}
+}
+
+func TestActorHasScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor Actor
+		scope Scope
+		want  bool
+	}{
+		{name: "session actors have every scope", actor: Actor{UserId: "user-1"}, scope: ScopeWritePromotions, want: true},
+		{name: "granted scope", actor: Actor{UserId: "user-1", ApiKeyId: "key", Scopes: []Scope{ScopeInteract}}, scope: ScopeInteract, want: true},
+		{name: "missing scope", actor: Actor{UserId: "user-1", ApiKeyId: "key", Scopes: []Scope{ScopeReadPromotions}}, scope: ScopeWritePromotions},
+		{name: "key without scopes", actor: Actor{UserId: "user-1", ApiKeyId: "key"}, scope: ScopeReadPromotions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.actor.HasScope(tt.scope); got != tt.want {
+				t.Fatalf("HasScope = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+type ApiKey struct {
+	Id         string     `json:"id" dynamodbav:"id"` //PK
+	UserId     string     `json:"userId" dynamodbav:"userId"`
+	Name       string     `json:"name" dynamodbav:"name"`
+	Scopes     []Scope    `json:"scopes" dynamodbav:"scopes"`
+	KeyHash    string     `json:"-" dynamodbav:"keyHash"`
+	Revoked    bool       `json:"revoked" dynamodbav:"revoked"`
+	CreatedAt  time.Time  `json:"createdAt" dynamodbav:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty" dynamodbav:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty" dynamodbav:"lastUsedAt,omitempty"`
+}
+
+func (k *ApiKey) IsActive(now time.Time) bool {
+	return !k.Revoked && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+type ApiKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []Scope    `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type CreatedApiKey struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+type Scope string
+
+func (s Scope) String() string {
+	return string(s)
+}
+
+func (s Scope) IsValid() bool {
+	switch s {
+	case ScopeReadPromotions, ScopeWritePromotions, ScopeInteract:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	ScopeReadPromotions  Scope = "promotions:read"
+	ScopeWritePromotions Scope = "promotions:write"
+	ScopeInteract        Scope = "interactions:write"
+)
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type HealthStatus string
+
+const (
+	HealthUp   HealthStatus = "up"
+	HealthDown HealthStatus = "down"
+)
+
+// DependencyHealth is exposed publicly, so it names dependencies by their config
+// key and reports a stable reason code; the underlying error stays in Err.
+type DependencyHealth struct {
+	Name      string       `json:"name"`
+	Kind      string       `json:"kind"`
+	Status    HealthStatus `json:"status"`
+	Reason    string       `json:"reason,omitempty"`
+	LatencyMs int64        `json:"latencyMs"`
+	Err       error        `json:"-"`
+}
+
+type HealthReport struct {
+	Status       HealthStatus       `json:"status"`
+	CheckedAt    time.Time          `json:"checkedAt"`
+	Dependencies []DependencyHealth `json:"dependencies"`
+}
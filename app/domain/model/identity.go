@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	PictureUrl    string
+}
+
+type UserIdentity struct {
+	Id        string    `json:"id" dynamodbav:"id"` //PK
+	Provider  string    `json:"provider" dynamodbav:"provider"`
+	Subject   string    `json:"subject" dynamodbav:"subject"`
+	UserId    string    `json:"userId" dynamodbav:"userId"`
+	CreatedAt time.Time `json:"createdAt" dynamodbav:"createdAt"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+type LoginAttempt struct {
+	Id          string    `json:"id" dynamodbav:"id"` //PK
+	Failures    int       `json:"failures" dynamodbav:"failures"`
+	WindowStart time.Time `json:"windowStart" dynamodbav:"windowStart,unixtime"`
+	LockedUntil time.Time `json:"lockedUntil" dynamodbav:"lockedUntil,unixtime"`
+	Ttl         int64     `json:"-" dynamodbav:"ttl"`
+}
+
+func (a *LoginAttempt) IsLocked(now time.Time) bool {
+	return a != nil && now.Before(a.LockedUntil)
+}
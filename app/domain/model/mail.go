@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type ActionToken struct {
+	Id        string        `json:"id" dynamodbav:"id"` //PK
+	UserId    string        `json:"userId" dynamodbav:"userId"`
+	Purpose   ActionPurpose `json:"purpose" dynamodbav:"purpose"`
+	Email     string        `json:"email" dynamodbav:"email"`
+	Used      bool          `json:"used" dynamodbav:"used"`
+	CreatedAt time.Time     `json:"createdAt" dynamodbav:"createdAt"`
+	ExpiresAt time.Time     `json:"expiresAt" dynamodbav:"expiresAt"`
+	Ttl       int64         `json:"-" dynamodbav:"ttl"`
+}
+
+type ActionPurpose string
+
+func (p ActionPurpose) String() string {
+	return string(p)
+}
+
+const (
+	VerifyEmail   ActionPurpose = "verify-email"
+	ResetPassword ActionPurpose = "reset-password"
+)
+
+type PasswordReset struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,11 @@
+package model
+
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type PageRequest struct {
+	Limit  int32  `json:"limit"`
+	Cursor string `json:"cursor"`
+}
@@ -3,17 +3,104 @@ package model
 import "time"
 
 type Promotion struct {
-	Id              string    `json:"id" dynamodbav:"id"` //PK
-	UserId          string    `json:"userId" dynamodbav:"userId"`
-	Title           string    `json:"title" dynamodbav:"title"`
-	OriginalPrice   float64   `json:"originalPrice" dynamodbav:"originalPrice"`
-	DiscountedPrice float64   `json:"discountedPrice" dynamodbav:"discountedPrice"`
-	DiscountBadge   float64   `json:"discountBadge" dynamodbav:"discountBadge"`
-	Platform        string    `json:"platform" dynamodbav:"platform"`
-	ImageUrl        string    `json:"imageUrl" dynamodbav:"imageUrl"`
-	Link            string    `json:"link" dynamodbav:"link"`
-	Categories      []string  `json:"categories" dynamodbav:"categories"`
-	CreatedAt       time.Time `json:"createdAt" dynamodbav:"createdAt"`
+	Id               string          `json:"id" dynamodbav:"id"` //PK
+	UserId           string          `json:"userId" dynamodbav:"userId"`
+	Title            string          `json:"title" dynamodbav:"title"`
+	OriginalPrice    float64         `json:"originalPrice" dynamodbav:"originalPrice"`
+	DiscountedPrice  float64         `json:"discountedPrice" dynamodbav:"discountedPrice"`
+	DiscountBadge    float64         `json:"discountBadge" dynamodbav:"discountBadge"`
+	Platform         string          `json:"platform" dynamodbav:"platform"`
+	ImageUrl         string          `json:"imageUrl" dynamodbav:"imageUrl"`
+	Link             string          `json:"link" dynamodbav:"link"`
+	Categories       []string        `json:"categories" dynamodbav:"categories"`
+	Popularity       int             `json:"popularity" dynamodbav:"popularity"`
+	HotScore         float64         `json:"hotScore" dynamodbav:"hotScore"`
+	Status           PromotionStatus `json:"status" dynamodbav:"status,omitempty"`
+	ExpiresAt        *time.Time      `json:"expiresAt,omitempty" dynamodbav:"expiresAt,omitempty"`
+	ExpiredReports   float64         `json:"expiredReports" dynamodbav:"expiredReports"`
+	ExpiredFlaggedAt *time.Time      `json:"expiredFlaggedAt,omitempty" dynamodbav:"expiredFlaggedAt,omitempty"`
+	CreatedAt        time.Time       `json:"createdAt" dynamodbav:"createdAt"`
+	UpdatedAt        time.Time       `json:"updatedAt" dynamodbav:"updatedAt"`
+	Ttl              int64           `json:"-" dynamodbav:"ttl,omitempty"`
+}
+
+// CurrentStatus treats promotions stored before statuses existed as active.
+func (p *Promotion) CurrentStatus() PromotionStatus {
+	if p.Status == "" {
+		return PromotionActive
+	}
+	return p.Status
+}
+
+// IsProbablyExpired reports whether community reports reached the threshold
+// for a promotion that is still active, which clients show as a badge.
+func (p *Promotion) IsProbablyExpired() bool {
+	return p.ExpiredFlaggedAt != nil && p.CurrentStatus() == PromotionActive
+}
+
+func (p *Promotion) IsExpiredAt(now time.Time) bool {
+	return p.ExpiresAt != nil && !now.Before(*p.ExpiresAt)
+}
+
+type PromotionStatus string
+
+func (s PromotionStatus) String() string {
+	return string(s)
+}
+
+func (s PromotionStatus) IsValid() bool {
+	_, ok := promotionTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether the lifecycle allows moving from s to next.
+// Removed is terminal, every other ended state can be reactivated.
+func (s PromotionStatus) CanTransitionTo(next PromotionStatus) bool {
+	for _, allowed := range promotionTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	PromotionActive  PromotionStatus = "active"
+	PromotionExpired PromotionStatus = "expired"
+	PromotionSoldOut PromotionStatus = "sold_out"
+	PromotionRemoved PromotionStatus = "removed"
+)
+
+var promotionTransitions = map[PromotionStatus][]PromotionStatus{
+	PromotionActive:  {PromotionExpired, PromotionSoldOut, PromotionRemoved},
+	PromotionExpired: {PromotionActive, PromotionRemoved},
+	PromotionSoldOut: {PromotionActive, PromotionRemoved},
+	PromotionRemoved: {},
+}
+
+type ExpiredReportResolution string
+
+func (r ExpiredReportResolution) String() string {
+	return string(r)
+}
+
+func (r ExpiredReportResolution) IsValid() bool {
+	switch r {
+	case ReportConfirmed, ReportDismissed:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	ReportConfirmed ExpiredReportResolution = "confirmed"
+	ReportDismissed ExpiredReportResolution = "dismissed"
+)
+
+type PromotionStatusChange struct {
+	Status    PromotionStatus `json:"status"`
+	ExpiresAt *time.Time      `json:"expiresAt"`
 }
 
 type Category struct {
@@ -27,12 +114,13 @@ type PromotionInteraction struct {
 	UserId          string          `json:"userId" dynamodbav:"userId"`
 	Comment         string          `json:"comment" dynamodbav:"comment"`
 	InteractionType InteractionType `json:"interactionType" dynamodbav:"interactionType"`
+	Weight          float64         `json:"weight,omitempty" dynamodbav:"weight,omitempty"`
 	CreatedAt       time.Time       `json:"createdAt" dynamodbav:"createdAt"`
 }
 
 func (p *PromotionInteraction) IsValidType() bool {
 	switch p.InteractionType {
-	case Create, Comment, Favorite, Like:
+	case Create, Comment, Favorite, Like, ExpiredReport:
 		return true
 	default:
 		return false
@@ -46,15 +134,43 @@ func (t InteractionType) String() string {
 }
 
 const (
-	Favorite InteractionType = "favorite"
-	Like     InteractionType = "like"
-	Comment  InteractionType = "comment"
-	Create   InteractionType = "create"
+	Favorite      InteractionType = "favorite"
+	Like          InteractionType = "like"
+	Comment       InteractionType = "comment"
+	Create        InteractionType = "create"
+	ExpiredReport InteractionType = "expired_report"
+)
+
+type PromotionSort string
+
+func (s PromotionSort) String() string {
+	return string(s)
+}
+
+func (s PromotionSort) IsValid() bool {
+	switch s {
+	case SortNewest, SortDiscount, SortPrice, SortPopular, SortHot:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	SortNewest   PromotionSort = "newest"
+	SortDiscount PromotionSort = "discount"
+	SortPrice    PromotionSort = "price"
+	SortPopular  PromotionSort = "popular"
+	SortHot      PromotionSort = "hot"
 )
 
 type PromotionQuery struct {
-	Categories []string `json:"category"`
-	Search     string   `json:"search"`
-	UserId     string   `json:"userId"`
-	Limit      int32    `json:"limit"`
+	Categories []string      `json:"category"`
+	Search     string        `json:"search"`
+	UserId     string        `json:"userId"`
+	Sort       PromotionSort `json:"sort"`
+	Limit      int32         `json:"limit"`
+	Cursor     string        `json:"cursor"`
+	// IncludeExpired also lists expired and sold out promotions, removed ones stay hidden.
+	IncludeExpired bool `json:"includeExpired"`
 }
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPromotionStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from PromotionStatus
+		to   PromotionStatus
+		want bool
+	}{
+		{from: PromotionActive, to: PromotionExpired, want: true},
+		{from: PromotionActive, to: PromotionSoldOut, want: true},
+		{from: PromotionActive, to: PromotionRemoved, want: true},
+		{from: PromotionActive, to: PromotionActive},
+		{from: PromotionExpired, to: PromotionActive, want: true},
+		{from: PromotionExpired, to: PromotionSoldOut},
+		{from: PromotionSoldOut, to: PromotionActive, want: true},
+		{from: PromotionSoldOut, to: PromotionRemoved, want: true},
+		{from: PromotionRemoved, to: PromotionActive},
+		{from: "archived", to: PromotionActive},
+	}
+
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestPromotionCurrentStatus(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Second), now.Add(time.Second)
+
+	tests := []struct {
+		name      string
+		promotion Promotion
+		status    PromotionStatus
+		expired   bool
+	}{
+		{name: "legacy without status", promotion: Promotion{}, status: PromotionActive},
+		{name: "sold out", promotion: Promotion{Status: PromotionSoldOut}, status: PromotionSoldOut},
+		{name: "expires later", promotion: Promotion{ExpiresAt: &future}, status: PromotionActive},
+		{name: "expired", promotion: Promotion{ExpiresAt: &past}, status: PromotionActive, expired: true},
+		{name: "expires now", promotion: Promotion{ExpiresAt: &now}, status: PromotionActive, expired: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := tt.promotion.CurrentStatus(); status != tt.status {
+				t.Fatalf("status = %s, want %s", status, tt.status)
+			}
+			if expired := tt.promotion.IsExpiredAt(now); expired != tt.expired {
+				t.Fatalf("expired = %v, want %v", expired, tt.expired)
+			}
+		})
+	}
+}
+
+func TestPromotionIsProbablyExpired(t *testing.T) {
+	flaggedAt := time.Now()
+
+	tests := []struct {
+		name      string
+		promotion Promotion
+		want      bool
+	}{
+		{name: "not flagged", promotion: Promotion{Status: PromotionActive, ExpiredReports: 1}},
+		{name: "flagged and active", promotion: Promotion{Status: PromotionActive, ExpiredFlaggedAt: &flaggedAt}, want: true},
+		{name: "flagged legacy promotion", promotion: Promotion{ExpiredFlaggedAt: &flaggedAt}, want: true},
+		{name: "flagged but already ended", promotion: Promotion{Status: PromotionExpired, ExpiredFlaggedAt: &flaggedAt}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.promotion.IsProbablyExpired(); got != tt.want {
+			t.Errorf("%s: probably expired = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
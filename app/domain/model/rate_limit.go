@@ -0,0 +1,56 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+type RateLimitDecision struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// RateLimitBucket is a token bucket holding up to Limit tokens and refilling
+// Limit tokens per Period, so short bursts are allowed but the average rate is not.
+type RateLimitBucket struct {
+	Key       string    `json:"key" dynamodbav:"key"` //PK
+	Tokens    float64   `json:"tokens" dynamodbav:"tokens"`
+	UpdatedAt time.Time `json:"updatedAt" dynamodbav:"updatedAt"`
+	Ttl       int64     `json:"-" dynamodbav:"ttl"`
+}
+
+func (b *RateLimitBucket) Take(limit RateLimit, now time.Time) RateLimitDecision {
+	capacity := float64(limit.Limit)
+	rate := capacity / limit.Period.Seconds()
+
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(b.UpdatedAt).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*rate)
+	}
+	b.UpdatedAt = now
+	b.Ttl = now.Add(limit.Period).Unix() + 1
+
+	decision := RateLimitDecision{Limit: limit.Limit}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = secondsToDuration((1 - b.Tokens) / rate)
+	}
+	decision.Remaining = int(math.Floor(b.Tokens))
+	decision.ResetAfter = secondsToDuration((capacity - b.Tokens) / rate)
+	return decision
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitBucketTake(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := RateLimit{Limit: 10, Period: 10 * time.Second}
+
+	tests := []struct {
+		name   string
+		bucket RateLimitBucket
+		now    time.Time
+		want   RateLimitDecision
+		tokens float64
+	}{
+		{
+			name:   "new bucket starts full",
+			now:    start,
+			want:   RateLimitDecision{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: time.Second},
+			tokens: 9,
+		},
+		{
+			name:   "empty bucket is denied until one token refilled",
+			bucket: RateLimitBucket{Tokens: 0.25, UpdatedAt: start},
+			now:    start,
+			want:   RateLimitDecision{Limit: 10, Remaining: 0, RetryAfter: 750 * time.Millisecond, ResetAfter: 9750 * time.Millisecond},
+			tokens: 0.25,
+		},
+		{
+			name:   "refills at limit per period",
+			bucket: RateLimitBucket{Tokens: 0, UpdatedAt: start},
+			now:    start.Add(3 * time.Second),
+			want:   RateLimitDecision{Allowed: true, Limit: 10, Remaining: 2, ResetAfter: 8 * time.Second},
+			tokens: 2,
+		},
+		{
+			name:   "refill is capped at the limit",
+			bucket: RateLimitBucket{Tokens: 5, UpdatedAt: start},
+			now:    start.Add(time.Hour),
+			want:   RateLimitDecision{Allowed: true, Limit: 10, Remaining: 9, ResetAfter: time.Second},
+			tokens: 9,
+		},
+		{
+			name:   "clock going backwards does not refill",
+			bucket: RateLimitBucket{Tokens: 0.5, UpdatedAt: start},
+			now:    start.Add(-time.Minute),
+			want:   RateLimitDecision{Limit: 10, Remaining: 0, RetryAfter: 500 * time.Millisecond, ResetAfter: 9500 * time.Millisecond},
+			tokens: 0.5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket := tt.bucket
+			got := bucket.Take(limit, tt.now)
+			if got != tt.want {
+				t.Fatalf("decision = %+v, want %+v", got, tt.want)
+			}
+			if bucket.Tokens != tt.tokens {
+				t.Fatalf("tokens = %v, want %v", bucket.Tokens, tt.tokens)
+			}
+			if !bucket.UpdatedAt.Equal(tt.now) || bucket.Ttl != tt.now.Add(limit.Period).Unix()+1 {
+				t.Fatalf("updatedAt = %s, ttl = %d", bucket.UpdatedAt, bucket.Ttl)
+			}
+		})
+	}
+}
+
+func TestRateLimitBucketBurst(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	limit := RateLimit{Limit: 3, Period: time.Minute}
+
+	var bucket RateLimitBucket
+	for i := 0; i < 3; i++ {
+		if !bucket.Take(limit, now).Allowed {
+			t.Fatalf("request %d denied inside the burst", i+1)
+		}
+	}
+
+	decision := bucket.Take(limit, now)
+	if decision.Allowed || decision.RetryAfter != 20*time.Second {
+		t.Fatalf("decision after burst = %+v", decision)
+	}
+}
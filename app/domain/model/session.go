@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type Session struct {
+	Id         string    `json:"-" dynamodbav:"id"` //PK
+	FamilyId   string    `json:"id" dynamodbav:"familyId"`
+	UserId     string    `json:"userId" dynamodbav:"userId"`
+	TokenHash  string    `json:"-" dynamodbav:"tokenHash"`
+	Device     string    `json:"device" dynamodbav:"device"`
+	Ip         string    `json:"ip" dynamodbav:"ip"`
+	Rotated    bool      `json:"-" dynamodbav:"rotated"`
+	Revoked    bool      `json:"-" dynamodbav:"revoked"`
+	CreatedAt  time.Time `json:"createdAt" dynamodbav:"createdAt"`
+	LastUsedAt time.Time `json:"lastUsedAt" dynamodbav:"lastUsedAt"`
+	ExpiresAt  time.Time `json:"expiresAt" dynamodbav:"expiresAt"`
+	Ttl        int64     `json:"-" dynamodbav:"ttl"`
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return !s.Rotated && !s.Revoked && now.Before(s.ExpiresAt)
+}
+
+type SessionClient struct {
+	Device string
+	Ip     string
+}
@@ -1,19 +1,63 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
 
 type User struct {
-	Id         string    `json:"id" dynamodbav:"id"` //PK
-	Email      string    `json:"email" dynamodbav:"email"`
-	Name       string    `json:"name" dynamodbav:"name"`
-	Password   string    `json:"password" dynamodbav:"password"`
-	PictureUrl string    `json:"pictureUrl" dynamodbav:"pictureUrl"`
-	TotalScore int       `json:"totalScore" dynamodbav:"totalScore"`
-	Level      int       `json:"level" dynamodbav:"level"`
-	Elo        string    `json:"elo" dynamodbav:"elo"`
-	CreatedAt  time.Time `json:"createdAt" dynamodbav:"createdAt"`
+	Id            string    `json:"id" dynamodbav:"id"` //PK
+	Email         string    `json:"email" dynamodbav:"email"`
+	Name          string    `json:"name" dynamodbav:"name"`
+	Password      string    `json:"password,omitempty" dynamodbav:"password"`
+	PictureUrl    string    `json:"pictureUrl" dynamodbav:"pictureUrl"`
+	TotalScore    int       `json:"totalScore" dynamodbav:"totalScore"`
+	Level         int       `json:"level" dynamodbav:"level"`
+	Elo           string    `json:"elo" dynamodbav:"elo"`
+	Role          Role      `json:"role" dynamodbav:"role"`
+	EmailVerified bool      `json:"emailVerified" dynamodbav:"emailVerified"`
+	CreatedAt     time.Time `json:"createdAt" dynamodbav:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt" dynamodbav:"updatedAt"`
 }
 
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	safe := user(u)
+	safe.Password = ""
+	return json.Marshal(safe)
+}
+
+type UserEmail struct {
+	Email  string `json:"email" dynamodbav:"email"` //PK
+	UserId string `json:"userId" dynamodbav:"userId"`
+}
+
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+type Role string
+
+func (r Role) String() string {
+	return string(r)
+}
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
 type Login struct {
 	Email    string `json:"email" dynamodbav:"email"`
 	Password string `json:"password" dynamodbav:"password"`
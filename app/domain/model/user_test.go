@@ -0,0 +1,58 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUserJSONOmitsPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{name: "value", value: User{Id: "1", Password: "$2a$12$hash"}},
+		{name: "pointer", value: &User{Id: "1", Password: "$2a$12$hash"}},
+		{name: "slice", value: []User{{Id: "1", Password: "$2a$12$hash"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(body), "password") || strings.Contains(string(body), "hash") {
+				t.Fatalf("password serialized: %s", body)
+			}
+		})
+	}
+}
+
+func TestUserJSONReadsPassword(t *testing.T) {
+	var user User
+	if err := json.Unmarshal([]byte(`{"email":"a@b.c","password":"secret"}`), &user); err != nil {
+		t.Fatal(err)
+	}
+	if user.Password != "secret" {
+		t.Fatalf("password = %q", user.Password)
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{email: "user@mail.com", want: "user@mail.com"},
+		{email: "User@Mail.COM", want: "user@mail.com"},
+		{email: "  user@mail.com\t", want: "user@mail.com"},
+		{email: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeEmail(tt.email); got != tt.want {
+			t.Errorf("NormalizeEmail(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
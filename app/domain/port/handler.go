@@ -7,8 +7,10 @@ import (
 )
 
 type Handler interface {
+	CheckReadiness(context.Context) (*model.HealthReport, error)
+
 	CreateInteraction(context.Context, *model.PromotionInteraction) error
-	GetCommentsByPromotionId(context.Context, string) ([]model.PromotionInteraction, error)
+	GetCommentsByPromotionId(context.Context, string, *model.PageRequest) (*model.Page[model.PromotionInteraction], error)
 	GetInteractionStatisticsByPromotionId(context.Context, string) (map[string]int, error)
 	GetInteractionStatisticsByUserId(context.Context, string) (map[string]int, error)
 	GetInteractionStatisticsByUserIdWithPromotionId(context.Context, string, string) (map[string]bool, error)
@@ -18,15 +20,43 @@ type Handler interface {
 	UpdateUser(context.Context, *model.User) error
 	DeleteUser(context.Context, string) error
 	GetUserById(context.Context, string) (*model.User, error)
-	GetUserRank(context.Context, int) ([]model.User, error)
-	Login(context.Context, *model.Login) (*model.User, error)
+	GetUserRank(context.Context, *model.PageRequest) (*model.Page[model.User], error)
+	Login(context.Context, *model.Login, *model.SessionClient) (*model.User, error)
+	ExternalLogin(context.Context, *model.ExternalIdentity) (*model.User, error)
+
+	CreateSession(context.Context, *model.User, *model.SessionClient) (string, error)
+	RefreshSession(context.Context, string, *model.SessionClient) (*model.User, string, error)
+	RevokeSession(context.Context, string) error
+	RevokeUserSession(context.Context, string) error
+	RevokeAllSessions(context.Context) error
+	GetActiveSessions(context.Context) ([]model.Session, error)
+
+	CreateApiKey(context.Context, *model.ApiKeyRequest) (*model.CreatedApiKey, error)
+	GetApiKeys(context.Context) ([]model.ApiKey, error)
+	RevokeApiKey(context.Context, string) error
+	AuthenticateApiKey(context.Context, string) (*model.Actor, error)
+
+	SendVerificationEmail(context.Context) error
+	VerifyEmail(context.Context, string) error
+	ForgotPassword(context.Context, string) error
+	ResetPassword(context.Context, *model.PasswordReset) error
 
 	CreatePromotion(context.Context, *model.Promotion) error
 	DeletePromotion(context.Context, string) error
 	UpdatePromotion(context.Context, *model.Promotion) error
 	UpdatePromotionImage(context.Context, string, io.Reader) error
+	UpdatePromotionStatus(context.Context, string, *model.PromotionStatusChange) (*model.Promotion, error)
+	ExpirePromotions(context.Context) (int, error)
+	ResolveExpiredReports(context.Context, string, model.ExpiredReportResolution) (*model.Promotion, error)
 	GetPromotionById(context.Context, string) (*model.Promotion, error)
-	GetFavoritesPromotionsByUserId(context.Context, string) ([]model.Promotion, error)
-	GetPromotions(context.Context, *model.PromotionQuery) ([]model.Promotion, error)
+	GetFavoritesPromotionsByUserId(context.Context, string, *model.PageRequest) (*model.Page[model.Promotion], error)
+	GetPromotions(context.Context, *model.PromotionQuery) (*model.Page[model.Promotion], error)
+	BackfillPromotionSortKeys(context.Context) (int, error)
 	GetCategories(context.Context) ([]model.Category, error)
+
+	GetUsers(context.Context, *model.PageRequest) (*model.Page[model.User], error)
+	UpdateUserRole(context.Context, string, model.Role) error
+	DeleteComment(context.Context, string) error
+	CreateCategory(context.Context, *model.Category) error
+	DeleteCategory(context.Context, string) error
 }
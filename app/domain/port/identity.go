@@ -0,0 +1,13 @@
+package port
+
+import (
+	"context"
+	"net/url"
+	"pixelPromo/domain/model"
+)
+
+type IdentityProvider interface {
+	Name() string
+	AuthURL(state string, verifier string, redirectURL string) string
+	Resolve(ctx context.Context, callback url.Values, verifier string, redirectURL string) (*model.ExternalIdentity, error)
+}
@@ -0,0 +1,10 @@
+package port
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+)
+
+type Mailer interface {
+	Send(context.Context, *model.Email) error
+}
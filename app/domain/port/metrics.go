@@ -0,0 +1,13 @@
+package port
+
+import "pixelPromo/domain/model"
+
+type Metrics interface {
+	PromotionCreated()
+	PromotionStatusChanged(from model.PromotionStatus, to model.PromotionStatus)
+	InteractionCreated(model.InteractionType)
+	InteractionRemoved(model.InteractionType)
+	LoginSucceeded(method string)
+	LoginFailed(method string, reason string)
+	ScorePointsGranted(points int)
+}
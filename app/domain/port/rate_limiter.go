@@ -0,0 +1,10 @@
+package port
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+)
+
+type RateLimiter interface {
+	Allow(context.Context, string, model.RateLimit) (*model.RateLimitDecision, error)
+}
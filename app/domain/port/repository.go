@@ -2,31 +2,74 @@ package port
 
 import (
 	"context"
+	"errors"
 	"pixelPromo/domain/model"
 	"time"
 )
 
+var ErrConditionFailed = errors.New("condition failed")
+
 type Repository interface {
+	CheckHealth(context.Context) []model.DependencyHealth
+
 	CreateOrUpdateInteraction(context.Context, *model.PromotionInteraction) error
 	GetInteractionById(context.Context, string) (*model.PromotionInteraction, error)
 	DeleteInteraction(context.Context, string) error
 	GetInteractionsByPromotionId(context.Context, string) ([]model.PromotionInteraction, error)
+	GetInteractionsByPromotionIdAndType(context.Context, string, model.InteractionType) ([]model.PromotionInteraction, error)
 	GetInteractionsByUserId(context.Context, string) ([]model.PromotionInteraction, error)
 	GetInteractionsByUserIdWithPromotionId(context.Context, string, string) ([]model.PromotionInteraction, error)
-	GetInteractionsByTypeWithPromotionId(context.Context, model.InteractionType, string) ([]model.PromotionInteraction, error)
-	GetInteractionsByTypeWithUserId(context.Context, model.InteractionType, string) ([]model.PromotionInteraction, error)
+	GetInteractionsByTypeWithPromotionId(context.Context, model.InteractionType, string, *model.PageRequest) ([]model.PromotionInteraction, string, error)
+	GetInteractionsByTypeWithUserId(context.Context, model.InteractionType, string, *model.PageRequest) ([]model.PromotionInteraction, string, error)
+	CreateUser(context.Context, *model.User) error
 	CreateOrUpdateUser(context.Context, *model.User) error
+	UpdateUserEmail(context.Context, *model.User, string) error
+	UpdateUserPassword(context.Context, string, string) error
+	VerifyUserEmail(context.Context, string, string) error
+	ResetUnverifiedUser(context.Context, string, string) error
+	AddUserScore(context.Context, *model.User, int) error
 	CreateOrUpdateUserScore(context.Context, *model.UserScore) error
 	DeleteUser(context.Context, string) error
 	GetAllUserScoreByTimeWithUserId(context.Context, string, time.Time) ([]model.UserScore, error)
 	GetAllUserScoreByTime(context.Context, time.Time) ([]model.UserScore, error)
 	GetUserById(context.Context, string) (*model.User, error)
-	GetAllUsers(context.Context) ([]model.User, error)
-	GetUserByEmailAndPassword(context.Context, string, string) (*model.User, error)
+	GetAllUsers(context.Context, *model.PageRequest) ([]model.User, string, error)
+	GetUserByEmail(context.Context, string) (*model.User, error)
 	CreateOrUpdatePromotion(context.Context, *model.Promotion) error
+	UpdatePromotionPopularity(context.Context, *model.Promotion, int) error
+	GetPromotionsWithoutSortKeys(context.Context) ([]model.Promotion, error)
+	BackfillPromotionSortKeys(context.Context, *model.Promotion) error
+	UpdatePromotion(context.Context, *model.Promotion, model.PromotionStatus) error
+	UpdatePromotionStatus(context.Context, *model.Promotion, model.PromotionStatus) error
+	UpdatePromotionExpiredReports(context.Context, *model.Promotion, float64) error
+	GetPromotionsDueToExpire(context.Context, time.Time, int32) ([]model.Promotion, error)
 	DeletePromotion(context.Context, string) error
 	GetPromotionById(context.Context, string) (*model.Promotion, error)
-	GetPromotionsWithParams(context.Context, *model.PromotionQuery) ([]model.Promotion, error)
+	GetPromotionsWithParams(context.Context, *model.PromotionQuery) ([]model.Promotion, string, error)
 	GetPromotionsByCategory(context.Context, string) ([]model.Promotion, error)
 	GetCategories(context.Context) ([]model.Category, error)
+	CreateOrUpdateCategory(context.Context, *model.Category) error
+	DeleteCategory(context.Context, string) error
+	CreateOrUpdateSession(context.Context, *model.Session) error
+	RotateSession(context.Context, string, time.Time) error
+	RevokeSessionById(context.Context, string) error
+	GetSessionById(context.Context, string) (*model.Session, error)
+	GetSessionsByFamilyId(context.Context, string) ([]model.Session, error)
+	GetSessionsByUserId(context.Context, string) ([]model.Session, error)
+	CreateActionToken(context.Context, *model.ActionToken) error
+	GetActionTokenById(context.Context, string) (*model.ActionToken, error)
+	ConsumeActionToken(context.Context, string) error
+	GetLoginAttempt(context.Context, string) (*model.LoginAttempt, error)
+	IncrementLoginFailures(context.Context, string, time.Time, time.Duration) (*model.LoginAttempt, error)
+	LockLoginAttempt(context.Context, string, time.Time, time.Time) error
+	DeleteLoginAttempt(context.Context, string) error
+	GetRateLimitBucket(context.Context, string) (*model.RateLimitBucket, error)
+	PutRateLimitBucket(context.Context, *model.RateLimitBucket, time.Time) error
+	CreateOrUpdateUserIdentity(context.Context, *model.UserIdentity) error
+	GetUserIdentityById(context.Context, string) (*model.UserIdentity, error)
+	CreateOrUpdateApiKey(context.Context, *model.ApiKey) error
+	TouchApiKey(context.Context, string, time.Time) error
+	RevokeApiKeyById(context.Context, string) error
+	GetApiKeyById(context.Context, string) (*model.ApiKey, error)
+	GetApiKeysByUserId(context.Context, string) ([]model.ApiKey, error)
 }
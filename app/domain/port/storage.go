@@ -3,9 +3,11 @@ package port
 import (
 	"context"
 	"io"
+	"pixelPromo/domain/model"
 )
 
 type Storage interface {
+	CheckHealth(context.Context) []model.DependencyHealth
 	UploadUserPicture(context.Context, string, io.Reader) (string, error)
 	UploadPromotionImage(context.Context, string, io.Reader) (string, error)
 }
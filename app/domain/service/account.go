@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"strings"
+	"time"
+)
+
+func (s *service) SendVerificationEmail(ctx context.Context) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.rp.GetUserById(ctx, actor.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if user == nil {
+		err = errs.NotFound("user")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err = s.sendVerificationEmail(ctx, user); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (s *service) VerifyEmail(ctx context.Context, token string) error {
+	actionToken, err := s.consumeActionToken(ctx, token, model.VerifyEmail)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	user, err := s.rp.GetUserById(ctx, actionToken.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if user == nil || user.Email != actionToken.Email {
+		err = errs.Invalid("invalid_token", "token is invalid or expired")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	err = s.rp.VerifyUserEmail(ctx, user.Id, actionToken.Email)
+	if errors.Is(err, port.ErrConditionFailed) {
+		err = errs.Invalid("invalid_token", "token is invalid or expired")
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("email verified")
+	return nil
+}
+
+func (s *service) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.rp.GetUserByEmail(ctx, model.NormalizeEmail(email))
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if user == nil {
+		s.logger(ctx).Debug("password reset requested for unknown email")
+		return nil
+	}
+
+	token, err := s.createActionToken(ctx, user, model.ResetPassword)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	err = s.ml.Send(ctx, &model.Email{
+		To:      user.Email,
+		Subject: "PixelPromo - Redefinição de senha",
+		Body: fmt.Sprintf("Olá %s,\n\nPara redefinir sua senha acesse o link abaixo:\n\n%s\n\nSe você não solicitou a redefinição, ignore este email.\n",
+			user.Name, fmt.Sprintf(s.cfg.Viper.GetString("service.mail.links.reset-password"), token)),
+	})
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("password reset email sent")
+	return nil
+}
+
+func (s *service) ResetPassword(ctx context.Context, reset *model.PasswordReset) error {
+	if reset == nil || len(strings.TrimSpace(reset.Password)) == 0 {
+		err := errs.Validation("password reset is invalid", errs.Required("password"))
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	actionToken, err := s.consumeActionToken(ctx, reset.Token, model.ResetPassword)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	user, err := s.rp.GetUserById(ctx, actionToken.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if user == nil {
+		err = errs.Invalid("invalid_token", "token is invalid or expired")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	password, err := s.hashPassword(reset.Password)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	err = s.rp.UpdateUserPassword(ctx, user.Id, password)
+	if errors.Is(err, port.ErrConditionFailed) {
+		err = errs.Invalid("invalid_token", "token is invalid or expired")
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	// the reset link went through the mailbox, which proves the address unless
+	// it changed since
+	err = s.rp.VerifyUserEmail(ctx, user.Id, actionToken.Email)
+	if err != nil && !errors.Is(err, port.ErrConditionFailed) {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = s.RevokeAllSessions(model.WithActor(ctx, &model.Actor{UserId: user.Id})); err != nil {
+		return err
+	}
+
+	// whoever was locked out of this account just proved they own the mailbox
+	if err = s.resetLoginFailures(ctx, user.Email); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("password reset")
+	return nil
+}
+
+func (s *service) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, err := s.createActionToken(ctx, user, model.VerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	return s.ml.Send(ctx, &model.Email{
+		To:      user.Email,
+		Subject: "PixelPromo - Confirme seu email",
+		Body: fmt.Sprintf("Olá %s,\n\nConfirme seu email acessando o link abaixo:\n\n%s\n",
+			user.Name, fmt.Sprintf(s.cfg.Viper.GetString("service.mail.links.verify-email"), token)),
+	})
+}
+
+func (s *service) createActionToken(ctx context.Context, user *model.User, purpose model.ActionPurpose) (string, error) {
+	id, err := randomId()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Viper.GetDuration(fmt.Sprintf("service.auth.action-token.ttl.%s", purpose)))
+	actionToken := model.ActionToken{
+		Id:        id,
+		UserId:    user.Id,
+		Purpose:   purpose,
+		Email:     user.Email,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+		Ttl:       expiresAt.Unix(),
+	}
+
+	if err = s.rp.CreateActionToken(ctx, &actionToken); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s", id, s.signActionToken(id, purpose)), nil
+}
+
+func (s *service) consumeActionToken(ctx context.Context, token string, purpose model.ActionPurpose) (*model.ActionToken, error) {
+	invalid := errs.Invalid("invalid_token", "token is invalid or expired")
+
+	id, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(s.signActionToken(id, purpose))) {
+		return nil, invalid
+	}
+
+	actionToken, err := s.rp.GetActionTokenById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if actionToken == nil || actionToken.Purpose != purpose || actionToken.Used || !time.Now().Before(actionToken.ExpiresAt) {
+		return nil, invalid
+	}
+
+	err = s.rp.ConsumeActionToken(ctx, id)
+	if errors.Is(err, port.ErrConditionFailed) {
+		return nil, invalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return actionToken, nil
+}
+
+func (s *service) signActionToken(id string, purpose model.ActionPurpose) string {
+	mac := hmac.New(sha256.New, s.actionTokenSecret)
+	mac.Write([]byte(purpose.String() + ":" + id))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+var accountSettings = map[string]any{
+	"service.auth.action-token.ttl.verify-email":   "48h",
+	"service.auth.action-token.ttl.reset-password": "1h",
+	"service.auth.password.bcrypt-cost":            4,
+	"service.auth.refresh-token.ttl":               "1h",
+	"service.mail.links.verify-email":              "verify:%s",
+	"service.mail.links.reset-password":            "reset:%s",
+}
+
+// mailedToken reads the action token out of the last link sent to the user.
+func mailedToken(t *testing.T, s *service, prefix string) string {
+	t.Helper()
+	sent := s.ml.(*fakeMailer).sent
+	if len(sent) == 0 {
+		t.Fatal("no email sent")
+	}
+	_, token, found := strings.Cut(sent[len(sent)-1].Body, prefix)
+	if !found {
+		t.Fatalf("no %s link in %q", prefix, sent[len(sent)-1].Body)
+	}
+	token, _, _ = strings.Cut(token, "\n")
+	return token
+}
+
+func TestConsumeActionToken(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare changes the stored token before it is consumed
+		prepare func(token *model.ActionToken)
+		token   func(token string) string
+		purpose model.ActionPurpose
+		valid   bool
+	}{
+		{name: "valid token", purpose: model.ResetPassword, valid: true},
+		{name: "other purpose", purpose: model.VerifyEmail},
+		{
+			name:    "tampered signature",
+			token:   func(token string) string { return token + "x" },
+			purpose: model.ResetPassword,
+		},
+		{
+			name: "signature of another token",
+			token: func(token string) string {
+				_, signature, _ := strings.Cut(token, ".")
+				return "other." + signature
+			},
+			purpose: model.ResetPassword,
+		},
+		{name: "malformed", token: func(string) string { return "malformed" }, purpose: model.ResetPassword},
+		{
+			name:    "expired",
+			prepare: func(token *model.ActionToken) { token.ExpiresAt = time.Now().Add(-time.Minute) },
+			purpose: model.ResetPassword,
+		},
+		{
+			name:    "already used",
+			prepare: func(token *model.ActionToken) { token.Used = true },
+			purpose: model.ResetPassword,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			s := newTestService(rp, accountSettings)
+			ctx := context.Background()
+
+			token, err := s.createActionToken(ctx, &model.User{Id: "user-1", Email: "user@mail.com"}, model.ResetPassword)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, _, _ := strings.Cut(token, ".")
+			if tt.prepare != nil {
+				stored := rp.actionTokens[id]
+				tt.prepare(&stored)
+				rp.actionTokens[id] = stored
+			}
+			if tt.token != nil {
+				token = tt.token(token)
+			}
+
+			actionToken, err := s.consumeActionToken(ctx, token, tt.purpose)
+			if !tt.valid {
+				if errCode(err) != "invalid_token" {
+					t.Fatalf("error = %v, want invalid_token", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actionToken.UserId != "user-1" || !rp.actionTokens[id].Used {
+				t.Fatalf("consumed token = %+v", rp.actionTokens[id])
+			}
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	rp := newFakeRepository()
+	rp.users["user-1"] = model.User{Id: "user-1", Name: "user", Email: "user@mail.com", Password: "old"}
+	lockedUntil := time.Now().Add(time.Hour)
+	rp.loginAttempts["email#user@mail.com"] = model.LoginAttempt{Id: "email#user@mail.com", Failures: 9, LockedUntil: lockedUntil}
+	s := newTestService(rp, accountSettings)
+	ctx := context.Background()
+
+	refreshToken, err := s.CreateSession(ctx, &model.User{Id: "user-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = s.ForgotPassword(ctx, "user@mail.com"); err != nil {
+		t.Fatal(err)
+	}
+	token := mailedToken(t, s, "reset:")
+
+	if err = s.ResetPassword(ctx, &model.PasswordReset{Token: token, Password: "new-secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	user := rp.users["user-1"]
+	if match, _, _ := s.checkPassword(user.Password, "new-secret"); !match || !isPasswordHash(user.Password) {
+		t.Fatalf("password not reset: %q", user.Password)
+	}
+	if !user.EmailVerified {
+		t.Fatal("reset through the mailbox did not verify the email")
+	}
+	if _, _, err = s.RefreshSession(ctx, refreshToken, nil); errCode(err) != "invalid_refresh_token" {
+		t.Fatalf("session survived the reset: %v", err)
+	}
+	if _, locked := rp.loginAttempts["email#user@mail.com"]; locked {
+		t.Fatal("reset kept the login lockout")
+	}
+
+	err = s.ResetPassword(ctx, &model.PasswordReset{Token: token, Password: "another"})
+	if errCode(err) != "invalid_token" {
+		t.Fatalf("second reset error = %v, want invalid_token", err)
+	}
+	if match, _, _ := s.checkPassword(rp.users["user-1"].Password, "new-secret"); !match {
+		t.Fatal("a used token changed the password")
+	}
+}
+
+func TestResetPasswordRaces(t *testing.T) {
+	tests := []struct {
+		name string
+		// meanwhile runs once the user is read
+		meanwhile func(rp *fakeRepository)
+		code      string
+		verified  bool
+	}{
+		{
+			name:      "user deleted meanwhile",
+			meanwhile: func(rp *fakeRepository) { delete(rp.users, "user-1") },
+			code:      "invalid_token",
+		},
+		{
+			name: "email changed meanwhile",
+			meanwhile: func(rp *fakeRepository) {
+				user := rp.users["user-1"]
+				user.Email = "new@mail.com"
+				rp.users["user-1"] = user
+			},
+		},
+		{
+			name: "profile edited meanwhile",
+			meanwhile: func(rp *fakeRepository) {
+				user := rp.users["user-1"]
+				user.Name = "renamed"
+				rp.users["user-1"] = user
+			},
+			verified: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Name: "user", Email: "user@mail.com", Password: "old"}
+			s := newTestService(rp, accountSettings)
+			ctx := context.Background()
+
+			if err := s.ForgotPassword(ctx, "user@mail.com"); err != nil {
+				t.Fatal(err)
+			}
+			token := mailedToken(t, s, "reset:")
+			rp.onGetUser = func() { tt.meanwhile(rp) }
+
+			err := s.ResetPassword(ctx, &model.PasswordReset{Token: token, Password: "new-secret"})
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+
+			user, exists := rp.users["user-1"]
+			if tt.code != "" {
+				if exists {
+					t.Fatalf("user recreated: %+v", user)
+				}
+				return
+			}
+			if match, _, _ := s.checkPassword(user.Password, "new-secret"); !match {
+				t.Fatalf("password not reset: %q", user.Password)
+			}
+			if user.EmailVerified != tt.verified {
+				t.Fatalf("verified = %v, want %v", user.EmailVerified, tt.verified)
+			}
+			// only the password and the verification are written
+			if tt.verified && user.Name != "renamed" {
+				t.Fatalf("reset reverted the profile: %+v", user)
+			}
+		})
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	s := newTestService(newFakeRepository(), accountSettings)
+
+	if err := s.ForgotPassword(context.Background(), "nobody@mail.com"); err != nil {
+		t.Fatal(err)
+	}
+	if sent := s.ml.(*fakeMailer).sent; len(sent) != 0 {
+		t.Fatalf("sent %d emails", len(sent))
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	tests := []struct {
+		name string
+		// email is the address stored when the link is opened
+		email string
+		// meanwhile runs once the user is read
+		meanwhile func(rp *fakeRepository)
+		verified  bool
+	}{
+		{name: "same email", email: "user@mail.com", verified: true},
+		{name: "email changed after the link was sent", email: "new@mail.com"},
+		{
+			name:  "email changed while verifying",
+			email: "user@mail.com",
+			meanwhile: func(rp *fakeRepository) {
+				user := rp.users["user-1"]
+				user.Email = "new@mail.com"
+				rp.users["user-1"] = user
+			},
+		},
+		{
+			name:      "user deleted while verifying",
+			email:     "user@mail.com",
+			meanwhile: func(rp *fakeRepository) { delete(rp.users, "user-1") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Name: "user", Email: "user@mail.com"}
+			s := newTestService(rp, accountSettings)
+			ctx := context.Background()
+
+			if err := s.SendVerificationEmail(model.WithActor(ctx, &model.Actor{UserId: "user-1"})); err != nil {
+				t.Fatal(err)
+			}
+			token := mailedToken(t, s, "verify:")
+
+			user := rp.users["user-1"]
+			user.Email = tt.email
+			rp.users["user-1"] = user
+			if tt.meanwhile != nil {
+				rp.onGetUser = func() { tt.meanwhile(rp) }
+			}
+
+			err := s.VerifyEmail(ctx, token)
+			if tt.verified && err != nil {
+				t.Fatal(err)
+			}
+			if !tt.verified && errCode(err) != "invalid_token" {
+				t.Fatalf("error = %v, want invalid_token", err)
+			}
+			if rp.users["user-1"].EmailVerified != tt.verified {
+				t.Fatalf("verified = %v, want %v", rp.users["user-1"].EmailVerified, tt.verified)
+			}
+
+			if err = s.VerifyEmail(ctx, token); errCode(err) != "invalid_token" {
+				t.Fatalf("reused token error = %v, want invalid_token", err)
+			}
+		})
+	}
+}
+
+func TestUnverifiedUsersCannotPost(t *testing.T) {
+	rp := newFakeRepository()
+	rp.users["user-1"] = model.User{Id: "user-1"}
+	s := newTestService(rp, accountSettings)
+
+	ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+	err := s.CreatePromotion(ctx, &model.Promotion{Title: "title", Link: "https://shop", OriginalPrice: 10, DiscountedPrice: 5})
+	if errCode(err) != "email_not_verified" {
+		t.Fatalf("error = %v, want email_not_verified", err)
+	}
+	if len(rp.promotions) != 0 {
+		t.Fatal("promotion created")
+	}
+}
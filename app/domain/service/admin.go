@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"strings"
+)
+
+func (s *service) GetUsers(ctx context.Context, page *model.PageRequest) (*model.Page[model.User], error) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = requireRole(actor, model.RoleModerator, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return nil, err
+	}
+
+	page.Limit = s.pageLimit(page.Limit)
+
+	users, nextCursor, err := s.rp.GetAllUsers(ctx, page)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	return &model.Page[model.User]{Items: users, NextCursor: nextCursor}, nil
+}
+
+func (s *service) UpdateUserRole(ctx context.Context, id string, role model.Role) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = requireRole(actor, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	if !role.IsValid() {
+		err = errs.Validation("role is invalid", errs.Field("role", "invalid", "role is invalid"))
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	user, err := s.rp.GetUserById(ctx, id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if user == nil {
+		err = errs.NotFound("user")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	user.Role = role
+
+	if err = s.rp.CreateOrUpdateUser(ctx, user); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("user role updated")
+	return nil
+}
+
+func (s *service) DeleteComment(ctx context.Context, id string) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	interaction, err := s.rp.GetInteractionById(ctx, id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if interaction == nil || interaction.InteractionType != model.Comment {
+		err = errs.NotFound("comment")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = requireOwner(actor, interaction.UserId, model.RoleModerator, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	ownerUser, err := s.rp.GetUserById(ctx, interaction.OwnerUserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if ownerUser != nil {
+		score, err := s.CreateUserScoreByInteraction(interaction)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+		score.Points = score.Points * -1
+
+		ownerUser, err = s.editUserStatisticByScore(ctx, ownerUser, score)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+
+		// an owner deleted meanwhile has no score left to revert
+		err = s.rp.AddUserScore(ctx, ownerUser, score.Points)
+		if err != nil && !errors.Is(err, port.ErrConditionFailed) {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+		if err == nil {
+			if err = s.rp.CreateOrUpdateUserScore(ctx, score); err != nil {
+				s.logger(ctx).Error(err.Error())
+				return err
+			}
+			s.mt.ScorePointsGranted(score.Points)
+		}
+	}
+
+	if err = s.rp.DeleteInteraction(ctx, id); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = s.updatePromotionPopularity(ctx, interaction.PromotionId, interaction.InteractionType, -1); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.mt.InteractionRemoved(interaction.InteractionType)
+	s.logger(ctx).Debug("comment deleted")
+	return nil
+}
+
+func (s *service) CreateCategory(ctx context.Context, category *model.Category) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = requireRole(actor, model.RoleModerator, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	if category == nil || len(strings.TrimSpace(category.Name)) == 0 {
+		err = errs.Validation("category is invalid", errs.Required("name"))
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	category.Name = strings.TrimSpace(category.Name)
+
+	if err = s.rp.CreateOrUpdateCategory(ctx, category); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("category created")
+	return nil
+}
+
+func (s *service) DeleteCategory(ctx context.Context, name string) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = requireRole(actor, model.RoleModerator, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	if err = s.rp.DeleteCategory(ctx, name); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("category deleted")
+	return nil
+}
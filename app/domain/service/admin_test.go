@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+func TestAdminPolicies(t *testing.T) {
+	operations := []struct {
+		name    string
+		allowed []model.Role
+		call    func(s *service, ctx context.Context) error
+		// applied reports whether the call changed the stored data, reads leave it nil
+		applied func(rp *fakeRepository) bool
+	}{
+		{
+			name:    "list users",
+			allowed: []model.Role{model.RoleModerator, model.RoleAdmin},
+			call: func(s *service, ctx context.Context) error {
+				_, err := s.GetUsers(ctx, &model.PageRequest{})
+				return err
+			},
+		},
+		{
+			name:    "change a role",
+			allowed: []model.Role{model.RoleAdmin},
+			call: func(s *service, ctx context.Context) error {
+				return s.UpdateUserRole(ctx, "author", model.RoleModerator)
+			},
+			applied: func(rp *fakeRepository) bool { return rp.users["author"].Role == model.RoleModerator },
+		},
+		{
+			name:    "create a category",
+			allowed: []model.Role{model.RoleModerator, model.RoleAdmin},
+			call: func(s *service, ctx context.Context) error {
+				return s.CreateCategory(ctx, &model.Category{Name: " games "})
+			},
+			applied: func(rp *fakeRepository) bool {
+				_, ok := rp.categories["games"]
+				return ok
+			},
+		},
+		{
+			name:    "delete a category",
+			allowed: []model.Role{model.RoleModerator, model.RoleAdmin},
+			call:    func(s *service, ctx context.Context) error { return s.DeleteCategory(ctx, "books") },
+			applied: func(rp *fakeRepository) bool {
+				_, ok := rp.categories["books"]
+				return !ok
+			},
+		},
+		{
+			name:    "delete another user's comment",
+			allowed: []model.Role{model.RoleModerator, model.RoleAdmin},
+			call:    func(s *service, ctx context.Context) error { return s.DeleteComment(ctx, "comment-1") },
+			applied: func(rp *fakeRepository) bool {
+				_, ok := rp.interactions["comment-1"]
+				return !ok
+			},
+		},
+		{
+			name:    "delete another user's promotion",
+			allowed: []model.Role{model.RoleModerator, model.RoleAdmin},
+			call:    func(s *service, ctx context.Context) error { return s.DeletePromotion(ctx, "promo-1") },
+			applied: func(rp *fakeRepository) bool {
+				_, ok := rp.promotions["promo-1"]
+				return !ok
+			},
+		},
+		{
+			name:    "delete another user",
+			allowed: []model.Role{model.RoleAdmin},
+			call:    func(s *service, ctx context.Context) error { return s.DeleteUser(ctx, "author") },
+			applied: func(rp *fakeRepository) bool {
+				_, ok := rp.users["author"]
+				return !ok
+			},
+		},
+	}
+
+	for _, op := range operations {
+		for _, role := range []model.Role{"", model.RoleUser, model.RoleModerator, model.RoleAdmin} {
+			name := role.String()
+			if name == "" {
+				name = "no role"
+			}
+			t.Run(op.name+" as "+name, func(t *testing.T) {
+				rp := newFakeRepository()
+				rp.users["author"] = model.User{Id: "author", Role: model.RoleUser, TotalScore: 100}
+				rp.users["owner"] = model.User{Id: "owner", Role: model.RoleUser, TotalScore: 100}
+				rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "owner"}
+				rp.categories["books"] = model.Category{Name: "books"}
+				rp.interactions["comment-1"] = model.PromotionInteraction{
+					Id:              "comment-1",
+					PromotionId:     "promo-1",
+					UserId:          "author",
+					OwnerUserId:     "owner",
+					InteractionType: model.Comment,
+				}
+				s := newTestService(rp, scoreSettings)
+				ctx := model.WithActor(context.Background(), &model.Actor{UserId: "staff", Role: role})
+
+				allowed := false
+				for _, r := range op.allowed {
+					allowed = allowed || r == role
+				}
+
+				err := op.call(s, ctx)
+				if allowed && err != nil {
+					t.Fatal(err)
+				}
+				if !allowed && errs.KindOf(err) != errs.KindForbidden {
+					t.Fatalf("error = %v, want forbidden", err)
+				}
+				if op.applied != nil && op.applied(rp) != allowed {
+					t.Fatalf("applied = %v, want %v", op.applied(rp), allowed)
+				}
+			})
+		}
+	}
+}
+
+func TestDeleteCommentRevertsScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor model.Actor
+		// meanwhile runs between reading the owner and writing the score
+		meanwhile func(rp *fakeRepository)
+		want      int
+		scores    int
+	}{
+		{name: "author", actor: model.Actor{UserId: "author", Role: model.RoleUser}, want: 90, scores: 1},
+		{name: "moderator", actor: model.Actor{UserId: "staff", Role: model.RoleModerator}, want: 90, scores: 1},
+		{
+			name:  "keeps a concurrent grant",
+			actor: model.Actor{UserId: "author", Role: model.RoleUser},
+			meanwhile: func(rp *fakeRepository) {
+				owner := rp.users["owner"]
+				owner.TotalScore += 5
+				rp.users["owner"] = owner
+			},
+			want:   95,
+			scores: 1,
+		},
+		{
+			name:      "owner deleted meanwhile",
+			actor:     model.Actor{UserId: "author", Role: model.RoleUser},
+			meanwhile: func(rp *fakeRepository) { delete(rp.users, "owner") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["owner"] = model.User{Id: "owner", TotalScore: 100}
+			rp.interactions["comment-1"] = model.PromotionInteraction{Id: "comment-1", UserId: "author", OwnerUserId: "owner", InteractionType: model.Comment}
+			if tt.meanwhile != nil {
+				rp.onAddUserScore = func() { tt.meanwhile(rp) }
+			}
+			s := newTestService(rp, scoreSettings)
+
+			if err := s.DeleteComment(model.WithActor(context.Background(), &tt.actor), "comment-1"); err != nil {
+				t.Fatal(err)
+			}
+			if _, kept := rp.interactions["comment-1"]; kept {
+				t.Fatal("comment not deleted")
+			}
+			if got := rp.users["owner"].TotalScore; got != tt.want {
+				t.Fatalf("owner score = %d, want %d", got, tt.want)
+			}
+			if len(rp.scores) != tt.scores || tt.scores == 1 && rp.scores[0].Points != -10 {
+				t.Fatalf("scores = %+v", rp.scores)
+			}
+		})
+	}
+}
+
+func TestUpdateUserRoleRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		userId string
+		role   model.Role
+		code   string
+	}{
+		{name: "unknown role", userId: "author", role: "owner", code: "validation_failed"},
+		{name: "empty role", userId: "author", role: "", code: "validation_failed"},
+		{name: "unknown user", userId: "nobody", role: model.RoleAdmin, code: "user_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["author"] = model.User{Id: "author", Role: model.RoleUser}
+			s := newTestService(rp, nil)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "admin", Role: model.RoleAdmin})
+			err := s.UpdateUserRole(ctx, tt.userId, tt.role)
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+			if rp.users["author"].Role != model.RoleUser {
+				t.Fatalf("role = %s", rp.users["author"].Role)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"sort"
+	"strings"
+	"time"
+)
+
+const apiKeyPrefix = "pp_"
+
+func (s *service) CreateApiKey(ctx context.Context, request *model.ApiKeyRequest) (*model.CreatedApiKey, error) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor.ApiKeyId != "" {
+		return nil, errs.Forbidden("api_key_not_allowed", "api keys cannot manage api keys")
+	}
+
+	err = s.validApiKeyRequest(request)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	apiKeys, err := s.rp.GetApiKeysByUserId(ctx, actor.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	active := 0
+	for _, apiKey := range apiKeys {
+		if apiKey.IsActive(now) {
+			active++
+		}
+	}
+	if active >= s.cfg.Viper.GetInt("service.auth.api-key.max-active") {
+		return nil, errs.Conflict("api_key_limit_reached", "too many active api keys")
+	}
+
+	id, err := randomId()
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	apiKey := model.ApiKey{
+		Id:        id,
+		UserId:    actor.UserId,
+		Name:      strings.TrimSpace(request.Name),
+		Scopes:    request.Scopes,
+		KeyHash:   hashToken(secret),
+		CreatedAt: now,
+		ExpiresAt: request.ExpiresAt,
+	}
+
+	if err = s.rp.CreateOrUpdateApiKey(ctx, &apiKey); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	s.logger(ctx).Debug("api key created")
+	return &model.CreatedApiKey{
+		ApiKey: apiKey,
+		Key:    fmt.Sprintf("%s%s_%s", apiKeyPrefix, id, secret),
+	}, nil
+}
+
+func (s *service) GetApiKeys(ctx context.Context) ([]model.ApiKey, error) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := s.rp.GetApiKeysByUserId(ctx, actor.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	sort.SliceStable(apiKeys, func(i, j int) bool {
+		return apiKeys[i].CreatedAt.After(apiKeys[j].CreatedAt)
+	})
+
+	return apiKeys, nil
+}
+
+func (s *service) RevokeApiKey(ctx context.Context, id string) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	apiKey, err := s.rp.GetApiKeyById(ctx, id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if apiKey == nil || apiKey.UserId != actor.UserId {
+		return errs.NotFound("api key")
+	}
+
+	if apiKey.Revoked {
+		return nil
+	}
+
+	err = s.rp.RevokeApiKeyById(ctx, apiKey.Id)
+	if err != nil && !errors.Is(err, port.ErrConditionFailed) {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("api key revoked")
+	return nil
+}
+
+func (s *service) AuthenticateApiKey(ctx context.Context, key string) (*model.Actor, error) {
+	id, secret, found := strings.Cut(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if !strings.HasPrefix(key, apiKeyPrefix) || !found || id == "" || secret == "" {
+		return nil, errs.Unauthenticated("invalid_api_key", "invalid api key")
+	}
+
+	apiKey, err := s.rp.GetApiKeyById(ctx, id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	if apiKey == nil || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(hashToken(secret))) != 1 {
+		return nil, errs.Unauthenticated("invalid_api_key", "invalid api key")
+	}
+
+	now := time.Now()
+	if !apiKey.IsActive(now) {
+		return nil, errs.Unauthenticated("invalid_api_key", "api key revoked or expired")
+	}
+
+	user, err := s.rp.GetUserById(ctx, apiKey.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+	if user == nil {
+		return nil, errs.Unauthenticated("invalid_api_key", "invalid api key")
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > s.cfg.Viper.GetDuration("service.auth.api-key.last-used-interval") {
+		err = s.rp.TouchApiKey(ctx, apiKey.Id, now)
+		if errors.Is(err, port.ErrConditionFailed) {
+			return nil, errs.Unauthenticated("invalid_api_key", "api key revoked or expired")
+		}
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+		}
+	}
+
+	return &model.Actor{
+		UserId:   apiKey.UserId,
+		Role:     model.RoleUser,
+		ApiKeyId: apiKey.Id,
+		Scopes:   apiKey.Scopes,
+	}, nil
+}
+
+func (s *service) revokeUserApiKeys(ctx context.Context, userId string) error {
+	apiKeys, err := s.rp.GetApiKeysByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	for _, apiKey := range apiKeys {
+		if apiKey.Revoked {
+			continue
+		}
+		err = s.rp.RevokeApiKeyById(ctx, apiKey.Id)
+		if err != nil && !errors.Is(err, port.ErrConditionFailed) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) validApiKeyRequest(request *model.ApiKeyRequest) error {
+	if request == nil {
+		return errs.Invalid("empty_body", "api key is empty")
+	}
+
+	var fields []errs.FieldError
+	if len(strings.TrimSpace(request.Name)) == 0 {
+		fields = append(fields, errs.Required("name"))
+	}
+	if len(request.Scopes) == 0 {
+		fields = append(fields, errs.Required("scopes"))
+	}
+	for i, scope := range request.Scopes {
+		if !scope.IsValid() {
+			fields = append(fields, errs.Field(fmt.Sprintf("scopes[%d]", i), "invalid", fmt.Sprintf("scope [%s] is invalid", scope)))
+		}
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		fields = append(fields, errs.Field("expiresAt", "out_of_range", "expiresAt must be in the future"))
+	}
+
+	if len(fields) > 0 {
+		return errs.Validation("api key is invalid", fields...)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+var apiKeySettings = map[string]any{
+	"service.auth.api-key.max-active":         2,
+	"service.auth.api-key.last-used-interval": "1m",
+}
+
+func TestCreateApiKey(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		actor   model.Actor
+		request *model.ApiKeyRequest
+		// existing keys the user already has
+		existing []model.ApiKey
+		code     string
+	}{
+		{
+			name:    "valid request",
+			actor:   model.Actor{UserId: "user-1"},
+			request: &model.ApiKeyRequest{Name: " bot ", Scopes: []model.Scope{model.ScopeReadPromotions}, ExpiresAt: &future},
+		},
+		{
+			name:    "unknown scope",
+			actor:   model.Actor{UserId: "user-1"},
+			request: &model.ApiKeyRequest{Name: "bot", Scopes: []model.Scope{"admin"}},
+			code:    "validation_failed",
+		},
+		{
+			name:    "no scopes",
+			actor:   model.Actor{UserId: "user-1"},
+			request: &model.ApiKeyRequest{Name: "bot"},
+			code:    "validation_failed",
+		},
+		{
+			name:    "no name",
+			actor:   model.Actor{UserId: "user-1"},
+			request: &model.ApiKeyRequest{Scopes: []model.Scope{model.ScopeInteract}},
+			code:    "validation_failed",
+		},
+		{
+			name:    "expiration in the past",
+			actor:   model.Actor{UserId: "user-1"},
+			request: &model.ApiKeyRequest{Name: "bot", Scopes: []model.Scope{model.ScopeInteract}, ExpiresAt: &past},
+			code:    "validation_failed",
+		},
+		{
+			name:    "api keys cannot create keys",
+			actor:   model.Actor{UserId: "user-1", ApiKeyId: "key-1", Scopes: []model.Scope{model.ScopeWritePromotions}},
+			request: &model.ApiKeyRequest{Name: "bot", Scopes: []model.Scope{model.ScopeWritePromotions}},
+			code:    "api_key_not_allowed",
+		},
+		{
+			name:     "too many active keys",
+			actor:    model.Actor{UserId: "user-1"},
+			request:  &model.ApiKeyRequest{Name: "bot", Scopes: []model.Scope{model.ScopeInteract}},
+			existing: []model.ApiKey{{Id: "a", UserId: "user-1"}, {Id: "b", UserId: "user-1"}},
+			code:     "api_key_limit_reached",
+		},
+		{
+			name:    "revoked and expired keys do not count",
+			actor:   model.Actor{UserId: "user-1"},
+			request: &model.ApiKeyRequest{Name: "bot", Scopes: []model.Scope{model.ScopeInteract}},
+			existing: []model.ApiKey{
+				{Id: "a", UserId: "user-1", Revoked: true},
+				{Id: "b", UserId: "user-1", ExpiresAt: &past},
+				{Id: "c", UserId: "user-2"},
+				{Id: "d", UserId: "user-2"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			for _, apiKey := range tt.existing {
+				rp.apiKeys[apiKey.Id] = apiKey
+			}
+			s := newTestService(rp, apiKeySettings)
+
+			created, err := s.CreateApiKey(model.WithActor(context.Background(), &tt.actor), tt.request)
+			if tt.code != "" {
+				if errCode(err) != tt.code {
+					t.Fatalf("error = %v, want %s", err, tt.code)
+				}
+				if len(rp.apiKeys) != len(tt.existing) {
+					t.Fatal("rejected request stored a key")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := rp.apiKeys[created.Id]
+			if stored.UserId != "user-1" || stored.Name != "bot" || strings.Contains(created.Key, stored.KeyHash) {
+				t.Fatalf("stored key = %+v", stored)
+			}
+			if !strings.HasPrefix(created.Key, apiKeyPrefix+created.Id+"_") {
+				t.Fatalf("key = %q", created.Key)
+			}
+		})
+	}
+}
+
+func TestAuthenticateApiKey(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare changes the stored key before it is used
+		prepare func(apiKey *model.ApiKey)
+		// meanwhile runs once the key is read, playing a concurrent writer
+		meanwhile func(rp *fakeRepository, id string)
+		key       func(key string) string
+		valid     bool
+	}{
+		{name: "valid key", valid: true},
+		{name: "wrong secret", key: func(key string) string { return key + "x" }},
+		{name: "missing prefix", key: func(key string) string { return strings.TrimPrefix(key, apiKeyPrefix) }},
+		{name: "malformed", key: func(string) string { return apiKeyPrefix + "malformed" }},
+		{name: "unknown id", key: func(key string) string { return strings.Replace(key, apiKeyPrefix, apiKeyPrefix+"0", 1) }},
+		{name: "revoked", prepare: func(apiKey *model.ApiKey) { apiKey.Revoked = true }},
+		{
+			name: "expired",
+			prepare: func(apiKey *model.ApiKey) {
+				expiresAt := time.Now().Add(-time.Second)
+				apiKey.ExpiresAt = &expiresAt
+			},
+		},
+		{
+			name:      "owner deleted",
+			meanwhile: func(rp *fakeRepository, _ string) { delete(rp.users, "user-1") },
+		},
+		{
+			name: "revoked after it was read",
+			meanwhile: func(rp *fakeRepository, id string) {
+				apiKey := rp.apiKeys[id]
+				apiKey.Revoked = true
+				rp.apiKeys[id] = apiKey
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Role: model.RoleAdmin}
+			s := newTestService(rp, apiKeySettings)
+			ctx := context.Background()
+
+			created, err := s.CreateApiKey(model.WithActor(ctx, &model.Actor{UserId: "user-1", Role: model.RoleAdmin}), &model.ApiKeyRequest{
+				Name:   "bot",
+				Scopes: []model.Scope{model.ScopeReadPromotions},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.prepare != nil {
+				stored := rp.apiKeys[created.Id]
+				tt.prepare(&stored)
+				rp.apiKeys[created.Id] = stored
+			}
+			if tt.meanwhile != nil {
+				rp.onGetApiKey = func(id string) { tt.meanwhile(rp, id) }
+			}
+			key := created.Key
+			if tt.key != nil {
+				key = tt.key(key)
+			}
+
+			actor, err := s.AuthenticateApiKey(ctx, key)
+			if !tt.valid {
+				if errCode(err) != "invalid_api_key" {
+					t.Fatalf("error = %v, want invalid_api_key", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// keys act as a plain user whatever the role of their owner
+			if actor.UserId != "user-1" || actor.Role != model.RoleUser || actor.ApiKeyId != created.Id {
+				t.Fatalf("actor = %+v", actor)
+			}
+			if !actor.HasScope(model.ScopeReadPromotions) || actor.HasScope(model.ScopeWritePromotions) {
+				t.Fatalf("scopes = %v", actor.Scopes)
+			}
+			if rp.apiKeys[created.Id].LastUsedAt == nil {
+				t.Fatal("last use not recorded")
+			}
+		})
+	}
+}
+
+func TestRevokeApiKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor string
+		code  string
+	}{
+		{name: "own key", actor: "user-1"},
+		{name: "key of another user", actor: "user-2", code: "api_key_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.apiKeys["key-1"] = model.ApiKey{Id: "key-1", UserId: "user-1"}
+			s := newTestService(rp, apiKeySettings)
+
+			err := s.RevokeApiKey(model.WithActor(context.Background(), &model.Actor{UserId: tt.actor}), "key-1")
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+			if rp.apiKeys["key-1"].Revoked != (tt.code == "") {
+				t.Fatalf("revoked = %v", rp.apiKeys["key-1"].Revoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+const expiredReportUpdateAttempts = 3
+
+// toggleExpiredReport works like the other toggles, reporting twice withdraws
+// the report. Both only apply to active promotions, once a promotion ended its
+// reports are settled. Reports do not score on their own, the poster is only
+// credited or penalized once the flag is resolved.
+func (s *service) toggleExpiredReport(ctx context.Context, promotion *model.Promotion, report *model.PromotionInteraction) error {
+	if report.UserId == promotion.UserId {
+		err := errs.Forbidden("own_promotion", "change the status of your own promotion instead of reporting it")
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	if promotion.CurrentStatus() != model.PromotionActive {
+		err := errs.Conflict("promotion_not_active", "only active promotions can be reported as expired")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	existing, err := s.rp.GetInteractionById(ctx, report.Id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if existing != nil {
+		if err = s.rp.DeleteInteraction(ctx, existing.Id); err != nil {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+
+		if err = s.adjustExpiredReports(ctx, promotion.Id, -existing.Weight); err != nil {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+
+		s.mt.InteractionRemoved(report.InteractionType)
+		s.logger(ctx).Debug("expired report withdrawn")
+		return nil
+	}
+
+	reporter, err := s.rp.GetUserById(ctx, report.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+	if reporter == nil {
+		return errs.NotFound("user")
+	}
+	if !reporter.EmailVerified {
+		err = errs.Forbidden("email_not_verified", "email not verified")
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	report.Weight = s.expiredReportWeight(reporter)
+	if err = s.rp.CreateOrUpdateInteraction(ctx, report); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = s.adjustExpiredReports(ctx, promotion.Id, report.Weight); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.mt.InteractionCreated(report.InteractionType)
+	s.logger(ctx).Debug("expired report created")
+	return nil
+}
+
+func (s *service) ResolveExpiredReports(ctx context.Context, id string, resolution model.ExpiredReportResolution) (*model.Promotion, error) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resolution.IsValid() {
+		err = errs.Validation("resolution is invalid",
+			errs.Field("resolution", "invalid_value", "resolution must be one of confirmed, dismissed"))
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	promotion, err := s.rp.GetPromotionById(ctx, id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	if promotion == nil || promotion.CurrentStatus() == model.PromotionRemoved {
+		err = errs.NotFound("promotion")
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	if err = requireOwner(actor, promotion.UserId, model.RoleModerator, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return nil, err
+	}
+
+	// owners can agree with the reporters, overruling them is a moderation call
+	if resolution == model.ReportDismissed {
+		if err = requireRole(actor, model.RoleModerator, model.RoleAdmin); err != nil {
+			s.logger(ctx).Warn(err.Error())
+			return nil, err
+		}
+	}
+
+	if !promotion.IsProbablyExpired() {
+		err = errs.Conflict("promotion_not_flagged", "promotion is not flagged as probably expired")
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	if resolution == model.ReportConfirmed {
+		err = s.changePromotionStatus(ctx, promotion, model.PromotionExpired, now)
+		if errors.Is(err, port.ErrConditionFailed) {
+			err = errs.Conflict("promotion_busy", "promotion status changed meanwhile, try again")
+		}
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+	}
+
+	if err = s.clearExpiredReports(ctx, promotion); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	// Owners confirming their own flag are not penalized for being honest.
+	if actor.UserId != promotion.UserId {
+		points := s.cfg.Viper.GetInt(fmt.Sprintf("service.score.expired-reports.%s", resolution))
+		if err = s.grantScore(ctx, promotion.UserId, points); err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+	}
+
+	s.logger(ctx).Debug(fmt.Sprintf("expired reports %s", resolution))
+	return promotion, nil
+}
+
+// adjustExpiredReports adds delta to the weighted report total and flags the
+// promotion once it crosses the threshold, retrying like popularity updates.
+func (s *service) adjustExpiredReports(ctx context.Context, promotionId string, delta float64) error {
+	for attempt := 0; attempt < expiredReportUpdateAttempts; attempt++ {
+		promotion, err := s.rp.GetPromotionById(ctx, promotionId)
+		if err != nil {
+			return err
+		}
+		if promotion == nil {
+			return nil
+		}
+
+		previous := promotion.ExpiredReports
+		promotion.ExpiredReports = math.Max(previous+delta, 0)
+		s.flagExpiredReports(promotion, time.Now())
+
+		err = s.rp.UpdatePromotionExpiredReports(ctx, promotion, previous)
+		if !errors.Is(err, port.ErrConditionFailed) {
+			return err
+		}
+	}
+
+	return errs.Conflict("promotion_busy", "promotion is being updated, try again")
+}
+
+func (s *service) clearExpiredReports(ctx context.Context, promotion *model.Promotion) error {
+	reports, err := s.rp.GetInteractionsByPromotionIdAndType(ctx, promotion.Id, model.ExpiredReport)
+	if err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		if err = s.rp.DeleteInteraction(ctx, report.Id); err != nil {
+			return err
+		}
+	}
+
+	previous := promotion.ExpiredReports
+	promotion.ExpiredReports = 0
+	promotion.ExpiredFlaggedAt = nil
+
+	err = s.rp.UpdatePromotionExpiredReports(ctx, promotion, previous)
+	if errors.Is(err, port.ErrConditionFailed) {
+		return errs.Conflict("promotion_busy", "promotion is being updated, try again")
+	}
+	return err
+}
+
+func (s *service) flagExpiredReports(promotion *model.Promotion, now time.Time) {
+	threshold := s.cfg.Viper.GetFloat64("service.promotion.expired-reports.threshold")
+	if threshold <= 0 {
+		threshold = 1
+	}
+
+	switch {
+	case promotion.ExpiredReports < threshold:
+		promotion.ExpiredFlaggedAt = nil
+	case promotion.ExpiredFlaggedAt == nil:
+		promotion.ExpiredFlaggedAt = &now
+	}
+}
+
+// expiredReportWeight trusts reporters by their recent elo, unknown or missing
+// elos count as a single report.
+func (s *service) expiredReportWeight(reporter *model.User) float64 {
+	weight := s.cfg.Viper.GetFloat64(fmt.Sprintf("service.promotion.expired-reports.weights.%s", reporter.Elo))
+	if weight <= 0 {
+		return 1
+	}
+	return weight
+}
+
+func (s *service) grantScore(ctx context.Context, userId string, points int) error {
+	if points == 0 {
+		return nil
+	}
+
+	user, err := s.rp.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errs.NotFound("user")
+	}
+
+	score := &model.UserScore{
+		UserId:    userId,
+		Points:    points,
+		CreatedAt: time.Now(),
+	}
+	score.Id = fmt.Sprintf("%d", score.CreatedAt.UnixNano())
+
+	user, err = s.editUserStatisticByScore(ctx, user, score)
+	if err != nil {
+		return err
+	}
+
+	err = s.rp.AddUserScore(ctx, user, points)
+	if errors.Is(err, port.ErrConditionFailed) {
+		return errs.NotFound("user")
+	}
+	if err != nil {
+		return err
+	}
+	if err = s.rp.CreateOrUpdateUserScore(ctx, score); err != nil {
+		return err
+	}
+
+	s.mt.ScorePointsGranted(points)
+	return nil
+}
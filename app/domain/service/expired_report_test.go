@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"testing"
+	"time"
+)
+
+var expiredReportSettings = map[string]any{
+	"service.promotion.expired-reports.threshold":       5,
+	"service.promotion.expired-reports.weights.none":    1,
+	"service.promotion.expired-reports.weights.silver":  1.5,
+	"service.promotion.expired-reports.weights.gold":    2,
+	"service.promotion.expired-reports.weights.diamond": 4,
+	"service.promotion.expiration.archive-after":        "2160h",
+	"service.score.expired-reports.confirmed":           -15,
+	"service.score.expired-reports.dismissed":           10,
+	"service.score.level.minimalPointsLevel":            25,
+	"service.score.level.growthRate":                    1.5,
+}
+
+func reportFrom(userId string) *model.PromotionInteraction {
+	return &model.PromotionInteraction{
+		Id:              fmt.Sprintf("%s#owner#promo-1#%s", userId, model.ExpiredReport),
+		PromotionId:     "promo-1",
+		UserId:          userId,
+		OwnerUserId:     "owner",
+		InteractionType: model.ExpiredReport,
+		CreatedAt:       time.Now(),
+	}
+}
+
+func TestExpiredReportWeight(t *testing.T) {
+	tests := []struct {
+		elo  string
+		want float64
+	}{
+		{elo: "none", want: 1},
+		{elo: "silver", want: 1.5},
+		{elo: "gold", want: 2},
+		{elo: "diamond", want: 4},
+		{elo: "bronze", want: 1},
+		{elo: "", want: 1},
+	}
+
+	s := newTestService(newFakeRepository(), expiredReportSettings)
+	for _, tt := range tests {
+		if got := s.expiredReportWeight(&model.User{Elo: tt.elo}); got != tt.want {
+			t.Errorf("weight for %q = %v, want %v", tt.elo, got, tt.want)
+		}
+	}
+}
+
+func TestExpiredReportThreshold(t *testing.T) {
+	tests := []struct {
+		name string
+		// reporters by elo, a repeated reporter withdraws the earlier report
+		reporters []string
+		total     float64
+		flagged   bool
+	}{
+		{name: "single report", reporters: []string{"a:none"}, total: 1},
+		{name: "below the threshold", reporters: []string{"a:none", "b:silver", "c:gold"}, total: 4.5},
+		{name: "trusted reporters reach it sooner", reporters: []string{"a:diamond", "b:none"}, total: 5, flagged: true},
+		{name: "crossing it flags", reporters: []string{"a:gold", "b:gold", "c:silver"}, total: 5.5, flagged: true},
+		{name: "withdrawing unflags", reporters: []string{"a:diamond", "b:none", "b:none"}, total: 4},
+		{name: "withdrawing everything", reporters: []string{"a:gold", "a:gold"}, total: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "owner", Status: model.PromotionActive}
+			s := newTestService(rp, expiredReportSettings)
+			ctx := context.Background()
+
+			for _, reporter := range tt.reporters {
+				userId, elo := reporter[:1], reporter[2:]
+				rp.users[userId] = model.User{Id: userId, Elo: elo, EmailVerified: true}
+
+				promotion := rp.promotions["promo-1"]
+				if err := s.toggleExpiredReport(ctx, &promotion, reportFrom(userId)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			promotion := rp.promotions["promo-1"]
+			if promotion.ExpiredReports != tt.total {
+				t.Fatalf("weighted reports = %v, want %v", promotion.ExpiredReports, tt.total)
+			}
+			if promotion.IsProbablyExpired() != tt.flagged {
+				t.Fatalf("flagged = %v, want %v", promotion.IsProbablyExpired(), tt.flagged)
+			}
+		})
+	}
+}
+
+func TestToggleExpiredReportRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		reporter model.User
+		status   model.PromotionStatus
+		// reported leaves an earlier report by the same user in place
+		reported bool
+		code     string
+	}{
+		{name: "own promotion", reporter: model.User{Id: "owner", EmailVerified: true}, status: model.PromotionActive, code: "own_promotion"},
+		{name: "ended promotion", reporter: model.User{Id: "a", EmailVerified: true}, status: model.PromotionSoldOut, code: "promotion_not_active"},
+		{name: "unverified reporter", reporter: model.User{Id: "a"}, status: model.PromotionActive, code: "email_not_verified"},
+		{name: "withdrawing on an ended promotion", reporter: model.User{Id: "a", EmailVerified: true}, status: model.PromotionExpired, reported: true, code: "promotion_not_active"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users[tt.reporter.Id] = tt.reporter
+			promotion := model.Promotion{Id: "promo-1", UserId: "owner", Status: tt.status, ExpiredReports: 1}
+			rp.promotions["promo-1"] = promotion
+			report := reportFrom(tt.reporter.Id)
+			if tt.reported {
+				rp.interactions[report.Id] = *report
+			}
+			s := newTestService(rp, expiredReportSettings)
+
+			err := s.toggleExpiredReport(context.Background(), &promotion, report)
+			if e, ok := errs.As(err); !ok || e.Code != tt.code {
+				t.Fatalf("error = %v, want code %s", err, tt.code)
+			}
+			if rp.promotions["promo-1"].ExpiredReports != 1 {
+				t.Fatal("rejected report changed the promotion")
+			}
+			if _, kept := rp.interactions[report.Id]; kept != tt.reported {
+				t.Fatal("rejected report changed the interactions")
+			}
+		})
+	}
+}
+
+func TestResolveExpiredReports(t *testing.T) {
+	tests := []struct {
+		name       string
+		actor      model.Actor
+		resolution model.ExpiredReportResolution
+		flagged    bool
+		code       string
+		status     model.PromotionStatus
+		points     int
+	}{
+		{
+			name:       "owner confirms without penalty",
+			actor:      model.Actor{UserId: "owner", Role: model.RoleUser},
+			resolution: model.ReportConfirmed,
+			flagged:    true,
+			status:     model.PromotionExpired,
+		},
+		{
+			name:       "other users cannot resolve",
+			actor:      model.Actor{UserId: "a", Role: model.RoleUser},
+			resolution: model.ReportConfirmed,
+			flagged:    true,
+			code:       "not_owner",
+		},
+		{
+			name:       "owner cannot dismiss",
+			actor:      model.Actor{UserId: "owner", Role: model.RoleUser},
+			resolution: model.ReportDismissed,
+			flagged:    true,
+			code:       "insufficient_role",
+		},
+		{
+			name:       "moderator confirms and penalizes the poster",
+			actor:      model.Actor{UserId: "mod", Role: model.RoleModerator},
+			resolution: model.ReportConfirmed,
+			flagged:    true,
+			status:     model.PromotionExpired,
+			points:     -15,
+		},
+		{
+			name:       "moderator dismisses and credits the poster",
+			actor:      model.Actor{UserId: "mod", Role: model.RoleModerator},
+			resolution: model.ReportDismissed,
+			flagged:    true,
+			status:     model.PromotionActive,
+			points:     10,
+		},
+		{
+			name:       "nothing to resolve",
+			actor:      model.Actor{UserId: "mod", Role: model.RoleAdmin},
+			resolution: model.ReportDismissed,
+			code:       "promotion_not_flagged",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["owner"] = model.User{Id: "owner", TotalScore: 100, Level: 3}
+			promotion := model.Promotion{Id: "promo-1", UserId: "owner", Status: model.PromotionActive, ExpiresAt: timeRef(time.Now().Add(time.Hour))}
+			if tt.flagged {
+				promotion.ExpiredReports = 6
+				promotion.ExpiredFlaggedAt = timeRef(time.Now())
+				for _, userId := range []string{"a", "b"} {
+					report := reportFrom(userId)
+					report.Weight = 3
+					rp.interactions[report.Id] = *report
+				}
+			}
+			rp.interactions["a#owner#promo-1#like"] = model.PromotionInteraction{Id: "a#owner#promo-1#like", PromotionId: "promo-1", InteractionType: model.Like}
+			rp.promotions["promo-1"] = promotion
+			s := newTestService(rp, expiredReportSettings)
+
+			_, err := s.ResolveExpiredReports(model.WithActor(context.Background(), &tt.actor), "promo-1", tt.resolution)
+			if tt.code != "" {
+				if e, ok := errs.As(err); !ok || e.Code != tt.code {
+					t.Fatalf("error = %v, want code %s", err, tt.code)
+				}
+				if rp.users["owner"].TotalScore != 100 || len(rp.scores) != 0 {
+					t.Fatal("rejected resolution changed the score")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := rp.promotions["promo-1"]
+			if stored.CurrentStatus() != tt.status {
+				t.Fatalf("status = %s, want %s", stored.CurrentStatus(), tt.status)
+			}
+			if stored.ExpiredReports != 0 || stored.IsProbablyExpired() {
+				t.Fatalf("flag not cleared: %v", stored.ExpiredReports)
+			}
+			if len(rp.interactions) != 1 {
+				t.Fatalf("interactions left = %d, only the like should remain", len(rp.interactions))
+			}
+
+			if got := rp.users["owner"].TotalScore - 100; got != tt.points {
+				t.Fatalf("score change = %d, want %d", got, tt.points)
+			}
+			if (len(rp.scores) == 1) != (tt.points != 0) {
+				t.Fatalf("score records = %d", len(rp.scores))
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/config"
+	"pixelPromo/domain/model"
+	"sync"
+	"time"
+)
+
+type healthCache struct {
+	mu     sync.Mutex
+	report *model.HealthReport
+}
+
+// CheckReadiness probes every configured table and bucket. Results are cached
+// for service.health.cache-ttl so frequent probes do not hammer AWS, and the
+// checks run detached from the caller so a dropped probe cannot poison the cache.
+func (s *service) CheckReadiness(ctx context.Context) (*model.HealthReport, error) {
+	s.health.mu.Lock()
+	defer s.health.mu.Unlock()
+
+	if report := s.health.report; report != nil && time.Since(report.CheckedAt) < s.cfg.Viper.GetDuration("service.health.cache-ttl") {
+		return report, nil
+	}
+
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.cfg.Viper.GetDuration("service.health.timeout"))
+	defer cancel()
+
+	var tables, buckets []model.DependencyHealth
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		tables = s.rp.CheckHealth(checkCtx)
+	}()
+	go func() {
+		defer wg.Done()
+		buckets = s.st.CheckHealth(checkCtx)
+	}()
+	wg.Wait()
+
+	report := &model.HealthReport{
+		Status:       model.HealthUp,
+		CheckedAt:    time.Now(),
+		Dependencies: append(tables, buckets...),
+	}
+	for _, dependency := range report.Dependencies {
+		if dependency.Status == model.HealthUp {
+			continue
+		}
+		report.Status = model.HealthDown
+
+		fields := []config.Field{config.F("dependency", dependency.Name), config.F("kind", dependency.Kind), config.F("reason", dependency.Reason)}
+		if dependency.Err != nil {
+			fields = append(fields, config.F("error", dependency.Err.Error()))
+		}
+		s.logger(ctx).Warn("dependency not ready", fields...)
+	}
+
+	s.health.report = report
+	return report, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+var healthSettings = map[string]any{
+	"service.health.timeout":   "1s",
+	"service.health.cache-ttl": "1m",
+}
+
+func TestCheckReadiness(t *testing.T) {
+	up := func(name string) model.DependencyHealth {
+		return model.DependencyHealth{Name: name, Status: model.HealthUp}
+	}
+	down := func(name string) model.DependencyHealth {
+		return model.DependencyHealth{Name: name, Status: model.HealthDown, Reason: "not_found"}
+	}
+
+	tests := []struct {
+		name    string
+		tables  []model.DependencyHealth
+		buckets []model.DependencyHealth
+		want    model.HealthStatus
+	}{
+		{name: "all up", tables: []model.DependencyHealth{up("user")}, buckets: []model.DependencyHealth{up("user")}, want: model.HealthUp},
+		{name: "nothing configured", want: model.HealthUp},
+		{name: "table down", tables: []model.DependencyHealth{up("user"), down("promotion")}, buckets: []model.DependencyHealth{up("user")}, want: model.HealthDown},
+		{name: "bucket down", tables: []model.DependencyHealth{up("user")}, buckets: []model.DependencyHealth{down("promotion")}, want: model.HealthDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.tables = tt.tables
+			s := newTestService(rp, healthSettings)
+			s.st = fakeStorage{buckets: tt.buckets}
+
+			report, err := s.CheckReadiness(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Status != tt.want || len(report.Dependencies) != len(tt.tables)+len(tt.buckets) {
+				t.Fatalf("report = %+v", report)
+			}
+		})
+	}
+}
+
+func TestCheckReadinessCache(t *testing.T) {
+	tests := []struct {
+		name     string
+		cacheTTL string
+		want     int
+	}{
+		{name: "cached within ttl", cacheTTL: "1m", want: 1},
+		{name: "cache disabled", cacheTTL: "0s", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			s := newTestService(rp, map[string]any{"service.health.timeout": "1s", "service.health.cache-ttl": tt.cacheTTL})
+
+			// the first caller goes away before the check, which must not fail it
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for i := 0; i < 2; i++ {
+				if _, err := s.CheckReadiness(ctx); err != nil {
+					t.Fatal(err)
+				}
+				ctx = context.Background()
+			}
+
+			if rp.healthChecks != tt.want {
+				t.Fatalf("health checks = %d, want %d", rp.healthChecks, tt.want)
+			}
+		})
+	}
+}
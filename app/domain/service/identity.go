@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"strings"
+	"time"
+)
+
+func (s *service) ExternalLogin(ctx context.Context, identity *model.ExternalIdentity) (*model.User, error) {
+	if identity == nil || len(strings.TrimSpace(identity.Subject)) == 0 {
+		err := errs.Unauthenticated("invalid_external_identity", "external identity is empty")
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	identity.Email = model.NormalizeEmail(identity.Email)
+	identityId := fmt.Sprintf("%s#%s", identity.Provider, identity.Subject)
+	link, err := s.rp.GetUserIdentityById(ctx, identityId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	if link != nil {
+		user, err := s.rp.GetUserById(ctx, link.UserId)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+		if user != nil {
+			s.mt.LoginSucceeded(identity.Provider)
+			return user, nil
+		}
+	}
+
+	user, err := s.findOrCreateExternalUser(ctx, identity)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	err = s.rp.CreateOrUpdateUserIdentity(ctx, &model.UserIdentity{
+		Id:        identityId,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		UserId:    user.Id,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	s.mt.LoginSucceeded(identity.Provider)
+	s.logger(ctx).Debug("external identity linked")
+	return user, nil
+}
+
+func (s *service) findOrCreateExternalUser(ctx context.Context, identity *model.ExternalIdentity) (*model.User, error) {
+	if identity.EmailVerified && isEmailValid(identity.Email) {
+		user, err := s.rp.GetUserByEmail(ctx, identity.Email)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			if !user.EmailVerified {
+				if err = s.resetUnverifiedUser(ctx, user); err != nil {
+					return nil, err
+				}
+			}
+			return user, nil
+		}
+	}
+
+	user := &model.User{
+		Name:          identity.Name,
+		PictureUrl:    identity.PictureUrl,
+		Role:          model.RoleUser,
+		EmailVerified: identity.EmailVerified,
+		CreatedAt:     time.Now(),
+	}
+	if isEmailValid(identity.Email) {
+		user.Email = identity.Email
+	}
+	if len(strings.TrimSpace(user.Name)) == 0 {
+		user.Name = fmt.Sprintf("%s_%s", identity.Provider, identity.Subject)
+	}
+	user.Id = fmt.Sprintf("%d", user.CreatedAt.UnixNano())
+
+	err := s.rp.CreateUser(ctx, user)
+	if errors.Is(err, port.ErrConditionFailed) && user.Email != "" {
+		user.Email = ""
+		user.EmailVerified = false
+		err = s.rp.CreateUser(ctx, user)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger(ctx).Debug("user created from external identity")
+	return user, nil
+}
+
+// resetUnverifiedUser takes over an account whose email was never confirmed.
+// Whoever registered it did not prove the address, so their password,
+// sessions and api keys are dropped before the provider identity is linked.
+// The link is aborted when the account was verified or changed meanwhile.
+func (s *service) resetUnverifiedUser(ctx context.Context, user *model.User) error {
+	err := s.rp.ResetUnverifiedUser(ctx, user.Id, user.Email)
+	if errors.Is(err, port.ErrConditionFailed) {
+		return errs.Conflict("account_busy", "account changed while signing in, try again")
+	}
+	if err != nil {
+		return err
+	}
+	user.Password = ""
+	user.EmailVerified = true
+
+	if err := s.RevokeAllSessions(model.WithActor(ctx, &model.Actor{UserId: user.Id})); err != nil {
+		return err
+	}
+	if err := s.revokeUserApiKeys(ctx, user.Id); err != nil {
+		return err
+	}
+
+	s.logger(ctx).Warn("unverified user credentials reset before linking external identity")
+	return nil
+}
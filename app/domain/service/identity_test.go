@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+func TestExternalLogin(t *testing.T) {
+	existing := model.User{Id: "user-1", Name: "user", Email: "user@mail.com", Role: model.RoleUser}
+
+	tests := []struct {
+		name     string
+		identity model.ExternalIdentity
+		// linked is the user the identity already points to, if any
+		linked string
+		// userId is the user the login must resolve to, empty for a new user
+		userId   string
+		verified bool
+		email    string
+		userName string
+	}{
+		{
+			name:     "linked identity",
+			identity: model.ExternalIdentity{Provider: "google", Subject: "g-1", Email: "other@mail.com", EmailVerified: true},
+			linked:   "user-1",
+			userId:   "user-1",
+			email:    "user@mail.com",
+			userName: "user",
+		},
+		{
+			name:     "verified email links the existing account",
+			identity: model.ExternalIdentity{Provider: "google", Subject: "g-1", Email: "user@mail.com", EmailVerified: true},
+			userId:   "user-1",
+			verified: true,
+			email:    "user@mail.com",
+			userName: "user",
+		},
+		{
+			name:     "verified email links after normalization",
+			identity: model.ExternalIdentity{Provider: "google", Subject: "g-1", Email: " User@Mail.com ", EmailVerified: true},
+			userId:   "user-1",
+			verified: true,
+			email:    "user@mail.com",
+			userName: "user",
+		},
+		{
+			name:     "unverified taken email creates a separate account without it",
+			identity: model.ExternalIdentity{Provider: "github", Subject: "42", Email: "user@mail.com", Name: "gh"},
+			userName: "gh",
+		},
+		{
+			name:     "unverified free email is kept",
+			identity: model.ExternalIdentity{Provider: "github", Subject: "42", Email: "gh@mail.com", Name: "gh"},
+			email:    "gh@mail.com",
+			userName: "gh",
+		},
+		{
+			name:     "link to a deleted user creates a new account",
+			identity: model.ExternalIdentity{Provider: "google", Subject: "g-1", Email: "new@mail.com", EmailVerified: true, Name: "new"},
+			linked:   "deleted",
+			verified: true,
+			email:    "new@mail.com",
+			userName: "new",
+		},
+		{
+			name:     "provider without email",
+			identity: model.ExternalIdentity{Provider: "steam", Subject: "7656"},
+			userName: "steam_7656",
+		},
+		{
+			name:     "invalid email is dropped",
+			identity: model.ExternalIdentity{Provider: "github", Subject: "42", Email: "not an email", EmailVerified: true, Name: "gh"},
+			verified: true,
+			userName: "gh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users[existing.Id] = existing
+			identityId := tt.identity.Provider + "#" + tt.identity.Subject
+			if tt.linked != "" {
+				rp.identities[identityId] = model.UserIdentity{Id: identityId, UserId: tt.linked}
+			}
+			s := newTestService(rp, nil)
+
+			user, err := s.ExternalLogin(context.Background(), &tt.identity)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.userId != "" && user.Id != tt.userId {
+				t.Fatalf("user = %s, want %s", user.Id, tt.userId)
+			}
+			if tt.userId == "" && (user.Id == existing.Id || len(rp.users) != 2) {
+				t.Fatalf("user = %s with %d users, want a new account", user.Id, len(rp.users))
+			}
+
+			stored := rp.users[user.Id]
+			if stored.EmailVerified != tt.verified || stored.Email != tt.email || stored.Name != tt.userName {
+				t.Fatalf("stored user = %+v", stored)
+			}
+			if tt.userId == "" && (stored.Role != model.RoleUser || stored.Password != "") {
+				t.Fatalf("new user = %+v", stored)
+			}
+			if rp.identities[identityId].UserId != user.Id {
+				t.Fatalf("identity points to %s, want %s", rp.identities[identityId].UserId, user.Id)
+			}
+		})
+	}
+}
+
+func TestExternalLoginRejectsEmptyIdentity(t *testing.T) {
+	s := newTestService(newFakeRepository(), nil)
+
+	for _, identity := range []*model.ExternalIdentity{nil, {Provider: "google", Subject: " "}} {
+		if _, err := s.ExternalLogin(context.Background(), identity); err == nil {
+			t.Fatalf("identity %+v accepted", identity)
+		}
+	}
+}
+
+func TestExternalLoginTakesOverUnverifiedAccount(t *testing.T) {
+	tests := []struct {
+		name string
+		// verified is whether the existing account confirmed its email
+		verified bool
+		// kept is whether its credentials survive the link
+		kept bool
+	}{
+		{name: "unverified account is reset", kept: false},
+		{name: "verified account is kept", verified: true, kept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Email: "user@mail.com", Password: "hash", EmailVerified: tt.verified, Role: model.RoleUser}
+			rp.sessions["session-1"] = model.Session{Id: "session-1", UserId: "user-1"}
+			rp.apiKeys["key-1"] = model.ApiKey{Id: "key-1", UserId: "user-1"}
+			s := newTestService(rp, nil)
+
+			user, err := s.ExternalLogin(context.Background(), &model.ExternalIdentity{Provider: "google", Subject: "g-1", Email: "user@mail.com", EmailVerified: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Id != "user-1" || !rp.users["user-1"].EmailVerified {
+				t.Fatalf("user = %+v", rp.users["user-1"])
+			}
+
+			if (rp.users["user-1"].Password != "") != tt.kept {
+				t.Fatalf("password = %q", rp.users["user-1"].Password)
+			}
+			if rp.sessions["session-1"].Revoked == tt.kept {
+				t.Fatalf("session revoked = %v", rp.sessions["session-1"].Revoked)
+			}
+			if rp.apiKeys["key-1"].Revoked == tt.kept {
+				t.Fatalf("api key revoked = %v", rp.apiKeys["key-1"].Revoked)
+			}
+		})
+	}
+}
+
+func TestExternalLoginAbortsWhenAccountChanges(t *testing.T) {
+	tests := []struct {
+		name string
+		// meanwhile runs once the account is found by email
+		meanwhile func(user *model.User)
+	}{
+		{name: "verified meanwhile", meanwhile: func(user *model.User) { user.EmailVerified = true }},
+		{name: "email changed meanwhile", meanwhile: func(user *model.User) { user.Email = "new@mail.com" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Email: "user@mail.com", Password: "hash", Role: model.RoleUser}
+			rp.sessions["session-1"] = model.Session{Id: "session-1", UserId: "user-1"}
+			rp.onGetUser = func() {
+				user := rp.users["user-1"]
+				tt.meanwhile(&user)
+				rp.users["user-1"] = user
+			}
+			s := newTestService(rp, nil)
+
+			_, err := s.ExternalLogin(context.Background(), &model.ExternalIdentity{Provider: "google", Subject: "g-1", Email: "user@mail.com", EmailVerified: true})
+			if errCode(err) != "account_busy" {
+				t.Fatalf("error = %v, want account_busy", err)
+			}
+			if rp.users["user-1"].Password != "hash" || rp.sessions["session-1"].Revoked {
+				t.Fatalf("account reset anyway: %+v", rp.users["user-1"])
+			}
+			if _, linked := rp.identities["google#g-1"]; linked {
+				t.Fatal("identity linked anyway")
+			}
+		})
+	}
+}
@@ -5,32 +5,37 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"pixelPromo/domain/errs"
 	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
 	"strings"
 	"time"
 )
 
-func (s *service) GetCommentsByPromotionId(ctx context.Context, id string) ([]model.PromotionInteraction, error) {
-	interaction, err := s.rp.GetInteractionsByTypeWithPromotionId(ctx, model.Comment, id)
+func (s *service) GetCommentsByPromotionId(ctx context.Context, id string, page *model.PageRequest) (*model.Page[model.PromotionInteraction], error) {
+	page.Limit = s.pageLimit(page.Limit)
+
+	interactions, nextCursor, err := s.rp.GetInteractionsByTypeWithPromotionId(ctx, model.Comment, id, page)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
-	return interaction, nil
+	return &model.Page[model.PromotionInteraction]{Items: interactions, NextCursor: nextCursor}, nil
 }
 
 func (s *service) GetInteractionStatisticsByPromotionId(ctx context.Context, id string) (map[string]int, error) {
 	interactions, err := s.rp.GetInteractionsByPromotionId(ctx, id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
 	counters := map[string]int{
-		"favorite": 0,
-		"like":     0,
-		"comment":  0,
+		"favorite":       0,
+		"like":           0,
+		"comment":        0,
+		"expired_report": 0,
 	}
 	for _, interaction := range interactions {
 		counters[string(interaction.InteractionType)] += 1
@@ -42,7 +47,7 @@ func (s *service) GetInteractionStatisticsByPromotionId(ctx context.Context, id
 func (s *service) GetInteractionStatisticsByUserId(ctx context.Context, id string) (map[string]int, error) {
 	interactions, err := s.rp.GetInteractionsByUserId(ctx, id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
@@ -62,13 +67,14 @@ func (s *service) GetInteractionStatisticsByUserId(ctx context.Context, id strin
 func (s *service) GetInteractionStatisticsByUserIdWithPromotionId(ctx context.Context, userId string, promotionId string) (map[string]bool, error) {
 	interactions, err := s.rp.GetInteractionsByUserIdWithPromotionId(ctx, userId, promotionId)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
 	counters := map[string]bool{
-		"favorite": false,
-		"like":     false,
+		"favorite":       false,
+		"like":           false,
+		"expired_report": false,
 	}
 	for _, interaction := range interactions {
 		counters[string(interaction.InteractionType)] = true
@@ -78,14 +84,20 @@ func (s *service) GetInteractionStatisticsByUserIdWithPromotionId(ctx context.Co
 }
 
 func (s *service) CreateInteraction(ctx context.Context, newInteraction *model.PromotionInteraction) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	newInteraction.UserId = actor.UserId
 
 	promotion, err := s.rp.GetPromotionById(ctx, newInteraction.PromotionId)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
-	if promotion == nil {
-		return errors.New("promotion not found")
+	if promotion == nil || promotion.CurrentStatus() == model.PromotionRemoved {
+		return errs.NotFound("promotion")
 	}
 
 	newInteraction.OwnerUserId = promotion.UserId
@@ -98,28 +110,32 @@ func (s *service) CreateInteraction(ctx context.Context, newInteraction *model.P
 
 	err = s.validInteraction(newInteraction)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
+	if newInteraction.InteractionType == model.ExpiredReport {
+		return s.toggleExpiredReport(ctx, promotion, newInteraction)
+	}
+
 	ownerUser, err := s.rp.GetUserById(ctx, newInteraction.OwnerUserId)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 	if ownerUser == nil {
-		return errors.New("owner user not found")
+		return errs.NotFound("owner user")
 	}
 
 	score, err := s.CreateUserScoreByInteraction(newInteraction)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	interaction, err := s.rp.GetInteractionById(ctx, newInteraction.Id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
@@ -129,93 +145,111 @@ func (s *service) CreateInteraction(ctx context.Context, newInteraction *model.P
 
 		ownerUser, err = s.editUserStatisticByScore(ctx, ownerUser, score)
 		if err != nil {
-			s.log.Error(err.Error())
+			s.logger(ctx).Error(err.Error())
 			return err
 		}
 
 		err = s.rp.CreateOrUpdateInteraction(ctx, newInteraction)
 		if err != nil {
-			s.log.Error(err.Error())
+			s.logger(ctx).Error(err.Error())
 			return err
 		}
-		err = s.rp.CreateOrUpdateUser(ctx, ownerUser)
+		err = s.addUserScore(ctx, ownerUser, score.Points)
 		if err != nil {
-			s.log.Error(err.Error())
+			s.logger(ctx).Error(err.Error())
 			return err
 		}
 		err = s.rp.CreateOrUpdateUserScore(ctx, score)
 		if err != nil {
-			s.log.Error(err.Error())
+			s.logger(ctx).Error(err.Error())
 			return err
 		}
 
 		err = s.rp.DeleteInteraction(ctx, newInteraction.Id)
 		if err != nil {
-			s.log.Error(err.Error())
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+
+		err = s.updatePromotionPopularity(ctx, newInteraction.PromotionId, newInteraction.InteractionType, -1)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
 			return err
 		}
 
-		s.log.Debug("interaction and score deleted")
+		s.mt.InteractionRemoved(newInteraction.InteractionType)
+		s.mt.ScorePointsGranted(score.Points)
+		s.logger(ctx).Debug("interaction and score deleted")
 		return nil
 	}
 
 	ownerUser, err = s.editUserStatisticByScore(ctx, ownerUser, score)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	err = s.rp.CreateOrUpdateInteraction(ctx, newInteraction)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
-	err = s.rp.CreateOrUpdateUser(ctx, ownerUser)
+	err = s.addUserScore(ctx, ownerUser, score.Points)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 	err = s.rp.CreateOrUpdateUserScore(ctx, score)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	err = s.updatePromotionPopularity(ctx, newInteraction.PromotionId, newInteraction.InteractionType, 1)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("interaction and score created")
+	s.mt.InteractionCreated(newInteraction.InteractionType)
+	s.mt.ScorePointsGranted(score.Points)
+	s.logger(ctx).Debug("interaction and score created")
 	return nil
 }
 
 func (s *service) validInteraction(interaction *model.PromotionInteraction) error {
 	if interaction == nil {
-		return errors.New("interaction is nil")
+		return errs.Invalid("empty_body", "interaction is empty")
 	}
 
+	var fields []errs.FieldError
 	if len(strings.TrimSpace(interaction.UserId)) == 0 {
-		return errors.New("userId is empty")
+		fields = append(fields, errs.Required("userId"))
 	}
 
 	if len(strings.TrimSpace(interaction.OwnerUserId)) == 0 {
-		return errors.New("ownerUserId is empty")
+		fields = append(fields, errs.Required("ownerUserId"))
 	}
 
 	if len(strings.TrimSpace(interaction.PromotionId)) == 0 {
-		return errors.New("promotionId is empty")
+		fields = append(fields, errs.Required("promotionId"))
 	}
 
 	if len(strings.TrimSpace(string(interaction.InteractionType))) == 0 {
-		return errors.New("interactionType is empty")
-	}
-
-	if !interaction.IsValidType() {
-		return errors.New("type is invalid")
+		fields = append(fields, errs.Required("interactionType"))
+	} else if !interaction.IsValidType() {
+		fields = append(fields, errs.Field("interactionType", "invalid", "interactionType is invalid"))
 	}
 
 	if interaction.InteractionType == model.Comment {
 		if len(strings.TrimSpace(interaction.Comment)) == 0 {
-			return errors.New("comment is empty")
+			fields = append(fields, errs.Required("comment"))
 		}
 	}
 
+	if len(fields) > 0 {
+		return errs.Validation("interaction is invalid", fields...)
+	}
 	return nil
 }
 
@@ -259,11 +293,21 @@ func (s *service) editUserStatisticByScore(ctx context.Context, user *model.User
 	return user, nil
 }
 
+// addUserScore adds the points on top of the stored total instead of writing back
+// the user as read, so concurrent interactions never lose points.
+func (s *service) addUserScore(ctx context.Context, user *model.User, points int) error {
+	err := s.rp.AddUserScore(ctx, user, points)
+	if errors.Is(err, port.ErrConditionFailed) {
+		return errs.NotFound("owner user")
+	}
+	return err
+}
+
 func (s *service) calculateElo(ctx context.Context, user *model.User, newPoints int) (string, error) {
 	initDate := time.Now().AddDate(0, 0, -s.cfg.Viper.GetInt("service.score.elo.timeRangeInDays"))
 	scoreList, err := s.rp.GetAllUserScoreByTimeWithUserId(ctx, user.Id, initDate)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return "", err
 	}
 
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+func TestCreateInteractionAddsScore(t *testing.T) {
+	tests := []struct {
+		name string
+		// meanwhile runs between reading the owner and writing the score
+		meanwhile func(rp *fakeRepository)
+		want      int
+		code      string
+	}{
+		{name: "like", want: 105},
+		{
+			name: "keeps a concurrent grant",
+			meanwhile: func(rp *fakeRepository) {
+				owner := rp.users["owner"]
+				owner.TotalScore += 10
+				rp.users["owner"] = owner
+			},
+			want: 115,
+		},
+		{
+			name:      "owner deleted meanwhile is not recreated",
+			meanwhile: func(rp *fakeRepository) { delete(rp.users, "owner") },
+			code:      "owner_user_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["owner"] = model.User{Id: "owner", TotalScore: 100}
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "owner"}
+			if tt.meanwhile != nil {
+				rp.onAddUserScore = func() { tt.meanwhile(rp) }
+			}
+			settings := maps.Clone(scoreSettings)
+			maps.Copy(settings, sortSettings)
+			s := newTestService(rp, settings)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+			err := s.CreateInteraction(ctx, &model.PromotionInteraction{PromotionId: "promo-1", InteractionType: model.Like})
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+
+			owner, exists := rp.users["owner"]
+			if tt.code != "" {
+				if exists {
+					t.Fatalf("owner recreated: %+v", owner)
+				}
+				return
+			}
+			if owner.TotalScore != tt.want {
+				t.Fatalf("owner score = %d, want %d", owner.TotalScore, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"testing"
+	"time"
+)
+
+var lockoutSettings = map[string]any{
+	"service.auth.lockout.window":             "1h",
+	"service.auth.lockout.base-delay":         "1m",
+	"service.auth.lockout.max-delay":          "1h",
+	"service.auth.lockout.email.max-failures": 3,
+	"service.auth.lockout.ip.max-failures":    5,
+}
+
+func TestLockoutDelay(t *testing.T) {
+	tests := []struct {
+		exceeded int
+		want     time.Duration
+	}{
+		{exceeded: 0, want: time.Minute},
+		{exceeded: 1, want: 2 * time.Minute},
+		{exceeded: 4, want: 16 * time.Minute},
+		{exceeded: 6, want: time.Hour},
+		{exceeded: 31, want: time.Hour},
+		{exceeded: 1000, want: time.Hour},
+	}
+
+	s := newTestService(newFakeRepository(), lockoutSettings)
+	for _, tt := range tests {
+		if got := s.lockoutDelay(tt.exceeded); got != tt.want {
+			t.Errorf("lockoutDelay(%d) = %s, want %s", tt.exceeded, got, tt.want)
+		}
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	client := &model.SessionClient{Ip: "203.0.113.7"}
+
+	tests := []struct {
+		name     string
+		failures int
+		// stale moves the window start past the configured window
+		stale  bool
+		reset  bool
+		locked bool
+	}{
+		{name: "below the email limit", failures: 2},
+		{name: "locks at the email limit", failures: 3, locked: true},
+		{name: "keeps locking past the limit", failures: 4, locked: true},
+		{name: "a stale window starts over", failures: 3, stale: true},
+		{name: "a successful login clears the email count", failures: 3, reset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			s := newTestService(rp, lockoutSettings)
+			ctx := context.Background()
+			keys := loginLockoutKeys("user@example.com", client)
+
+			for i := 0; i < tt.failures; i++ {
+				if tt.stale && i == tt.failures-1 {
+					for id, attempt := range rp.loginAttempts {
+						attempt.WindowStart = attempt.WindowStart.Add(-2 * time.Hour)
+						rp.loginAttempts[id] = attempt
+					}
+				}
+				if err := s.registerLoginFailure(ctx, keys, client); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.reset {
+				if err := s.resetLoginFailures(ctx, "user@example.com"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := s.checkLoginLockout(ctx, keys)
+			if !tt.locked {
+				if err != nil {
+					t.Fatalf("unexpected lockout: %v", err)
+				}
+				return
+			}
+
+			retry, ok := errs.As(err)
+			if !ok || retry.Code != "login_locked" {
+				t.Fatalf("error = %v, want login_locked", err)
+			}
+			if retry.RetryAfter <= 0 || retry.RetryAfter > time.Hour {
+				t.Fatalf("retry after = %s", retry.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestLoginLockoutByIp(t *testing.T) {
+	rp := newFakeRepository()
+	s := newTestService(rp, lockoutSettings)
+	ctx := context.Background()
+	client := &model.SessionClient{Ip: "203.0.113.7"}
+
+	// spraying different emails from one address trips the ip limit only
+	for i := 0; i < 5; i++ {
+		email := string(rune('a'+i)) + "@example.com"
+		if err := s.registerLoginFailure(ctx, loginLockoutKeys(email, client), client); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.checkLoginLockout(ctx, loginLockoutKeys("new@example.com", client)); err == nil {
+		t.Fatal("ip was not locked")
+	}
+	if err := s.checkLoginLockout(ctx, loginLockoutKeys("new@example.com", &model.SessionClient{Ip: "198.51.100.1"})); err != nil {
+		t.Fatalf("other ip locked: %v", err)
+	}
+}
+
+func TestLoginSuccessKeepsIpFailures(t *testing.T) {
+	rp := newFakeRepository()
+	s := newTestService(rp, lockoutSettings)
+	ctx := context.Background()
+	client := &model.SessionClient{Ip: "203.0.113.7"}
+
+	spray := func(from int, to int) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			email := string(rune('a'+i)) + "@example.com"
+			if err := s.registerLoginFailure(ctx, loginLockoutKeys(email, client), client); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// a login into an owned account between guesses does not buy more of them
+	spray(0, 4)
+	if err := s.resetLoginFailures(ctx, "owned@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	spray(4, 5)
+
+	if err := s.checkLoginLockout(ctx, loginLockoutKeys("new@example.com", client)); errCode(err) != "login_locked" {
+		t.Fatalf("error = %v, want login_locked", err)
+	}
+
+	// the count only goes once its window has passed
+	for id, attempt := range rp.loginAttempts {
+		attempt.WindowStart = attempt.WindowStart.Add(-2 * time.Hour)
+		attempt.LockedUntil = time.Now().Add(-time.Minute)
+		rp.loginAttempts[id] = attempt
+	}
+	spray(5, 6)
+	if failures := rp.loginAttempts[lockoutKey{kind: "ip", value: client.Ip}.id()].Failures; failures != 1 {
+		t.Fatalf("ip failures = %d, want a fresh window", failures)
+	}
+}
+
+func TestLoginLocksOutAndRecovers(t *testing.T) {
+	rp := newFakeRepository()
+	rp.users["user-1"] = model.User{Id: "user-1", Email: "user@example.com", Password: mustHash(t, "secret", 4)}
+	settings := map[string]any{"service.auth.password.bcrypt-cost": 4}
+	for key, value := range lockoutSettings {
+		settings[key] = value
+	}
+	s := newTestService(rp, settings)
+	ctx := context.Background()
+
+	login := func(password string) (*model.User, error) {
+		return s.Login(ctx, &model.Login{Email: "user@example.com", Password: password}, nil)
+	}
+
+	for i := 0; i < 2; i++ {
+		if user, err := login("wrong"); user != nil || err != nil {
+			t.Fatalf("failed login = %v, %v", user, err)
+		}
+	}
+	if user, err := login("secret"); user == nil || err != nil {
+		t.Fatalf("login below the limit = %v, %v", user, err)
+	}
+	if _, ok := rp.loginAttempts["email#user@example.com"]; ok {
+		t.Fatal("successful login kept the email count")
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := login("wrong"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if user, err := login("secret"); user != nil || errCode(err) != "login_locked" {
+		t.Fatalf("login while locked = %v, %v", user, err)
+	}
+
+	attempt := rp.loginAttempts["email#user@example.com"]
+	attempt.LockedUntil = time.Now().Add(-time.Second)
+	rp.loginAttempts["email#user@example.com"] = attempt
+	if user, err := login("secret"); user == nil || err != nil {
+		t.Fatalf("login after the lock expired = %v, %v", user, err)
+	}
+}
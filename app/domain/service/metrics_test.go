@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"pixelPromo/domain/model"
+	"reflect"
+	"testing"
+)
+
+func TestLoginMetrics(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		// failures already recorded for the email before this login
+		failures int
+		want     []string
+	}{
+		{name: "success", password: "secret", want: []string{"login_succeeded:password"}},
+		{name: "wrong password", password: "wrong", want: []string{"login_failed:password:invalid_credentials"}},
+		{name: "locked out", password: "secret", failures: 3, want: []string{"login_failed:password:locked_out"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Email: "user@mail.com", Password: mustHash(t, "secret", 4)}
+			settings := map[string]any{"service.auth.password.bcrypt-cost": 4}
+			maps.Copy(settings, lockoutSettings)
+			s := newTestService(rp, settings)
+
+			ctx := context.Background()
+			for i := 0; i < tt.failures; i++ {
+				if _, err := s.Login(ctx, &model.Login{Email: "user@mail.com", Password: "wrong"}, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			s.mt = &fakeMetrics{}
+
+			_, _ = s.Login(ctx, &model.Login{Email: "user@mail.com", Password: tt.password}, nil)
+			if events := s.mt.(*fakeMetrics).events; !reflect.DeepEqual(events, tt.want) {
+				t.Fatalf("events = %v, want %v", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestInteractionMetrics(t *testing.T) {
+	rp := newFakeRepository()
+	rp.users["owner"] = model.User{Id: "owner"}
+	rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "owner"}
+	settings := maps.Clone(scoreSettings)
+	maps.Copy(settings, sortSettings)
+	s := newTestService(rp, settings)
+	metrics := s.mt.(*fakeMetrics)
+
+	ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+	like := func() {
+		t.Helper()
+		if err := s.CreateInteraction(ctx, &model.PromotionInteraction{PromotionId: "promo-1", InteractionType: model.Like}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	like()
+	if !reflect.DeepEqual(metrics.events, []string{"interaction_created:like"}) || metrics.points != 5 {
+		t.Fatalf("after like: events = %v, points = %d", metrics.events, metrics.points)
+	}
+
+	// liking again toggles the like off and takes the points back
+	like()
+	if !reflect.DeepEqual(metrics.events, []string{"interaction_created:like", "interaction_removed:like"}) || metrics.points != 0 {
+		t.Fatalf("after unlike: events = %v, points = %d", metrics.events, metrics.points)
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"strings"
+	"testing"
+)
+
+func TestOwnership(t *testing.T) {
+	operations := []struct {
+		name string
+		call func(s *service, ctx context.Context) error
+		// changed reports whether the call touched the owner's resources
+		changed func(rp *fakeRepository) bool
+	}{
+		{
+			name: "delete promotion",
+			call: func(s *service, ctx context.Context) error { return s.DeletePromotion(ctx, "promo-1") },
+			changed: func(rp *fakeRepository) bool {
+				_, ok := rp.promotions["promo-1"]
+				return !ok
+			},
+		},
+		{
+			name: "update promotion",
+			call: func(s *service, ctx context.Context) error {
+				return s.UpdatePromotion(ctx, &model.Promotion{Id: "promo-1", Title: "changed", Link: "https://shop", OriginalPrice: 10, DiscountedPrice: 5})
+			},
+			changed: func(rp *fakeRepository) bool { return rp.promotions["promo-1"].Title == "changed" },
+		},
+		{
+			name: "update promotion image",
+			call: func(s *service, ctx context.Context) error {
+				return s.UpdatePromotionImage(ctx, "promo-1", strings.NewReader("image"))
+			},
+			changed: func(rp *fakeRepository) bool { return rp.promotions["promo-1"].ImageUrl != "" },
+		},
+		{
+			name: "update user",
+			call: func(s *service, ctx context.Context) error {
+				return s.UpdateUser(ctx, &model.User{Id: "owner", Name: "changed", Email: "owner@mail.com", Password: "secret"})
+			},
+			changed: func(rp *fakeRepository) bool { return rp.users["owner"].Name == "changed" },
+		},
+		{
+			name: "update user picture",
+			call: func(s *service, ctx context.Context) error {
+				return s.UpdateUserPicture(ctx, "owner", strings.NewReader("picture"))
+			},
+			changed: func(rp *fakeRepository) bool { return rp.users["owner"].PictureUrl != "" },
+		},
+		{
+			name: "delete user",
+			call: func(s *service, ctx context.Context) error { return s.DeleteUser(ctx, "owner") },
+			changed: func(rp *fakeRepository) bool {
+				_, ok := rp.users["owner"]
+				return !ok
+			},
+		},
+	}
+
+	actors := []struct {
+		name  string
+		actor *model.Actor
+		code  string
+	}{
+		{name: "owner", actor: &model.Actor{UserId: "owner"}},
+		{name: "another user", actor: &model.Actor{UserId: "other"}, code: "not_owner"},
+		{name: "anonymous", code: "missing_actor"},
+		{name: "empty user id", actor: &model.Actor{}, code: "missing_actor"},
+	}
+
+	for _, op := range operations {
+		for _, a := range actors {
+			t.Run(op.name+" as "+a.name, func(t *testing.T) {
+				rp := newFakeRepository()
+				rp.users["owner"] = model.User{Id: "owner", Name: "owner", Email: "owner@mail.com", EmailVerified: true}
+				rp.users["other"] = model.User{Id: "other", Name: "other", Email: "other@mail.com", EmailVerified: true}
+				rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "owner", Title: "title", Link: "https://shop"}
+				s := newTestService(rp, passwordSettings)
+
+				ctx := context.Background()
+				if a.actor != nil {
+					ctx = model.WithActor(ctx, a.actor)
+				}
+
+				err := op.call(s, ctx)
+				if a.code == "" && err != nil {
+					t.Fatal(err)
+				}
+				if errCode(err) != a.code {
+					t.Fatalf("error = %v, want %s", err, a.code)
+				}
+				if op.changed(rp) != (a.code == "") {
+					t.Fatalf("changed = %v, want %v", op.changed(rp), a.code == "")
+				}
+			})
+		}
+	}
+}
+
+func TestUpdatePromotionKeepsOwner(t *testing.T) {
+	rp := newFakeRepository()
+	rp.users["owner"] = model.User{Id: "owner", EmailVerified: true}
+	rp.users["other"] = model.User{Id: "other"}
+	rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "owner", ImageUrl: "https://images/promo-1.jpg"}
+	s := newTestService(rp, nil)
+
+	ctx := model.WithActor(context.Background(), &model.Actor{UserId: "owner"})
+	err := s.UpdatePromotion(ctx, &model.Promotion{Id: "promo-1", UserId: "other", Title: "title", Link: "https://shop", OriginalPrice: 10, DiscountedPrice: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := rp.promotions["promo-1"]
+	if stored.UserId != "owner" || stored.ImageUrl != "https://images/promo-1.jpg" || stored.DiscountBadge != 50 {
+		t.Fatalf("stored promotion = %+v", stored)
+	}
+}
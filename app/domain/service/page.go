@@ -0,0 +1,39 @@
+package service
+
+import (
+	"encoding/base64"
+	"pixelPromo/domain/errs"
+	"strconv"
+)
+
+func (s *service) pageLimit(limit int32) int32 {
+	maxLimit := s.cfg.Viper.GetInt32("service.pagination.max-limit")
+	if limit <= 0 {
+		return s.cfg.Viper.GetInt32("service.pagination.default-limit")
+	}
+	if limit > maxLimit {
+		return maxLimit
+	}
+	return limit
+}
+
+func encodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeOffsetCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errs.Invalid("invalid_cursor", "cursor is invalid")
+	}
+
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, errs.Invalid("invalid_cursor", "cursor is invalid")
+	}
+	return offset, nil
+}
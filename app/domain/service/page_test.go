@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"pixelPromo/domain/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var pageSettings = map[string]any{
+	"service.pagination.default-limit": 20,
+	"service.pagination.max-limit":     100,
+}
+
+func TestPageLimit(t *testing.T) {
+	s := newTestService(newFakeRepository(), pageSettings)
+
+	tests := []struct {
+		limit int32
+		want  int32
+	}{
+		{limit: 0, want: 20},
+		{limit: -1, want: 20},
+		{limit: 1, want: 1},
+		{limit: 100, want: 100},
+		{limit: 101, want: 100},
+	}
+
+	for _, tt := range tests {
+		if got := s.pageLimit(tt.limit); got != tt.want {
+			t.Errorf("pageLimit(%d) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeOffsetCursor(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor string
+		offset int
+		code   string
+	}{
+		{name: "empty", cursor: "", offset: 0},
+		{name: "encoded offset", cursor: encodeOffsetCursor(40), offset: 40},
+		{name: "not base64", cursor: "%%%", code: "invalid_cursor"},
+		{name: "not a number", cursor: "YWJj", code: "invalid_cursor"},
+		{name: "negative offset", cursor: "LTE", code: "invalid_cursor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, err := decodeOffsetCursor(tt.cursor)
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+			if offset != tt.offset {
+				t.Fatalf("offset = %d, want %d", offset, tt.offset)
+			}
+		})
+	}
+}
+
+func TestGetUserRankPages(t *testing.T) {
+	rp := newFakeRepository()
+	now := time.Now()
+	points := map[string]int{"a": 30, "b": 50, "c": 30, "d": 10, "e": 40, "deleted": 45}
+	for id, p := range points {
+		if id != "deleted" {
+			rp.users[id] = model.User{Id: id}
+		}
+		rp.scores = append(rp.scores, model.UserScore{UserId: id, Points: p, CreatedAt: now.Add(-time.Hour)})
+	}
+	// scores older than a week do not count
+	rp.scores = append(rp.scores, model.UserScore{UserId: "d", Points: 100, CreatedAt: now.Add(-8 * 24 * time.Hour)})
+	s := newTestService(rp, pageSettings)
+
+	tests := []struct {
+		limit int32
+		want  []string
+	}{
+		{limit: 2, want: []string{"b", "e", "a", "c", "d"}},
+		{limit: 3, want: []string{"b", "e", "a", "c", "d"}},
+		{limit: 10, want: []string{"b", "e", "a", "c", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %d", tt.limit), func(t *testing.T) {
+			var got []string
+			cursor := ""
+			for pages := 0; ; pages++ {
+				if pages > len(points) {
+					t.Fatal("pagination does not terminate")
+				}
+
+				page, err := s.GetUserRank(context.Background(), &model.PageRequest{Limit: tt.limit, Cursor: cursor})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if int32(len(page.Items)) > tt.limit {
+					t.Fatalf("page has %d items, limit %d", len(page.Items), tt.limit)
+				}
+				for _, user := range page.Items {
+					if user.TotalScore != points[user.Id] {
+						t.Fatalf("user %s scored %d, want %d", user.Id, user.TotalScore, points[user.Id])
+					}
+					got = append(got, user.Id)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("rank = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetUsersPages(t *testing.T) {
+	rp := newFakeRepository()
+	ids := []string{"a", "b", "c", "d", "e"}
+	for _, id := range ids {
+		rp.users[id] = model.User{Id: id}
+	}
+	s := newTestService(rp, pageSettings)
+	ctx := model.WithActor(context.Background(), &model.Actor{UserId: "a", Role: model.RoleAdmin})
+
+	tests := []struct {
+		limit int32
+		pages int
+	}{
+		{limit: 2, pages: 3},
+		{limit: 5, pages: 1},
+		{limit: 0, pages: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("limit %d", tt.limit), func(t *testing.T) {
+			var got []string
+			cursor := ""
+			pages := 0
+			for ; ; pages++ {
+				if pages > len(ids) {
+					t.Fatal("pagination does not terminate")
+				}
+
+				page, err := s.GetUsers(ctx, &model.PageRequest{Limit: tt.limit, Cursor: cursor})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, user := range page.Items {
+					got = append(got, user.Id)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				cursor = page.NextCursor
+			}
+
+			if !reflect.DeepEqual(got, ids) || pages+1 != tt.pages {
+				t.Fatalf("users = %v in %d pages, want %v in %d", got, pages+1, ids, tt.pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+	"sync"
+)
+
+// dummyPasswordHash is compared when there is no stored hash to check, so
+// unknown emails and passwordless accounts cost the same bcrypt work as real ones.
+type dummyPasswordHash struct {
+	once sync.Once
+	hash []byte
+}
+
+func (s *service) burnPasswordCheck(password string) {
+	s.dummyHash.once.Do(func() {
+		s.dummyHash.hash, _ = bcrypt.GenerateFromPassword([]byte("pixelpromo-dummy-password"), s.passwordCost())
+	})
+	_ = bcrypt.CompareHashAndPassword(s.dummyHash.hash, []byte(password))
+}
+
+func (s *service) hashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.passwordCost())
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// checkPassword reports whether password matches the stored value and whether
+// the stored value must be rehashed (legacy plaintext rows or an outdated cost).
+func (s *service) checkPassword(stored string, password string) (bool, bool, error) {
+	if stored == "" {
+		s.burnPasswordCheck(password)
+		return false, false, nil
+	}
+
+	if !isPasswordHash(stored) {
+		match := subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return match, match, nil
+	}
+
+	err := bcrypt.CompareHashAndPassword([]byte(stored), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+
+	cost, err := bcrypt.Cost([]byte(stored))
+	if err != nil {
+		return false, false, err
+	}
+	return true, cost < s.passwordCost(), nil
+}
+
+func (s *service) passwordCost() int {
+	cost := s.cfg.Viper.GetInt("service.auth.password.bcrypt-cost")
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
+}
+
+func isPasswordHash(value string) bool {
+	return strings.HasPrefix(value, "$2a$") || strings.HasPrefix(value, "$2b$") || strings.HasPrefix(value, "$2y$")
+}
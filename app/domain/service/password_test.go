@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"golang.org/x/crypto/bcrypt"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+var passwordSettings = map[string]any{"service.auth.password.bcrypt-cost": bcrypt.MinCost + 1}
+
+func mustHash(t *testing.T, password string, cost int) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestCheckPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		match    bool
+		rehash   bool
+	}{
+		{name: "current hash", stored: mustHash(t, "secret", bcrypt.MinCost+1), password: "secret", match: true},
+		{name: "wrong password", stored: mustHash(t, "secret", bcrypt.MinCost+1), password: "other"},
+		{name: "outdated cost", stored: mustHash(t, "secret", bcrypt.MinCost), password: "secret", match: true, rehash: true},
+		{name: "wrong password on outdated cost", stored: mustHash(t, "secret", bcrypt.MinCost), password: "other"},
+		{name: "legacy plaintext", stored: "secret", password: "secret", match: true, rehash: true},
+		{name: "wrong legacy plaintext", stored: "secret", password: "secreT"},
+		{name: "account without password", stored: "", password: "secret"},
+		{name: "empty password on an account without password", stored: "", password: ""},
+	}
+
+	s := newTestService(newFakeRepository(), passwordSettings)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := s.checkPassword(tt.stored, tt.password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if match != tt.match || rehash != tt.rehash {
+				t.Fatalf("checkPassword = %v, %v, want %v, %v", match, rehash, tt.match, tt.rehash)
+			}
+		})
+	}
+}
+
+func TestPasswordCost(t *testing.T) {
+	tests := []struct {
+		cost any
+		want int
+	}{
+		{cost: 12, want: 12},
+		{cost: nil, want: bcrypt.DefaultCost},
+		{cost: bcrypt.MinCost - 1, want: bcrypt.DefaultCost},
+		{cost: bcrypt.MaxCost + 1, want: bcrypt.DefaultCost},
+	}
+
+	for _, tt := range tests {
+		s := newTestService(newFakeRepository(), map[string]any{"service.auth.password.bcrypt-cost": tt.cost})
+		if got := s.passwordCost(); got != tt.want {
+			t.Errorf("cost for %v = %d, want %d", tt.cost, got, tt.want)
+		}
+	}
+}
+
+func TestLoginRehashesPasswords(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		loggedIn bool
+		rehashed bool
+	}{
+		{name: "current hash is kept", stored: mustHash(t, "secret", bcrypt.MinCost+1), password: "secret", loggedIn: true},
+		{name: "outdated cost is rehashed", stored: mustHash(t, "secret", bcrypt.MinCost), password: "secret", loggedIn: true, rehashed: true},
+		{name: "legacy plaintext is rehashed", stored: "secret", password: "secret", loggedIn: true, rehashed: true},
+		{name: "failed login keeps the plaintext row", stored: "secret", password: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Email: "user@mail.com", Password: tt.stored}
+			s := newTestService(rp, passwordSettings)
+
+			user, err := s.Login(context.Background(), &model.Login{Email: "user@mail.com", Password: tt.password}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (user != nil) != tt.loggedIn {
+				t.Fatalf("login = %v, want logged in %v", user, tt.loggedIn)
+			}
+
+			stored := rp.users["user-1"].Password
+			if (stored != tt.stored) != tt.rehashed {
+				t.Fatalf("stored password changed = %v, want %v", stored != tt.stored, tt.rehashed)
+			}
+			if tt.rehashed {
+				if cost, err := bcrypt.Cost([]byte(stored)); err != nil || cost != bcrypt.MinCost+1 {
+					t.Fatalf("rehashed cost = %d, %v", cost, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoginUnknownEmail(t *testing.T) {
+	s := newTestService(newFakeRepository(), passwordSettings)
+
+	user, err := s.Login(context.Background(), &model.Login{Email: "nobody@mail.com", Password: "secret"}, nil)
+	if user != nil || err != nil {
+		t.Fatalf("login = %v, %v", user, err)
+	}
+}
+
+func TestLoginSpendsBcryptOnMissingHash(t *testing.T) {
+	tests := []struct {
+		name  string
+		users map[string]model.User
+		burn  bool
+	}{
+		{name: "unknown email", burn: true},
+		{name: "account without password", users: map[string]model.User{"user-1": {Id: "user-1", Email: "user@mail.com"}}, burn: true},
+		{name: "account with password", users: map[string]model.User{"user-1": {Id: "user-1", Email: "user@mail.com", Password: mustHash(t, "secret", bcrypt.MinCost+1)}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			for id, user := range tt.users {
+				rp.users[id] = user
+			}
+			s := newTestService(rp, passwordSettings)
+
+			user, err := s.Login(context.Background(), &model.Login{Email: "user@mail.com", Password: "wrong"}, nil)
+			if user != nil || err != nil {
+				t.Fatalf("user = %v, error = %v, want a rejected login", user, err)
+			}
+			if burned := s.dummyHash.hash != nil; burned != tt.burn {
+				t.Fatalf("dummy hash compared = %v, want %v", burned, tt.burn)
+			}
+		})
+	}
+}
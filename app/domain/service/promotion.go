@@ -6,25 +6,46 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"pixelPromo/domain/errs"
 	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
 	"strings"
 	"time"
 )
 
 func (s *service) CreatePromotion(ctx context.Context, promotion *model.Promotion) error {
-	err := s.validPromotion(ctx, promotion)
+	actor, err := actorFromContext(ctx)
 	if err != nil {
-		s.log.Error(err.Error())
+		return err
+	}
+
+	if promotion != nil {
+		promotion.UserId = actor.UserId
+	}
+
+	err = s.validPromotion(ctx, promotion)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	promotion.DiscountBadge = math.Round(((promotion.OriginalPrice - promotion.DiscountedPrice) / promotion.OriginalPrice) * 100)
 
 	promotion.CreatedAt = time.Now()
+	promotion.ExpiresAt, err = s.promotionExpiresAt(promotion.ExpiresAt, promotion.CreatedAt)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
 	promotion.Id = fmt.Sprintf("%d", promotion.CreatedAt.UnixNano())
+	promotion.Status = model.PromotionActive
+	promotion.Ttl = 0
+	promotion.Popularity = 0
+	promotion.HotScore = s.hotScore(promotion.Popularity, promotion.CreatedAt)
 
 	if err = s.rp.CreateOrUpdatePromotion(ctx, promotion); err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
@@ -38,137 +59,246 @@ func (s *service) CreatePromotion(ctx context.Context, promotion *model.Promotio
 	}
 
 	if err = s.CreateInteraction(ctx, &interaction); err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("promotion created")
+	s.mt.PromotionCreated()
+	s.logger(ctx).Debug("promotion created")
 	return nil
 }
 
 func (s *service) DeletePromotion(ctx context.Context, promotionId string) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	promotion, err := s.rp.GetPromotionById(ctx, promotionId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if promotion == nil {
+		err = errs.NotFound("promotion")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = requireOwner(actor, promotion.UserId, model.RoleModerator, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
 
-	if err := s.rp.DeletePromotion(ctx, promotionId); err != nil {
-		s.log.Error(err.Error())
+	if err = s.rp.DeletePromotion(ctx, promotionId); err != nil {
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("promotion deleted")
+	s.logger(ctx).Debug("promotion deleted")
 	return nil
 }
 func (s *service) UpdatePromotion(ctx context.Context, newPromotion *model.Promotion) error {
-	err := s.validPromotion(ctx, newPromotion)
+	actor, err := actorFromContext(ctx)
 	if err != nil {
-		s.log.Error(err.Error())
+		return err
+	}
+
+	if newPromotion != nil {
+		newPromotion.UserId = actor.UserId
+	}
+
+	err = s.validPromotion(ctx, newPromotion)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	if newPromotion.Id == "" {
-		err = errors.New("promotion id is empty")
-		s.log.Error(err.Error())
+		err = errs.Validation("promotion is invalid", errs.Required("id"))
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	promotion, err := s.rp.GetPromotionById(ctx, newPromotion.Id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	if promotion == nil {
-		err = errors.New("promotion not found")
-		s.log.Error(err.Error())
+		err = errs.NotFound("promotion")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = requireOwner(actor, promotion.UserId); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	if promotion.CurrentStatus() == model.PromotionRemoved {
+		err = errs.Conflict("promotion_removed", "removed promotions cannot be edited")
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	if err = s.rp.CreateOrUpdatePromotion(ctx, newPromotion); err != nil {
-		s.log.Error(err.Error())
+	if newPromotion.ExpiresAt == nil {
+		newPromotion.ExpiresAt = promotion.ExpiresAt
+	} else {
+		newPromotion.ExpiresAt, err = s.promotionExpiresAt(newPromotion.ExpiresAt, time.Now())
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+	}
+
+	newPromotion.DiscountBadge = math.Round(((newPromotion.OriginalPrice - newPromotion.DiscountedPrice) / newPromotion.OriginalPrice) * 100)
+	newPromotion.ImageUrl = promotion.ImageUrl
+	newPromotion.CreatedAt = promotion.CreatedAt
+	newPromotion.Popularity = promotion.Popularity
+	newPromotion.HotScore = promotion.HotScore
+	// promotions stored before statuses existed get one, or an expiresAt would
+	// never reach the sweeper's index
+	newPromotion.Status = promotion.CurrentStatus()
+	newPromotion.Ttl = promotion.Ttl
+	newPromotion.ExpiredReports = promotion.ExpiredReports
+	newPromotion.ExpiredFlaggedAt = promotion.ExpiredFlaggedAt
+
+	err = s.rp.UpdatePromotion(ctx, newPromotion, promotion.CurrentStatus())
+	if errors.Is(err, port.ErrConditionFailed) {
+		err = errs.Conflict("promotion_busy", "promotion status changed while updating, try again")
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("promotion updated")
+	s.logger(ctx).Debug("promotion updated")
 	return nil
 }
 
 func (s *service) UpdatePromotionImage(ctx context.Context, id string, image io.Reader) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
 
 	promotion, err := s.rp.GetPromotionById(ctx, id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	if promotion == nil {
-		err = errors.New("promotion not found")
-		s.log.Error(err.Error())
+		err = errs.NotFound("promotion")
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = requireOwner(actor, promotion.UserId); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	if promotion.CurrentStatus() == model.PromotionRemoved {
+		err = errs.Conflict("promotion_removed", "removed promotions cannot be edited")
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	url, err := s.st.UploadPromotionImage(ctx, fmt.Sprintf("%s.jpg", id), image)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	promotion.ImageUrl = url
 
-	if err = s.rp.CreateOrUpdatePromotion(ctx, promotion); err != nil {
-		s.log.Error(err.Error())
+	err = s.rp.UpdatePromotion(ctx, promotion, promotion.CurrentStatus())
+	if errors.Is(err, port.ErrConditionFailed) {
+		err = errs.Conflict("promotion_busy", "promotion status changed while updating, try again")
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("picture uploaded")
+	s.logger(ctx).Debug("picture uploaded")
 	return nil
 }
 
 func (s *service) GetPromotionById(ctx context.Context, id string) (*model.Promotion, error) {
 	promotion, err := s.rp.GetPromotionById(ctx, id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
+	// removed promotions stay readable only to whoever can still act on them
+	if promotion != nil && promotion.CurrentStatus() == model.PromotionRemoved {
+		actor := model.ActorFromContext(ctx)
+		if actor == nil || requireOwner(actor, promotion.UserId, model.RoleModerator, model.RoleAdmin) != nil {
+			return nil, errs.NotFound("promotion")
+		}
+	}
+
 	return promotion, nil
 }
 
-func (s *service) GetPromotions(ctx context.Context, params *model.PromotionQuery) ([]model.Promotion, error) {
-	promotion, err := s.rp.GetPromotionsWithParams(ctx, params)
+func (s *service) GetPromotions(ctx context.Context, params *model.PromotionQuery) (*model.Page[model.Promotion], error) {
+	params.Limit = s.pageLimit(params.Limit)
+
+	if err := s.validPromotionQuery(params); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	promotions, nextCursor, err := s.rp.GetPromotionsWithParams(ctx, params)
 	if err != nil {
-		s.log.Error(err.Error())
-		return []model.Promotion{}, err
+		s.logger(ctx).Error(err.Error())
+		return nil, err
 	}
 
-	return promotion, nil
+	return &model.Page[model.Promotion]{Items: promotions, NextCursor: nextCursor}, nil
 }
 
-func (s *service) GetFavoritesPromotionsByUserId(ctx context.Context, userId string) ([]model.Promotion, error) {
-	interactions, err := s.rp.GetInteractionsByTypeWithUserId(ctx, model.Favorite, userId)
+func (s *service) GetFavoritesPromotionsByUserId(ctx context.Context, userId string, page *model.PageRequest) (*model.Page[model.Promotion], error) {
+	page.Limit = s.pageLimit(page.Limit)
+
+	interactions, nextCursor, err := s.rp.GetInteractionsByTypeWithUserId(ctx, model.Favorite, userId, page)
 	if err != nil {
-		s.log.Error(err.Error())
-		return []model.Promotion{}, err
+		s.logger(ctx).Error(err.Error())
+		return nil, err
 	}
 
-	promotions := make([]model.Promotion, 0)
+	actor := model.ActorFromContext(ctx)
+	promotions := make([]model.Promotion, 0, len(interactions))
 	for _, interaction := range interactions {
 		promotion, err := s.rp.GetPromotionById(ctx, interaction.PromotionId)
 		if err != nil {
-			s.log.Error(err.Error())
-			return []model.Promotion{}, err
+			s.logger(ctx).Error(err.Error())
+			return nil, err
 		}
-		if promotion != nil {
-			promotions = append(promotions, *promotion)
+		if promotion == nil {
+			continue
 		}
-
+		// like the other listings, removed promotions only show up for their owner
+		if promotion.CurrentStatus() == model.PromotionRemoved && (actor == nil || actor.UserId != promotion.UserId) {
+			continue
+		}
+		promotions = append(promotions, *promotion)
 	}
 
-	return promotions, nil
+	return &model.Page[model.Promotion]{Items: promotions, NextCursor: nextCursor}, nil
 
 }
 
 func (s *service) GetPromotionsByCategory(ctx context.Context, category string) ([]model.Promotion, error) {
 	promotion, err := s.rp.GetPromotionsByCategory(ctx, category)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return []model.Promotion{}, err
 	}
 
@@ -178,43 +308,90 @@ func (s *service) GetPromotionsByCategory(ctx context.Context, category string)
 func (s *service) GetCategories(ctx context.Context) ([]model.Category, error) {
 	categories, err := s.rp.GetCategories(ctx)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return []model.Category{}, err
 	}
 
 	return categories, nil
 }
 
+// validPromotionCategories only accepts categories registered by moderators.
+func (s *service) validPromotionCategories(ctx context.Context, categories []string) error {
+	if len(categories) == 0 {
+		return nil
+	}
+
+	registered, err := s.rp.GetCategories(ctx)
+	if err != nil {
+		return err
+	}
+
+	allowed := make(map[string]bool, len(registered))
+	for _, category := range registered {
+		allowed[category.Name] = true
+	}
+
+	var fields []errs.FieldError
+	for i, category := range categories {
+		if !allowed[category] {
+			fields = append(fields, errs.Field(fmt.Sprintf("categories[%d]", i), "invalid_value", fmt.Sprintf("category [%s] is not allowed", category)))
+		}
+	}
+
+	if len(fields) > 0 {
+		return errs.Validation("promotion is invalid", fields...)
+	}
+	return nil
+}
+
 func (s *service) validPromotion(ctx context.Context, promotion *model.Promotion) error {
 	if promotion == nil {
-		return errors.New("promotion is nil")
+		return errs.Invalid("empty_body", "promotion is empty")
 	}
+
+	var fields []errs.FieldError
 	if len(strings.TrimSpace(promotion.Link)) == 0 {
-		return errors.New("link is empty")
+		fields = append(fields, errs.Required("link"))
 	}
 	if len(strings.TrimSpace(promotion.Title)) == 0 {
-		return errors.New("title is empty")
+		fields = append(fields, errs.Required("title"))
 	}
 	if len(strings.TrimSpace(promotion.UserId)) == 0 {
-		return errors.New("userId is empty")
+		fields = append(fields, errs.Required("userId"))
+	}
+
+	if promotion.OriginalPrice <= 0 {
+		fields = append(fields, errs.Field("originalPrice", "out_of_range", "originalPrice must be greater than 0"))
+	}
+	if promotion.DiscountedPrice < 0 || promotion.DiscountedPrice > promotion.OriginalPrice {
+		fields = append(fields, errs.Field("discountedPrice", "out_of_range", "discountedPrice must be between 0 and originalPrice"))
 	}
 
-	if len(promotion.Categories) > 0 {
-		for _, category := range promotion.Categories {
-			if len(strings.TrimSpace(category)) == 0 {
-				return errors.New("category name is empty")
-			}
+	for i, category := range promotion.Categories {
+		if len(strings.TrimSpace(category)) == 0 {
+			fields = append(fields, errs.Required(fmt.Sprintf("categories[%d]", i)))
 		}
 	}
 
+	if len(fields) > 0 {
+		return errs.Validation("promotion is invalid", fields...)
+	}
+
+	if err := s.validPromotionCategories(ctx, promotion.Categories); err != nil {
+		return err
+	}
+
 	user, err := s.rp.GetUserById(ctx, promotion.UserId)
 	if err != nil {
 		return err
 	}
 
 	if user == nil {
-		err = errors.New("user not found")
-		return err
+		return errs.NotFound("user")
+	}
+
+	if !user.EmailVerified {
+		return errs.Forbidden("email_not_verified", "email not verified")
 	}
 
 	return nil
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+const popularityUpdateAttempts = 3
+
+// hotScore grows with the log of popularity and linearly with the creation time,
+// so newer promotions outrank older ones without rescoring the whole catalog.
+func (s *service) hotScore(popularity int, createdAt time.Time) float64 {
+	gravity := s.cfg.Viper.GetFloat64("service.promotion.sort.hot-gravity")
+	if gravity <= 0 {
+		gravity = 45000
+	}
+	return math.Log10(math.Max(float64(popularity), 1)) + float64(createdAt.Unix())/gravity
+}
+
+// BackfillPromotionSortKeys indexes the promotions stored before the sorted feeds
+// existed. Writes only land on promotions still missing a feed, so every instance
+// can run it on start.
+func (s *service) BackfillPromotionSortKeys(ctx context.Context) (int, error) {
+	promotions, err := s.rp.GetPromotionsWithoutSortKeys(ctx)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return 0, err
+	}
+
+	backfilled := 0
+	for i := range promotions {
+		promotion := &promotions[i]
+		promotion.HotScore = s.hotScore(promotion.Popularity, promotion.CreatedAt)
+
+		err = s.rp.BackfillPromotionSortKeys(ctx, promotion)
+		if errors.Is(err, port.ErrConditionFailed) {
+			continue
+		}
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return backfilled, err
+		}
+		backfilled++
+	}
+
+	if backfilled > 0 {
+		s.logger(ctx).Info("promotion sort keys backfilled", config.F("count", backfilled))
+	}
+	return backfilled, nil
+}
+
+func (s *service) validPromotionQuery(query *model.PromotionQuery) error {
+	if query.Sort == "" {
+		query.Sort = model.PromotionSort(s.cfg.Viper.GetString("service.promotion.sort.default"))
+	}
+	if !query.Sort.IsValid() {
+		return errs.Validation("promotion query is invalid",
+			errs.Field("sort", "invalid_value", "sort must be one of newest, discount, price, popular, hot"))
+	}
+	return nil
+}
+
+func (s *service) updatePromotionPopularity(ctx context.Context, promotionId string, interactionType model.InteractionType, sign int) error {
+	weight := s.cfg.Viper.GetInt(fmt.Sprintf("service.promotion.sort.popularity.%s", interactionType))
+	if weight == 0 {
+		return nil
+	}
+
+	for attempt := 0; attempt < popularityUpdateAttempts; attempt++ {
+		promotion, err := s.rp.GetPromotionById(ctx, promotionId)
+		if err != nil {
+			return err
+		}
+		if promotion == nil {
+			return nil
+		}
+
+		previous := promotion.Popularity
+		promotion.Popularity = max(previous+sign*weight, 0)
+		promotion.HotScore = s.hotScore(promotion.Popularity, promotion.CreatedAt)
+
+		err = s.rp.UpdatePromotionPopularity(ctx, promotion, previous)
+		if !errors.Is(err, port.ErrConditionFailed) {
+			return err
+		}
+	}
+
+	return errs.Conflict("promotion_busy", "promotion is being updated, try again")
+}
@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"slices"
+	"testing"
+	"time"
+)
+
+var sortSettings = map[string]any{
+	"service.promotion.sort.default":             "newest",
+	"service.promotion.sort.hot-gravity":         45000,
+	"service.promotion.sort.popularity.like":     1,
+	"service.promotion.sort.popularity.favorite": 2,
+	"service.promotion.sort.popularity.comment":  1,
+}
+
+func TestValidPromotionQuery(t *testing.T) {
+	tests := []struct {
+		sort model.PromotionSort
+		want model.PromotionSort
+		code string
+	}{
+		{sort: "", want: model.SortNewest},
+		{sort: model.SortDiscount, want: model.SortDiscount},
+		{sort: model.SortPrice, want: model.SortPrice},
+		{sort: model.SortPopular, want: model.SortPopular},
+		{sort: model.SortHot, want: model.SortHot},
+		{sort: "cheapest", want: "cheapest", code: "validation_failed"},
+		{sort: "Newest", want: "Newest", code: "validation_failed"},
+	}
+
+	s := newTestService(newFakeRepository(), sortSettings)
+	for _, tt := range tests {
+		t.Run(string(tt.sort), func(t *testing.T) {
+			query := &model.PromotionQuery{Sort: tt.sort}
+			err := s.validPromotionQuery(query)
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+			if query.Sort != tt.want {
+				t.Fatalf("sort = %s, want %s", query.Sort, tt.want)
+			}
+		})
+	}
+}
+
+func TestHotScore(t *testing.T) {
+	s := newTestService(newFakeRepository(), sortSettings)
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		higher, lower float64
+	}{
+		{name: "popularity raises the score", higher: s.hotScore(10, now), lower: s.hotScore(1, now)},
+		{name: "newer promotions win at equal popularity", higher: s.hotScore(5, now), lower: s.hotScore(5, now.Add(-time.Hour))},
+		{name: "ten times the popularity outweighs half a day", higher: s.hotScore(100, now.Add(-12*time.Hour)), lower: s.hotScore(10, now)},
+		{name: "a day old promotion needs more than ten times the popularity", higher: s.hotScore(10, now), lower: s.hotScore(100, now.Add(-24*time.Hour))},
+		{name: "no popularity counts as one", higher: s.hotScore(2, now), lower: s.hotScore(0, now)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.higher <= tt.lower {
+				t.Fatalf("%f <= %f", tt.higher, tt.lower)
+			}
+		})
+	}
+
+	if s.hotScore(0, now) != s.hotScore(1, now) {
+		t.Fatal("zero and one popularity score differently")
+	}
+}
+
+func TestUpdatePromotionPopularity(t *testing.T) {
+	tests := []struct {
+		name            string
+		current         int
+		interactionType model.InteractionType
+		sign            int
+		// concurrent writes that land before each of our attempts
+		concurrent []int
+		want       int
+		code       string
+	}{
+		{name: "like", current: 3, interactionType: model.Like, sign: 1, want: 4},
+		{name: "favorite weighs more", current: 3, interactionType: model.Favorite, sign: 1, want: 5},
+		{name: "removed comment", current: 3, interactionType: model.Comment, sign: -1, want: 2},
+		{name: "never below zero", current: 1, interactionType: model.Favorite, sign: -1, want: 0},
+		{name: "unweighted type", current: 3, interactionType: model.Create, sign: 1, want: 3},
+		{name: "retries after a concurrent write", current: 3, interactionType: model.Like, sign: 1, concurrent: []int{1}, want: 5},
+		{name: "gives up when every attempt races", current: 3, interactionType: model.Like, sign: 1, concurrent: []int{1, 1, 1}, want: 6, code: "promotion_busy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			createdAt := time.Now().Add(-time.Hour)
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", Popularity: tt.current, CreatedAt: createdAt}
+			s := newTestService(rp, sortSettings)
+
+			attempt := 0
+			rp.onUpdatePopularity = func() {
+				if attempt < len(tt.concurrent) {
+					promotion := rp.promotions["promo-1"]
+					promotion.Popularity += tt.concurrent[attempt]
+					rp.promotions["promo-1"] = promotion
+				}
+				attempt++
+			}
+
+			err := s.updatePromotionPopularity(context.Background(), "promo-1", tt.interactionType, tt.sign)
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+
+			stored := rp.promotions["promo-1"]
+			if stored.Popularity != tt.want {
+				t.Fatalf("popularity = %d, want %d", stored.Popularity, tt.want)
+			}
+			if tt.code == "" && tt.want != tt.current && stored.HotScore != s.hotScore(tt.want, createdAt) {
+				t.Fatalf("hot score = %f, want %f", stored.HotScore, s.hotScore(tt.want, createdAt))
+			}
+		})
+	}
+}
+
+func TestUpdatePromotionPopularityMissingPromotion(t *testing.T) {
+	s := newTestService(newFakeRepository(), sortSettings)
+
+	if err := s.updatePromotionPopularity(context.Background(), "missing", model.Like, 1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillPromotionSortKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexed []string
+		// raced are indexed by another instance once the scan is done
+		raced      []string
+		backfilled int
+	}{
+		{name: "everything indexed", indexed: []string{"promo-1", "promo-2"}},
+		{name: "legacy promotions", backfilled: 2},
+		{name: "partly indexed", indexed: []string{"promo-1"}, backfilled: 1},
+		{name: "skips what another instance indexed", raced: []string{"promo-1"}, backfilled: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			createdAt := time.Now().Add(-time.Hour)
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", Popularity: 4, CreatedAt: createdAt}
+			rp.promotions["promo-2"] = model.Promotion{Id: "promo-2", Popularity: 7, CreatedAt: createdAt}
+			for _, id := range tt.indexed {
+				rp.indexed[id] = true
+			}
+			rp.onScanSortKeys = func() {
+				for _, id := range tt.raced {
+					rp.indexed[id] = true
+				}
+			}
+			s := newTestService(rp, sortSettings)
+
+			backfilled, err := s.BackfillPromotionSortKeys(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if backfilled != tt.backfilled {
+				t.Fatalf("backfilled = %d, want %d", backfilled, tt.backfilled)
+			}
+			for id, promotion := range rp.promotions {
+				if !rp.indexed[id] {
+					t.Fatalf("promotion %s not indexed", id)
+				}
+				written := promotion.HotScore != 0
+				if written != (backfilled > 0 && !slices.Contains(tt.indexed, id) && !slices.Contains(tt.raced, id)) {
+					t.Fatalf("promotion %s hot score = %f", id, promotion.HotScore)
+				}
+				if written && promotion.HotScore != s.hotScore(promotion.Popularity, createdAt) {
+					t.Fatalf("promotion %s hot score = %f, want %f", id, promotion.HotScore, s.hotScore(promotion.Popularity, createdAt))
+				}
+			}
+		})
+	}
+}
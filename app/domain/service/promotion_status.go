@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"time"
+)
+
+const defaultExpirationBatchSize = 100
+
+func (s *service) UpdatePromotionStatus(ctx context.Context, id string, change *model.PromotionStatusChange) (*model.Promotion, error) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if change == nil {
+		err = errs.Invalid("empty_body", "status change is empty")
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	promotion, err := s.rp.GetPromotionById(ctx, id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	if promotion == nil {
+		err = errs.NotFound("promotion")
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	if err = requireOwner(actor, promotion.UserId, model.RoleModerator, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	if change.ExpiresAt != nil {
+		promotion.ExpiresAt, err = s.promotionExpiresAt(change.ExpiresAt, now)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+	}
+
+	err = s.changePromotionStatus(ctx, promotion, change.Status, now)
+	if errors.Is(err, port.ErrConditionFailed) {
+		err = errs.Conflict("promotion_busy", "promotion status changed meanwhile, try again")
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	s.logger(ctx).Debug("promotion status updated")
+	return promotion, nil
+}
+
+// ExpirePromotions moves one batch of active promotions past their expiresAt to
+// expired. Writes are conditional on the status read, so several instances can
+// sweep at the same time and an owner's concurrent change always wins.
+func (s *service) ExpirePromotions(ctx context.Context) (int, error) {
+	batchSize := s.cfg.Viper.GetInt32("service.promotion.expiration.sweeper.batch-size")
+	if batchSize <= 0 {
+		batchSize = defaultExpirationBatchSize
+	}
+
+	now := time.Now()
+	promotions, err := s.rp.GetPromotionsDueToExpire(ctx, now, batchSize)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return 0, err
+	}
+
+	expired := 0
+	for i := range promotions {
+		err = s.changePromotionStatus(ctx, &promotions[i], model.PromotionExpired, now)
+		if errors.Is(err, port.ErrConditionFailed) {
+			continue
+		}
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return expired, err
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		s.logger(ctx).Info("promotions expired", config.F("count", expired))
+	}
+	return expired, nil
+}
+
+// changePromotionStatus validates the transition and persists it. Ended
+// promotions get a ttl so DynamoDB archives them once archive-after elapses.
+func (s *service) changePromotionStatus(ctx context.Context, promotion *model.Promotion, next model.PromotionStatus, now time.Time) error {
+	if !next.IsValid() {
+		return errs.Validation("status change is invalid",
+			errs.Field("status", "invalid_value", "status must be one of active, expired, sold_out, removed"))
+	}
+
+	previous := promotion.CurrentStatus()
+	if !previous.CanTransitionTo(next) {
+		return errs.Conflict("invalid_status_transition", fmt.Sprintf("promotion cannot move from %s to %s", previous, next))
+	}
+
+	if next == model.PromotionActive && promotion.IsExpiredAt(now) {
+		return errs.Validation("status change is invalid",
+			errs.Field("expiresAt", "out_of_range", "expiresAt must be in the future to reactivate the promotion"))
+	}
+
+	promotion.Status = next
+	promotion.Ttl = 0
+	archiveAfter := s.cfg.Viper.GetDuration("service.promotion.expiration.archive-after")
+	if next != model.PromotionActive && archiveAfter > 0 {
+		promotion.Ttl = now.Add(archiveAfter).Unix()
+	}
+
+	if err := s.rp.UpdatePromotionStatus(ctx, promotion, previous); err != nil {
+		return err
+	}
+
+	s.mt.PromotionStatusChanged(previous, next)
+	return nil
+}
+
+// promotionExpiresAt normalizes expiresAt to whole UTC seconds, so the stored
+// strings sort chronologically, and applies the default lifetime when absent.
+func (s *service) promotionExpiresAt(expiresAt *time.Time, now time.Time) (*time.Time, error) {
+	if expiresAt == nil {
+		defaultTtl := s.cfg.Viper.GetDuration("service.promotion.expiration.default-ttl")
+		if defaultTtl <= 0 {
+			return nil, nil
+		}
+		defaultExpiresAt := now.Add(defaultTtl)
+		expiresAt = &defaultExpiresAt
+	}
+
+	normalized := expiresAt.UTC().Truncate(time.Second)
+	if !normalized.After(now) {
+		return nil, errs.Validation("promotion is invalid",
+			errs.Field("expiresAt", "out_of_range", "expiresAt must be in the future"))
+	}
+
+	maxTtl := s.cfg.Viper.GetDuration("service.promotion.expiration.max-ttl")
+	if maxTtl > 0 && normalized.After(now.Add(maxTtl)) {
+		return nil, errs.Validation("promotion is invalid",
+			errs.Field("expiresAt", "out_of_range", fmt.Sprintf("expiresAt must be within %s", maxTtl)))
+	}
+
+	return &normalized, nil
+}
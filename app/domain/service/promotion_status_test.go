@@ -0,0 +1,465 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+var expirationSettings = map[string]any{
+	"service.promotion.expiration.default-ttl":        "720h",
+	"service.promotion.expiration.max-ttl":            "8760h",
+	"service.promotion.expiration.archive-after":      "2160h",
+	"service.promotion.expiration.sweeper.batch-size": 2,
+}
+
+func timeRef(t time.Time) *time.Time {
+	return &t
+}
+
+func TestUpdatePromotionStatus(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		promotion model.Promotion
+		actor     model.Actor
+		change    model.PromotionStatusChange
+		// race changes the stored promotion after it was read
+		race   bool
+		code   string
+		status model.PromotionStatus
+		ttl    bool
+	}{
+		{
+			name:      "owner marks sold out",
+			promotion: model.Promotion{Status: model.PromotionActive},
+			change:    model.PromotionStatusChange{Status: model.PromotionSoldOut},
+			status:    model.PromotionSoldOut,
+			ttl:       true,
+		},
+		{
+			name:      "legacy promotions without status count as active",
+			promotion: model.Promotion{},
+			change:    model.PromotionStatusChange{Status: model.PromotionExpired},
+			status:    model.PromotionExpired,
+			ttl:       true,
+		},
+		{
+			name:      "reactivation clears the ttl",
+			promotion: model.Promotion{Status: model.PromotionExpired, ExpiresAt: &past, Ttl: 1},
+			change:    model.PromotionStatusChange{Status: model.PromotionActive, ExpiresAt: &future},
+			status:    model.PromotionActive,
+		},
+		{
+			name:      "reactivation needs a future expiresAt",
+			promotion: model.Promotion{Status: model.PromotionExpired, ExpiresAt: &past},
+			change:    model.PromotionStatusChange{Status: model.PromotionActive},
+			code:      "validation_failed",
+		},
+		{
+			name:      "removed is terminal",
+			promotion: model.Promotion{Status: model.PromotionRemoved},
+			change:    model.PromotionStatusChange{Status: model.PromotionActive, ExpiresAt: &future},
+			code:      "invalid_status_transition",
+		},
+		{
+			name:      "same status is not a transition",
+			promotion: model.Promotion{Status: model.PromotionActive},
+			change:    model.PromotionStatusChange{Status: model.PromotionActive},
+			code:      "invalid_status_transition",
+		},
+		{
+			name:      "unknown status",
+			promotion: model.Promotion{Status: model.PromotionActive},
+			change:    model.PromotionStatusChange{Status: "archived"},
+			code:      "validation_failed",
+		},
+		{
+			name:      "other users cannot change it",
+			promotion: model.Promotion{Status: model.PromotionActive},
+			actor:     model.Actor{UserId: "user-2", Role: model.RoleUser},
+			change:    model.PromotionStatusChange{Status: model.PromotionRemoved},
+			code:      "not_owner",
+		},
+		{
+			name:      "moderators can remove it",
+			promotion: model.Promotion{Status: model.PromotionActive},
+			actor:     model.Actor{UserId: "user-2", Role: model.RoleModerator},
+			change:    model.PromotionStatusChange{Status: model.PromotionRemoved},
+			status:    model.PromotionRemoved,
+			ttl:       true,
+		},
+		{
+			name:      "concurrent change conflicts",
+			promotion: model.Promotion{Status: model.PromotionActive},
+			change:    model.PromotionStatusChange{Status: model.PromotionSoldOut},
+			race:      true,
+			code:      "promotion_busy",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			tt.promotion.Id, tt.promotion.UserId = "promo-1", "user-1"
+			rp.promotions["promo-1"] = tt.promotion
+			s := newTestService(rp, expirationSettings)
+
+			actor := tt.actor
+			if actor.UserId == "" {
+				actor = model.Actor{UserId: "user-1", Role: model.RoleUser}
+			}
+			if tt.race {
+				s.rp = &racingRepository{fakeRepository: rp}
+			}
+
+			promotion, err := s.UpdatePromotionStatus(model.WithActor(context.Background(), &actor), "promo-1", &tt.change)
+			if tt.code != "" {
+				if e, ok := errs.As(err); !ok || e.Code != tt.code {
+					t.Fatalf("error = %v, want code %s", err, tt.code)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stored := rp.promotions["promo-1"]
+			if promotion.Status != tt.status || stored.Status != tt.status {
+				t.Fatalf("status = %s, stored %s, want %s", promotion.Status, stored.Status, tt.status)
+			}
+			if (stored.Ttl > 0) != tt.ttl {
+				t.Fatalf("ttl = %d", stored.Ttl)
+			}
+		})
+	}
+}
+
+// racingRepository lets another writer change the status between the read and
+// the conditional write.
+type racingRepository struct {
+	*fakeRepository
+}
+
+func (r *racingRepository) UpdatePromotionStatus(ctx context.Context, promotion *model.Promotion, previous model.PromotionStatus) error {
+	stored := r.promotions[promotion.Id]
+	stored.Status = model.PromotionRemoved
+	r.promotions[promotion.Id] = stored
+	return r.fakeRepository.UpdatePromotionStatus(ctx, promotion, previous)
+}
+
+func TestExpirePromotions(t *testing.T) {
+	now := time.Now()
+
+	rp := newFakeRepository()
+	rp.promotions["due-1"] = model.Promotion{Id: "due-1", ExpiresAt: timeRef(now.Add(-time.Hour))}
+	rp.promotions["due-2"] = model.Promotion{Id: "due-2", Status: model.PromotionActive, ExpiresAt: timeRef(now.Add(-time.Minute))}
+	rp.promotions["due-3"] = model.Promotion{Id: "due-3", Status: model.PromotionActive, ExpiresAt: timeRef(now.Add(-time.Second))}
+	rp.promotions["raced"] = model.Promotion{Id: "raced", Status: model.PromotionActive, ExpiresAt: timeRef(now.Add(-time.Hour))}
+	rp.promotions["open"] = model.Promotion{Id: "open", Status: model.PromotionActive}
+	rp.promotions["later"] = model.Promotion{Id: "later", Status: model.PromotionActive, ExpiresAt: timeRef(now.Add(time.Hour))}
+	rp.promotions["sold"] = model.Promotion{Id: "sold", Status: model.PromotionSoldOut, ExpiresAt: timeRef(now.Add(-time.Hour))}
+
+	s := newTestService(rp, expirationSettings)
+	ctx := context.Background()
+
+	// the owner marks one promotion sold out while the sweeper holds it
+	raced := false
+	rp.onDueToExpire = func() {
+		if !raced {
+			raced = true
+			promotion := rp.promotions["raced"]
+			promotion.Status = model.PromotionSoldOut
+			rp.promotions["raced"] = promotion
+		}
+	}
+
+	total := 0
+	for i := 0; i < 5; i++ {
+		expired, err := s.ExpirePromotions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expired > 2 {
+			t.Fatalf("batch expired %d, batch size is 2", expired)
+		}
+		total += expired
+	}
+	if total != 3 {
+		t.Fatalf("expired %d promotions, want 3", total)
+	}
+
+	want := map[string]model.PromotionStatus{
+		"due-1": model.PromotionExpired,
+		"due-2": model.PromotionExpired,
+		"due-3": model.PromotionExpired,
+		"raced": model.PromotionSoldOut,
+		"open":  model.PromotionActive,
+		"later": model.PromotionActive,
+		"sold":  model.PromotionSoldOut,
+	}
+	for id, status := range want {
+		if got := rp.promotions[id]; got.CurrentStatus() != status {
+			t.Errorf("%s status = %s, want %s", id, got.CurrentStatus(), status)
+		}
+	}
+}
+
+func TestPromotionExpiresAt(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		settings  map[string]any
+		expiresAt *time.Time
+		want      *time.Time
+		invalid   bool
+	}{
+		{name: "default ttl", expiresAt: nil, want: timeRef(now.Add(720 * time.Hour))},
+		{
+			name:     "no default keeps it open-ended",
+			settings: map[string]any{"service.promotion.expiration.default-ttl": "0s"},
+			want:     nil,
+		},
+		{
+			name:      "normalized to whole utc seconds",
+			expiresAt: timeRef(now.Add(time.Hour + 500*time.Millisecond).In(time.FixedZone("BRT", -3*3600))),
+			want:      timeRef(now.Add(time.Hour)),
+		},
+		{name: "past", expiresAt: timeRef(now.Add(-time.Second)), invalid: true},
+		{name: "now", expiresAt: timeRef(now), invalid: true},
+		{name: "beyond max ttl", expiresAt: timeRef(now.Add(8761 * time.Hour)), invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := map[string]any{}
+			for key, value := range expirationSettings {
+				settings[key] = value
+			}
+			for key, value := range tt.settings {
+				settings[key] = value
+			}
+			s := newTestService(newFakeRepository(), settings)
+
+			got, err := s.promotionExpiresAt(tt.expiresAt, now)
+			if tt.invalid {
+				if e, ok := errs.As(err); !ok || e.Kind != errs.KindValidation {
+					t.Fatalf("error = %v, want validation", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && (!got.Equal(*tt.want) || got.Location() != time.UTC)) {
+				t.Fatalf("expiresAt = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPromotionByIdHidesRemoved(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  model.PromotionStatus
+		actor   *model.Actor
+		visible bool
+	}{
+		{name: "active is public", status: model.PromotionActive, visible: true},
+		{name: "expired is public", status: model.PromotionExpired, actor: &model.Actor{UserId: "user-2"}, visible: true},
+		{name: "removed without actor", status: model.PromotionRemoved},
+		{name: "removed for other users", status: model.PromotionRemoved, actor: &model.Actor{UserId: "user-2", Role: model.RoleUser}},
+		{name: "removed for the owner", status: model.PromotionRemoved, actor: &model.Actor{UserId: "user-1"}, visible: true},
+		{name: "removed for moderators", status: model.PromotionRemoved, actor: &model.Actor{UserId: "user-2", Role: model.RoleModerator}, visible: true},
+		{name: "removed for admins", status: model.PromotionRemoved, actor: &model.Actor{UserId: "user-2", Role: model.RoleAdmin}, visible: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "user-1", Status: tt.status}
+			s := newTestService(rp, nil)
+
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = model.WithActor(ctx, tt.actor)
+			}
+
+			promotion, err := s.GetPromotionById(ctx, "promo-1")
+			if tt.visible {
+				if err != nil || promotion == nil {
+					t.Fatalf("promotion = %v, error = %v", promotion, err)
+				}
+				return
+			}
+			if e, ok := errs.As(err); !ok || e.Kind != errs.KindNotFound {
+				t.Fatalf("error = %v, want not found", err)
+			}
+		})
+	}
+}
+
+func TestUpdatePromotionRacesStatusChange(t *testing.T) {
+	tests := []struct {
+		name string
+		// meanwhile is the status written between the read and the edit
+		meanwhile model.PromotionStatus
+		code      string
+	}{
+		{name: "status unchanged"},
+		{name: "removed meanwhile", meanwhile: model.PromotionRemoved, code: "promotion_busy"},
+		{name: "expired meanwhile", meanwhile: model.PromotionExpired, code: "promotion_busy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", EmailVerified: true}
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "user-1", Title: "title", Status: model.PromotionActive}
+			rp.onUpdatePromotion = func() {
+				if tt.meanwhile != "" {
+					promotion := rp.promotions["promo-1"]
+					promotion.Status = tt.meanwhile
+					rp.promotions["promo-1"] = promotion
+				}
+			}
+			s := newTestService(rp, expirationSettings)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+			err := s.UpdatePromotion(ctx, &model.Promotion{Id: "promo-1", Title: "changed", Link: "https://shop", OriginalPrice: 10, DiscountedPrice: 5})
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+
+			stored := rp.promotions["promo-1"]
+			if tt.code != "" && (stored.Title != "title" || stored.Status != tt.meanwhile) {
+				t.Fatalf("stored promotion = %+v", stored)
+			}
+			if tt.code == "" && stored.Title != "changed" {
+				t.Fatalf("stored promotion = %+v", stored)
+			}
+		})
+	}
+}
+
+func TestUpdatePromotionImageRespectsStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status model.PromotionStatus
+		// meanwhile is the status written between the read and the edit
+		meanwhile model.PromotionStatus
+		code      string
+	}{
+		{name: "active", status: model.PromotionActive},
+		{name: "expired", status: model.PromotionExpired},
+		{name: "removed", status: model.PromotionRemoved, code: "promotion_removed"},
+		{name: "removed meanwhile", status: model.PromotionActive, meanwhile: model.PromotionRemoved, code: "promotion_busy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "user-1", Status: tt.status}
+			rp.onUpdatePromotion = func() {
+				if tt.meanwhile != "" {
+					promotion := rp.promotions["promo-1"]
+					promotion.Status = tt.meanwhile
+					rp.promotions["promo-1"] = promotion
+				}
+			}
+			s := newTestService(rp, nil)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+			err := s.UpdatePromotionImage(ctx, "promo-1", strings.NewReader("image"))
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+
+			stored := rp.promotions["promo-1"]
+			if (stored.ImageUrl != "") != (tt.code == "") {
+				t.Fatalf("stored promotion = %+v", stored)
+			}
+			if tt.meanwhile != "" && stored.Status != tt.meanwhile {
+				t.Fatalf("status = %s, want %s", stored.Status, tt.meanwhile)
+			}
+		})
+	}
+}
+
+func TestGetFavoritesPromotionsHidesRemoved(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor *model.Actor
+		want  []string
+	}{
+		{name: "anonymous", want: []string{"active", "expired"}},
+		{name: "other user", actor: &model.Actor{UserId: "user-2"}, want: []string{"active", "expired"}},
+		{name: "owner", actor: &model.Actor{UserId: "user-1"}, want: []string{"active", "expired", "removed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			for _, status := range []model.PromotionStatus{model.PromotionActive, model.PromotionExpired, model.PromotionRemoved} {
+				id := string(status)
+				rp.promotions[id] = model.Promotion{Id: id, UserId: "user-1", Status: status}
+				rp.interactions["fan#"+id] = model.PromotionInteraction{Id: "fan#" + id, UserId: "fan", PromotionId: id, InteractionType: model.Favorite}
+			}
+			s := newTestService(rp, nil)
+
+			ctx := context.Background()
+			if tt.actor != nil {
+				ctx = model.WithActor(ctx, tt.actor)
+			}
+
+			page, err := s.GetFavoritesPromotionsByUserId(ctx, "fan", &model.PageRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, promotion := range page.Items {
+				ids = append(ids, promotion.Id)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("promotions = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdatePromotionBackfillsStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    model.PromotionStatus
+		expiresAt *time.Time
+		want      model.PromotionStatus
+	}{
+		{name: "legacy promotion gains an expiry", expiresAt: timeRef(time.Now().Add(time.Hour)), want: model.PromotionActive},
+		{name: "legacy promotion without expiry", want: model.PromotionActive},
+		{name: "status is kept", status: model.PromotionSoldOut, expiresAt: timeRef(time.Now().Add(time.Hour)), want: model.PromotionSoldOut},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", EmailVerified: true}
+			rp.promotions["promo-1"] = model.Promotion{Id: "promo-1", UserId: "user-1", Status: tt.status}
+			s := newTestService(rp, expirationSettings)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+			err := s.UpdatePromotion(ctx, &model.Promotion{Id: "promo-1", Title: "changed", Link: "https://shop", OriginalPrice: 10, DiscountedPrice: 5, ExpiresAt: tt.expiresAt})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored := rp.promotions["promo-1"]; stored.Status != tt.want {
+				t.Fatalf("status = %q, want %q", stored.Status, tt.want)
+			}
+		})
+	}
+}
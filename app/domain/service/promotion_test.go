@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"maps"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"reflect"
+	"testing"
+)
+
+func TestCreatePromotionValidation(t *testing.T) {
+	valid := func(change func(*model.Promotion)) *model.Promotion {
+		promotion := &model.Promotion{Title: "Console", Link: "https://store.com", Platform: "store", OriginalPrice: 100, DiscountedPrice: 80, Categories: []string{"games"}}
+		change(promotion)
+		return promotion
+	}
+
+	tests := []struct {
+		name      string
+		promotion *model.Promotion
+		fields    []string
+	}{
+		{name: "valid", promotion: valid(func(*model.Promotion) {})},
+		{name: "free", promotion: valid(func(p *model.Promotion) { p.DiscountedPrice = 0 })},
+		{name: "no categories", promotion: valid(func(p *model.Promotion) { p.Categories = nil })},
+		{name: "zero price", promotion: valid(func(p *model.Promotion) { p.OriginalPrice = 0; p.DiscountedPrice = 0 }), fields: []string{"originalPrice:out_of_range"}},
+		{name: "discount above price", promotion: valid(func(p *model.Promotion) { p.DiscountedPrice = 120 }), fields: []string{"discountedPrice:out_of_range"}},
+		{name: "negative discount", promotion: valid(func(p *model.Promotion) { p.DiscountedPrice = -1 }), fields: []string{"discountedPrice:out_of_range"}},
+		{name: "unregistered category", promotion: valid(func(p *model.Promotion) { p.Categories = []string{"games", "weapons"} }), fields: []string{"categories[1]:invalid_value"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", EmailVerified: true}
+			rp.categories["games"] = model.Category{Name: "games"}
+			settings := maps.Clone(scoreSettings)
+			maps.Copy(settings, sortSettings)
+			s := newTestService(rp, settings)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+			err := s.CreatePromotion(ctx, tt.promotion)
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(rp.promotions) != 1 {
+					t.Fatal("promotion not created")
+				}
+				return
+			}
+
+			e, ok := errs.As(err)
+			if !ok || e.Code != "validation_failed" {
+				t.Fatalf("error = %v, want validation_failed", err)
+			}
+			var fields []string
+			for _, field := range e.Fields {
+				fields = append(fields, field.Field+":"+field.Code)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Fatalf("fields = %v, want %v", fields, tt.fields)
+			}
+			if len(rp.promotions) != 0 {
+				t.Fatal("promotion created")
+			}
+		})
+	}
+}
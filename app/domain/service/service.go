@@ -1,7 +1,12 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"os"
 	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
 	"pixelPromo/domain/port"
 )
 
@@ -9,19 +14,62 @@ func NewService(
 	rp port.Repository,
 	cfg *config.Config,
 	st port.Storage,
+	ml port.Mailer,
+	mt port.Metrics,
 	log config.Logger,
 ) port.Handler {
+	actionTokenSecret := os.Getenv(cfg.Viper.GetString("service.auth.action-token.secret-env"))
+	if actionTokenSecret == "" {
+		panic(errors.New("action token secret is empty"))
+	}
+
 	return &service{
-		rp:  rp,
-		cfg: cfg,
-		st:  st,
-		log: log,
+		rp:                rp,
+		cfg:               cfg,
+		st:                st,
+		ml:                ml,
+		mt:                mt,
+		log:               log,
+		actionTokenSecret: []byte(actionTokenSecret),
+		health:            &healthCache{},
+		dummyHash:         &dummyPasswordHash{},
 	}
 }
 
 type service struct {
-	rp  port.Repository
-	cfg *config.Config
-	st  port.Storage
-	log config.Logger
+	rp                port.Repository
+	cfg               *config.Config
+	st                port.Storage
+	ml                port.Mailer
+	mt                port.Metrics
+	log               config.Logger
+	actionTokenSecret []byte
+	health            *healthCache
+	dummyHash         *dummyPasswordHash
+}
+
+func (s *service) logger(ctx context.Context) config.Logger {
+	return config.LoggerFromContext(ctx, s.log)
+}
+
+func actorFromContext(ctx context.Context) (*model.Actor, error) {
+	actor := model.ActorFromContext(ctx)
+	if actor == nil || actor.UserId == "" {
+		return nil, errs.Unauthenticated("missing_actor", "authentication required")
+	}
+	return actor, nil
+}
+
+func requireOwner(actor *model.Actor, ownerId string, bypassRoles ...model.Role) error {
+	if actor.UserId != ownerId && !actor.HasRole(bypassRoles...) {
+		return errs.Forbidden("not_owner", "resource belongs to another user")
+	}
+	return nil
+}
+
+func requireRole(actor *model.Actor, roles ...model.Role) error {
+	if !actor.HasRole(roles...) {
+		return errs.Forbidden("insufficient_role", "insufficient role")
+	}
+	return nil
 }
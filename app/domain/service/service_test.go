@@ -0,0 +1,682 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"io"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"sort"
+	"time"
+)
+
+// fakeRepository keeps what the tests touch in memory. Methods a test does not
+// override panic through the nil embedded interface, so unexpected calls show up.
+type fakeRepository struct {
+	port.Repository
+
+	users         map[string]model.User
+	sessions      map[string]model.Session
+	promotions    map[string]model.Promotion
+	interactions  map[string]model.PromotionInteraction
+	categories    map[string]model.Category
+	actionTokens  map[string]model.ActionToken
+	loginAttempts map[string]model.LoginAttempt
+	identities    map[string]model.UserIdentity
+	apiKeys       map[string]model.ApiKey
+	scores        []model.UserScore
+	tables        []model.DependencyHealth
+	healthChecks  int
+	// indexed holds the promotions that already carry their sort keys
+	indexed map[string]bool
+
+	// onUpdatePopularity runs before each conditional popularity write, so a
+	// test can play a concurrent writer
+	onUpdatePopularity func()
+	// onCreateSession runs after a session is stored, so a test can play a
+	// concurrent revocation
+	onCreateSession func(*model.Session)
+	// onDueToExpire runs after each sweeper query, so a test can change a
+	// promotion the sweeper already holds
+	onDueToExpire func()
+	// onGetApiKey runs after a key is read, so a test can revoke it before it
+	// is used
+	onGetApiKey func(id string)
+	// onScanSortKeys runs after the backfill scan, so a test can play another
+	// instance backfilling the same promotions
+	onScanSortKeys func()
+	// onUpdatePromotion runs before each conditional promotion edit, so a test
+	// can change the status the edit was based on
+	onUpdatePromotion func()
+	// onAddUserScore runs before each score write, so a test can play a
+	// concurrent grant or deletion
+	onAddUserScore func()
+	// onGetUser runs after a user is read, so a test can change it before the
+	// service writes back
+	onGetUser func()
+}
+
+func newFakeRepository() *fakeRepository {
+	return &fakeRepository{
+		users:         map[string]model.User{},
+		sessions:      map[string]model.Session{},
+		promotions:    map[string]model.Promotion{},
+		interactions:  map[string]model.PromotionInteraction{},
+		categories:    map[string]model.Category{},
+		actionTokens:  map[string]model.ActionToken{},
+		loginAttempts: map[string]model.LoginAttempt{},
+		identities:    map[string]model.UserIdentity{},
+		apiKeys:       map[string]model.ApiKey{},
+		indexed:       map[string]bool{},
+	}
+}
+
+func (r *fakeRepository) GetUserById(_ context.Context, id string) (*model.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	if r.onGetUser != nil {
+		r.onGetUser()
+	}
+	return &user, nil
+}
+
+// GetAllUsers pages by id, the cursor is the last id of the previous page.
+func (r *fakeRepository) GetAllUsers(_ context.Context, page *model.PageRequest) ([]model.User, string, error) {
+	users := make([]model.User, 0, len(r.users))
+	for _, user := range r.users {
+		if user.Id > page.Cursor {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+	if page.Limit > 0 && len(users) > int(page.Limit) {
+		users = users[:page.Limit]
+		return users, users[len(users)-1].Id, nil
+	}
+	return users, "", nil
+}
+
+func (r *fakeRepository) GetUserByEmail(_ context.Context, email string) (*model.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			if r.onGetUser != nil {
+				r.onGetUser()
+			}
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeRepository) CreateOrUpdateUser(_ context.Context, user *model.User) error {
+	r.users[user.Id] = *user
+	return nil
+}
+
+func (r *fakeRepository) CreateUser(_ context.Context, user *model.User) error {
+	if _, ok := r.users[user.Id]; ok || r.emailTaken(user.Email, user.Id) {
+		return port.ErrConditionFailed
+	}
+	r.users[user.Id] = *user
+	return nil
+}
+
+func (r *fakeRepository) UpdateUserEmail(_ context.Context, user *model.User, _ string) error {
+	if _, ok := r.users[user.Id]; !ok || r.emailTaken(user.Email, user.Id) {
+		return port.ErrConditionFailed
+	}
+	r.users[user.Id] = *user
+	return nil
+}
+
+func (r *fakeRepository) emailTaken(email string, userId string) bool {
+	if email == "" {
+		return false
+	}
+	for _, user := range r.users {
+		if user.Email == email && user.Id != userId {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *fakeRepository) DeleteUser(_ context.Context, id string) error {
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeRepository) CreateOrUpdateSession(_ context.Context, session *model.Session) error {
+	r.sessions[session.Id] = *session
+	if r.onCreateSession != nil {
+		r.onCreateSession(session)
+	}
+	return nil
+}
+
+func (r *fakeRepository) RevokeSessionById(_ context.Context, id string) error {
+	session, ok := r.sessions[id]
+	if !ok {
+		return port.ErrConditionFailed
+	}
+	session.Revoked = true
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *fakeRepository) RotateSession(_ context.Context, id string, usedAt time.Time) error {
+	session, ok := r.sessions[id]
+	if !ok || session.Rotated || session.Revoked {
+		return port.ErrConditionFailed
+	}
+	session.Rotated = true
+	session.LastUsedAt = usedAt
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *fakeRepository) GetSessionById(_ context.Context, id string) (*model.Session, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &session, nil
+}
+
+func (r *fakeRepository) GetSessionsByFamilyId(_ context.Context, familyId string) ([]model.Session, error) {
+	var sessions []model.Session
+	for _, session := range r.sessions {
+		if session.FamilyId == familyId {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeRepository) GetSessionsByUserId(_ context.Context, userId string) ([]model.Session, error) {
+	var sessions []model.Session
+	for _, session := range r.sessions {
+		if session.UserId == userId {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *fakeRepository) GetPromotionById(_ context.Context, id string) (*model.Promotion, error) {
+	promotion, ok := r.promotions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &promotion, nil
+}
+
+func (r *fakeRepository) CreateOrUpdatePromotion(_ context.Context, promotion *model.Promotion) error {
+	r.promotions[promotion.Id] = *promotion
+	return nil
+}
+
+func (r *fakeRepository) UpdatePromotionPopularity(_ context.Context, promotion *model.Promotion, previous int) error {
+	if r.onUpdatePopularity != nil {
+		r.onUpdatePopularity()
+	}
+	stored, ok := r.promotions[promotion.Id]
+	if !ok || stored.Popularity != previous {
+		return port.ErrConditionFailed
+	}
+	stored.Popularity = promotion.Popularity
+	stored.HotScore = promotion.HotScore
+	r.promotions[promotion.Id] = stored
+	return nil
+}
+
+func (r *fakeRepository) GetPromotionsWithoutSortKeys(context.Context) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	for _, promotion := range r.promotions {
+		if !r.indexed[promotion.Id] {
+			promotions = append(promotions, promotion)
+		}
+	}
+	sort.Slice(promotions, func(i, j int) bool { return promotions[i].Id < promotions[j].Id })
+	if r.onScanSortKeys != nil {
+		r.onScanSortKeys()
+	}
+	return promotions, nil
+}
+
+func (r *fakeRepository) BackfillPromotionSortKeys(_ context.Context, promotion *model.Promotion) error {
+	stored, ok := r.promotions[promotion.Id]
+	if !ok || r.indexed[promotion.Id] {
+		return port.ErrConditionFailed
+	}
+	stored.HotScore = promotion.HotScore
+	r.promotions[promotion.Id] = stored
+	r.indexed[promotion.Id] = true
+	return nil
+}
+
+func (r *fakeRepository) UpdatePromotion(_ context.Context, promotion *model.Promotion, previous model.PromotionStatus) error {
+	if r.onUpdatePromotion != nil {
+		r.onUpdatePromotion()
+	}
+	stored, ok := r.promotions[promotion.Id]
+	if !ok || stored.CurrentStatus() != previous {
+		return port.ErrConditionFailed
+	}
+	r.promotions[promotion.Id] = *promotion
+	return nil
+}
+
+func (r *fakeRepository) UpdatePromotionStatus(_ context.Context, promotion *model.Promotion, previous model.PromotionStatus) error {
+	stored, ok := r.promotions[promotion.Id]
+	if !ok || stored.CurrentStatus() != previous {
+		return port.ErrConditionFailed
+	}
+	r.promotions[promotion.Id] = *promotion
+	return nil
+}
+
+func (r *fakeRepository) UpdatePromotionExpiredReports(_ context.Context, promotion *model.Promotion, previous float64) error {
+	stored, ok := r.promotions[promotion.Id]
+	if !ok || stored.ExpiredReports != previous {
+		return port.ErrConditionFailed
+	}
+	stored.ExpiredReports = promotion.ExpiredReports
+	stored.ExpiredFlaggedAt = promotion.ExpiredFlaggedAt
+	r.promotions[promotion.Id] = stored
+	return nil
+}
+
+func (r *fakeRepository) GetPromotionsDueToExpire(_ context.Context, now time.Time, limit int32) ([]model.Promotion, error) {
+	var promotions []model.Promotion
+	for _, promotion := range r.promotions {
+		if int32(len(promotions)) == limit {
+			break
+		}
+		if promotion.CurrentStatus() == model.PromotionActive && promotion.IsExpiredAt(now) {
+			promotions = append(promotions, promotion)
+		}
+	}
+	if r.onDueToExpire != nil {
+		r.onDueToExpire()
+	}
+	return promotions, nil
+}
+
+func (r *fakeRepository) DeletePromotion(_ context.Context, id string) error {
+	delete(r.promotions, id)
+	return nil
+}
+
+func (r *fakeRepository) GetInteractionById(_ context.Context, id string) (*model.PromotionInteraction, error) {
+	interaction, ok := r.interactions[id]
+	if !ok {
+		return nil, nil
+	}
+	return &interaction, nil
+}
+
+func (r *fakeRepository) GetInteractionsByPromotionId(_ context.Context, promotionId string) ([]model.PromotionInteraction, error) {
+	var interactions []model.PromotionInteraction
+	for _, interaction := range r.interactions {
+		if interaction.PromotionId == promotionId {
+			interactions = append(interactions, interaction)
+		}
+	}
+	return interactions, nil
+}
+
+func (r *fakeRepository) GetInteractionsByTypeWithUserId(_ context.Context, interactionType model.InteractionType, userId string, _ *model.PageRequest) ([]model.PromotionInteraction, string, error) {
+	var interactions []model.PromotionInteraction
+	for _, interaction := range r.interactions {
+		if interaction.UserId == userId && interaction.InteractionType == interactionType {
+			interactions = append(interactions, interaction)
+		}
+	}
+	sort.Slice(interactions, func(i, j int) bool { return interactions[i].Id < interactions[j].Id })
+	return interactions, "", nil
+}
+
+func (r *fakeRepository) GetInteractionsByPromotionIdAndType(_ context.Context, promotionId string, interactionType model.InteractionType) ([]model.PromotionInteraction, error) {
+	var interactions []model.PromotionInteraction
+	for _, interaction := range r.interactions {
+		if interaction.PromotionId == promotionId && interaction.InteractionType == interactionType {
+			interactions = append(interactions, interaction)
+		}
+	}
+	return interactions, nil
+}
+
+func (r *fakeRepository) CreateOrUpdateInteraction(_ context.Context, interaction *model.PromotionInteraction) error {
+	r.interactions[interaction.Id] = *interaction
+	return nil
+}
+
+func (r *fakeRepository) DeleteInteraction(_ context.Context, id string) error {
+	delete(r.interactions, id)
+	return nil
+}
+
+func (r *fakeRepository) UpdateUserPassword(_ context.Context, id string, password string) error {
+	stored, ok := r.users[id]
+	if !ok {
+		return port.ErrConditionFailed
+	}
+	stored.Password = password
+	r.users[id] = stored
+	return nil
+}
+
+func (r *fakeRepository) VerifyUserEmail(_ context.Context, id string, email string) error {
+	stored, ok := r.users[id]
+	if !ok || stored.Email != email {
+		return port.ErrConditionFailed
+	}
+	stored.EmailVerified = true
+	r.users[id] = stored
+	return nil
+}
+
+func (r *fakeRepository) ResetUnverifiedUser(_ context.Context, id string, email string) error {
+	stored, ok := r.users[id]
+	if !ok || stored.EmailVerified || stored.Email != email {
+		return port.ErrConditionFailed
+	}
+	stored.Password = ""
+	stored.EmailVerified = true
+	r.users[id] = stored
+	return nil
+}
+
+func (r *fakeRepository) AddUserScore(_ context.Context, user *model.User, points int) error {
+	if r.onAddUserScore != nil {
+		r.onAddUserScore()
+	}
+	stored, ok := r.users[user.Id]
+	if !ok {
+		return port.ErrConditionFailed
+	}
+	stored.TotalScore += points
+	stored.Elo = user.Elo
+	stored.Level = user.Level
+	r.users[user.Id] = stored
+	return nil
+}
+
+func (r *fakeRepository) CreateOrUpdateUserScore(_ context.Context, score *model.UserScore) error {
+	r.scores = append(r.scores, *score)
+	return nil
+}
+
+func (r *fakeRepository) CheckHealth(context.Context) []model.DependencyHealth {
+	r.healthChecks++
+	return r.tables
+}
+
+func (r *fakeRepository) GetAllUserScoreByTime(_ context.Context, createdAt time.Time) ([]model.UserScore, error) {
+	var scores []model.UserScore
+	for _, score := range r.scores {
+		if score.CreatedAt.After(createdAt) {
+			scores = append(scores, score)
+		}
+	}
+	return scores, nil
+}
+
+func (r *fakeRepository) GetAllUserScoreByTimeWithUserId(_ context.Context, userId string, createdAt time.Time) ([]model.UserScore, error) {
+	var scores []model.UserScore
+	for _, score := range r.scores {
+		if score.UserId == userId && score.CreatedAt.After(createdAt) {
+			scores = append(scores, score)
+		}
+	}
+	return scores, nil
+}
+
+func (r *fakeRepository) CreateOrUpdateCategory(_ context.Context, category *model.Category) error {
+	r.categories[category.Name] = *category
+	return nil
+}
+
+func (r *fakeRepository) GetCategories(context.Context) ([]model.Category, error) {
+	categories := make([]model.Category, 0, len(r.categories))
+	for _, category := range r.categories {
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+func (r *fakeRepository) DeleteCategory(_ context.Context, name string) error {
+	delete(r.categories, name)
+	return nil
+}
+
+func (r *fakeRepository) CreateActionToken(_ context.Context, token *model.ActionToken) error {
+	r.actionTokens[token.Id] = *token
+	return nil
+}
+
+func (r *fakeRepository) GetActionTokenById(_ context.Context, id string) (*model.ActionToken, error) {
+	token, ok := r.actionTokens[id]
+	if !ok {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+func (r *fakeRepository) ConsumeActionToken(_ context.Context, id string) error {
+	token, ok := r.actionTokens[id]
+	if !ok || token.Used {
+		return port.ErrConditionFailed
+	}
+	token.Used = true
+	r.actionTokens[id] = token
+	return nil
+}
+
+func (r *fakeRepository) GetLoginAttempt(_ context.Context, id string) (*model.LoginAttempt, error) {
+	attempt, ok := r.loginAttempts[id]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+// IncrementLoginFailures restarts the count once the window has passed, like the
+// conditional update in the DynamoDB repository.
+func (r *fakeRepository) IncrementLoginFailures(_ context.Context, id string, now time.Time, window time.Duration) (*model.LoginAttempt, error) {
+	attempt, ok := r.loginAttempts[id]
+	if !ok || !attempt.WindowStart.After(now.Add(-window)) {
+		attempt = model.LoginAttempt{Id: id, WindowStart: now}
+	}
+	attempt.Failures++
+	r.loginAttempts[id] = attempt
+	return &attempt, nil
+}
+
+func (r *fakeRepository) LockLoginAttempt(_ context.Context, id string, lockedUntil time.Time, _ time.Time) error {
+	attempt := r.loginAttempts[id]
+	attempt.LockedUntil = lockedUntil
+	r.loginAttempts[id] = attempt
+	return nil
+}
+
+func (r *fakeRepository) DeleteLoginAttempt(_ context.Context, id string) error {
+	delete(r.loginAttempts, id)
+	return nil
+}
+
+func (r *fakeRepository) GetUserIdentityById(_ context.Context, id string) (*model.UserIdentity, error) {
+	identity, ok := r.identities[id]
+	if !ok {
+		return nil, nil
+	}
+	return &identity, nil
+}
+
+func (r *fakeRepository) CreateOrUpdateUserIdentity(_ context.Context, identity *model.UserIdentity) error {
+	r.identities[identity.Id] = *identity
+	return nil
+}
+
+func (r *fakeRepository) CreateOrUpdateApiKey(_ context.Context, apiKey *model.ApiKey) error {
+	r.apiKeys[apiKey.Id] = *apiKey
+	return nil
+}
+
+func (r *fakeRepository) TouchApiKey(_ context.Context, id string, usedAt time.Time) error {
+	apiKey, ok := r.apiKeys[id]
+	if !ok || apiKey.Revoked {
+		return port.ErrConditionFailed
+	}
+	apiKey.LastUsedAt = &usedAt
+	r.apiKeys[id] = apiKey
+	return nil
+}
+
+func (r *fakeRepository) RevokeApiKeyById(_ context.Context, id string) error {
+	apiKey, ok := r.apiKeys[id]
+	if !ok {
+		return port.ErrConditionFailed
+	}
+	apiKey.Revoked = true
+	r.apiKeys[id] = apiKey
+	return nil
+}
+
+func (r *fakeRepository) GetApiKeyById(_ context.Context, id string) (*model.ApiKey, error) {
+	apiKey, ok := r.apiKeys[id]
+	if !ok {
+		return nil, nil
+	}
+	if r.onGetApiKey != nil {
+		r.onGetApiKey(id)
+	}
+	return &apiKey, nil
+}
+
+func (r *fakeRepository) GetApiKeysByUserId(_ context.Context, userId string) ([]model.ApiKey, error) {
+	var apiKeys []model.ApiKey
+	for _, apiKey := range r.apiKeys {
+		if apiKey.UserId == userId {
+			apiKeys = append(apiKeys, apiKey)
+		}
+	}
+	return apiKeys, nil
+}
+
+// errCode is the code of a domain error, empty for nil or untyped errors.
+func errCode(err error) string {
+	if e, ok := errs.As(err); ok {
+		return e.Code
+	}
+	return ""
+}
+
+type fakeStorage struct {
+	buckets []model.DependencyHealth
+}
+
+func (s fakeStorage) CheckHealth(context.Context) []model.DependencyHealth {
+	return s.buckets
+}
+
+func (fakeStorage) UploadUserPicture(_ context.Context, name string, _ io.Reader) (string, error) {
+	return fmt.Sprintf("https://pictures/%s", name), nil
+}
+
+func (fakeStorage) UploadPromotionImage(_ context.Context, name string, _ io.Reader) (string, error) {
+	return fmt.Sprintf("https://images/%s", name), nil
+}
+
+type fakeMailer struct {
+	sent []model.Email
+}
+
+func (m *fakeMailer) Send(_ context.Context, email *model.Email) error {
+	m.sent = append(m.sent, *email)
+	return nil
+}
+
+// fakeMetrics records domain events as "event:label" strings.
+type fakeMetrics struct {
+	events []string
+	points int
+}
+
+func (m *fakeMetrics) PromotionCreated() {
+	m.events = append(m.events, "promotion_created")
+}
+
+func (m *fakeMetrics) PromotionStatusChanged(from model.PromotionStatus, to model.PromotionStatus) {
+	m.events = append(m.events, "promotion_status_changed:"+string(from)+":"+string(to))
+}
+
+func (m *fakeMetrics) InteractionCreated(interactionType model.InteractionType) {
+	m.events = append(m.events, "interaction_created:"+interactionType.String())
+}
+
+func (m *fakeMetrics) InteractionRemoved(interactionType model.InteractionType) {
+	m.events = append(m.events, "interaction_removed:"+interactionType.String())
+}
+
+func (m *fakeMetrics) LoginSucceeded(method string) {
+	m.events = append(m.events, "login_succeeded:"+method)
+}
+
+func (m *fakeMetrics) LoginFailed(method string, reason string) {
+	m.events = append(m.events, "login_failed:"+method+":"+reason)
+}
+
+func (m *fakeMetrics) ScorePointsGranted(points int) {
+	m.points += points
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...config.Field)      {}
+func (nopLogger) Info(string, ...config.Field)       {}
+func (nopLogger) Warn(string, ...config.Field)       {}
+func (nopLogger) Error(string, ...config.Field)      {}
+func (nopLogger) Panic(string, ...config.Field)      {}
+func (nopLogger) Fatal(string, ...config.Field)      {}
+func (nopLogger) With(...config.Field) config.Logger { return nopLogger{} }
+func (nopLogger) Flush() error                       { return nil }
+
+var scoreSettings = map[string]any{
+	"service.score.level.minimalPointsLevel":          25,
+	"service.score.level.growthRate":                  1.5,
+	"service.score.elo.timeRangeInDays":               7,
+	"service.score.elo.levels.silver.minimal-score":   50,
+	"service.score.elo.levels.gold.minimal-score":     100,
+	"service.score.elo.levels.platinum.minimal-score": 200,
+	"service.score.elo.levels.diamond.minimal-score":  500,
+	"service.score.interactions.favorite":             10,
+	"service.score.interactions.like":                 5,
+	"service.score.interactions.comment":              10,
+	"service.score.interactions.create":               25,
+}
+
+func newTestService(rp port.Repository, settings map[string]any) *service {
+	v := viper.New()
+	for key, value := range settings {
+		v.Set(key, value)
+	}
+
+	return &service{
+		rp:                rp,
+		cfg:               &config.Config{Viper: v, Env: config.Local},
+		st:                fakeStorage{},
+		ml:                &fakeMailer{},
+		mt:                &fakeMetrics{},
+		log:               nopLogger{},
+		actionTokenSecret: []byte("action-token-secret"),
+		health:            &healthCache{},
+		dummyHash:         &dummyPasswordHash{},
+	}
+}
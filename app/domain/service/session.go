@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"pixelPromo/config"
+	"pixelPromo/domain/errs"
+	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
+	"sort"
+	"strings"
+	"time"
+)
+
+func (s *service) CreateSession(ctx context.Context, user *model.User, client *model.SessionClient) (string, error) {
+	familyId, err := randomId()
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return "", err
+	}
+
+	refreshToken, err := s.issueRefreshToken(ctx, user.Id, familyId, client, time.Now())
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return "", err
+	}
+
+	s.logger(ctx).Debug("session created")
+	return refreshToken, nil
+}
+
+func (s *service) RefreshSession(ctx context.Context, refreshToken string, client *model.SessionClient) (*model.User, string, error) {
+	session, err := s.getSessionByRefreshToken(ctx, refreshToken)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, "", err
+	}
+
+	if session == nil || session.Revoked || !time.Now().Before(session.ExpiresAt) {
+		return nil, "", errs.Unauthenticated("invalid_refresh_token", "invalid refresh token")
+	}
+
+	if session.Rotated {
+		return nil, "", s.revokeReusedFamily(ctx, session)
+	}
+
+	err = s.rp.RotateSession(ctx, session.Id, time.Now())
+	if errors.Is(err, port.ErrConditionFailed) {
+		return nil, "", s.revokeReusedFamily(ctx, session)
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, "", err
+	}
+
+	user, err := s.rp.GetUserById(ctx, session.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", errs.Unauthenticated("invalid_refresh_token", "invalid refresh token")
+	}
+
+	newRefreshToken, err := s.issueRefreshToken(ctx, user.Id, session.FamilyId, client, session.CreatedAt)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, "", err
+	}
+
+	// A family revoked while the new token was being issued never saw the new
+	// session, so revoke it here instead of handing out a live token.
+	current, err := s.rp.GetSessionById(ctx, session.Id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, "", err
+	}
+	if current == nil || current.Revoked {
+		newSessionId, _, _ := strings.Cut(newRefreshToken, ".")
+		if err = s.rp.RevokeSessionById(ctx, newSessionId); err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, "", err
+		}
+		return nil, "", errs.Unauthenticated("invalid_refresh_token", "invalid refresh token")
+	}
+
+	s.logger(ctx).Debug("session refreshed")
+	return user, newRefreshToken, nil
+}
+
+func (s *service) RevokeSession(ctx context.Context, refreshToken string) error {
+	session, err := s.getSessionByRefreshToken(ctx, refreshToken)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if session == nil {
+		return nil
+	}
+
+	if err = s.revokeFamily(ctx, session.FamilyId); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("session revoked")
+	return nil
+}
+
+func (s *service) RevokeUserSession(ctx context.Context, familyId string) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.rp.GetSessionsByFamilyId(ctx, familyId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if len(sessions) == 0 || sessions[0].UserId != actor.UserId {
+		return errs.NotFound("session")
+	}
+
+	if err = s.revokeFamily(ctx, familyId); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("session revoked")
+	return nil
+}
+
+func (s *service) RevokeAllSessions(ctx context.Context) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := s.rp.GetSessionsByUserId(ctx, actor.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = s.revokeSessions(ctx, sessions); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("all sessions revoked")
+	return nil
+}
+
+func (s *service) GetActiveSessions(ctx context.Context) ([]model.Session, error) {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.rp.GetSessionsByUserId(ctx, actor.UserId)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	now := time.Now()
+	active := make([]model.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.IsActive(now) {
+			active = append(active, session)
+		}
+	}
+
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].LastUsedAt.After(active[j].LastUsedAt)
+	})
+
+	return active, nil
+}
+
+func (s *service) issueRefreshToken(ctx context.Context, userId string, familyId string, client *model.SessionClient, createdAt time.Time) (string, error) {
+	id, err := randomId()
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.cfg.Viper.GetDuration("service.auth.refresh-token.ttl"))
+	session := model.Session{
+		Id:         id,
+		FamilyId:   familyId,
+		UserId:     userId,
+		TokenHash:  hashToken(secret),
+		CreatedAt:  createdAt,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+		Ttl:        expiresAt.Unix(),
+	}
+	if client != nil {
+		session.Device = client.Device
+		session.Ip = client.Ip
+	}
+
+	if err = s.rp.CreateOrUpdateSession(ctx, &session); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s.%s", id, secret), nil
+}
+
+func (s *service) getSessionByRefreshToken(ctx context.Context, refreshToken string) (*model.Session, error) {
+	id, secret, found := strings.Cut(refreshToken, ".")
+	if !found || id == "" || secret == "" {
+		return nil, nil
+	}
+
+	session, err := s.rp.GetSessionById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if session == nil || subtle.ConstantTimeCompare([]byte(session.TokenHash), []byte(hashToken(secret))) != 1 {
+		return nil, nil
+	}
+
+	return session, nil
+}
+
+func (s *service) revokeReusedFamily(ctx context.Context, session *model.Session) error {
+	s.logger(ctx).Warn("refresh token reused, revoking session family",
+		config.F("familyId", session.FamilyId),
+		config.F("userId", session.UserId),
+	)
+	if err := s.revokeFamily(ctx, session.FamilyId); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+	return errs.Unauthenticated("refresh_token_reused", "refresh token reused")
+}
+
+func (s *service) revokeFamily(ctx context.Context, familyId string) error {
+	sessions, err := s.rp.GetSessionsByFamilyId(ctx, familyId)
+	if err != nil {
+		return err
+	}
+
+	return s.revokeSessions(ctx, sessions)
+}
+
+func (s *service) revokeSessions(ctx context.Context, sessions []model.Session) error {
+	for _, session := range sessions {
+		if session.Revoked {
+			continue
+		}
+		err := s.rp.RevokeSessionById(ctx, session.Id)
+		if err != nil && !errors.Is(err, port.ErrConditionFailed) {
+			return err
+		}
+	}
+	return nil
+}
+
+func randomId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"pixelPromo/domain/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+var sessionSettings = map[string]any{"service.auth.refresh-token.ttl": "1h"}
+
+func TestRefreshSession(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare changes the stored session before the refresh
+		prepare func(rp *fakeRepository, session *model.Session)
+		token   func(token string) string
+		err     string
+		// familyRevoked expects every session of the family to end up revoked
+		familyRevoked bool
+	}{
+		{
+			name: "rotates the session",
+		},
+		{
+			name: "rejects a wrong secret",
+			token: func(token string) string {
+				id, _, _ := strings.Cut(token, ".")
+				return id + ".wrong"
+			},
+			err: "invalid_refresh_token",
+		},
+		{
+			name:  "rejects a malformed token",
+			token: func(string) string { return "malformed" },
+			err:   "invalid_refresh_token",
+		},
+		{
+			name: "rejects an expired session",
+			prepare: func(_ *fakeRepository, session *model.Session) {
+				session.ExpiresAt = time.Now().Add(-time.Minute)
+			},
+			err: "invalid_refresh_token",
+		},
+		{
+			name: "rejects a revoked session",
+			prepare: func(_ *fakeRepository, session *model.Session) {
+				session.Revoked = true
+			},
+			err: "invalid_refresh_token",
+		},
+		{
+			name: "revokes the family when a rotated token is reused",
+			prepare: func(_ *fakeRepository, session *model.Session) {
+				session.Rotated = true
+			},
+			err:           "refresh_token_reused",
+			familyRevoked: true,
+		},
+		{
+			name: "revokes the new session when the family is revoked meanwhile",
+			prepare: func(rp *fakeRepository, session *model.Session) {
+				rp.onCreateSession = func(*model.Session) {
+					revoked := rp.sessions[session.Id]
+					revoked.Revoked = true
+					rp.sessions[session.Id] = revoked
+				}
+			},
+			err:           "invalid_refresh_token",
+			familyRevoked: true,
+		},
+		{
+			name: "rejects sessions of deleted users",
+			prepare: func(rp *fakeRepository, _ *model.Session) {
+				delete(rp.users, "user-1")
+			},
+			err: "invalid_refresh_token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1"}
+			s := newTestService(rp, sessionSettings)
+			ctx := context.Background()
+
+			token, err := s.CreateSession(ctx, &model.User{Id: "user-1"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, _, _ := strings.Cut(token, ".")
+			session := rp.sessions[id]
+
+			if tt.prepare != nil {
+				tt.prepare(rp, &session)
+				rp.sessions[id] = session
+			}
+			if tt.token != nil {
+				token = tt.token(token)
+			}
+
+			user, newToken, err := s.RefreshSession(ctx, token, nil)
+			if tt.err != "" {
+				if errCode(err) != tt.err {
+					t.Fatalf("error = %v, want %s", err, tt.err)
+				}
+			} else {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if user == nil || user.Id != "user-1" || newToken == "" || newToken == token {
+					t.Fatalf("refresh = %v, %q", user, newToken)
+				}
+				if !rp.sessions[id].Rotated {
+					t.Fatal("old session was not rotated")
+				}
+			}
+
+			if tt.familyRevoked {
+				for _, stored := range rp.sessions {
+					if stored.FamilyId == session.FamilyId && !stored.Revoked {
+						t.Fatalf("session %s of the family is still active", stored.Id)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRefreshSessionReuseAfterRotation(t *testing.T) {
+	rp := newFakeRepository()
+	rp.users["user-1"] = model.User{Id: "user-1"}
+	s := newTestService(rp, sessionSettings)
+	ctx := context.Background()
+
+	first, err := s.CreateSession(ctx, &model.User{Id: "user-1"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, err := s.RefreshSession(ctx, first, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = s.RefreshSession(ctx, first, nil)
+	if errCode(err) != "refresh_token_reused" {
+		t.Fatalf("reuse error = %v", err)
+	}
+
+	_, _, err = s.RefreshSession(ctx, second, nil)
+	if errCode(err) != "invalid_refresh_token" {
+		t.Fatalf("refresh after reuse error = %v", err)
+	}
+	if len(rp.sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(rp.sessions))
+	}
+}
+
+func TestRevokeUserSession(t *testing.T) {
+	tests := []struct {
+		name   string
+		userId string
+		family bool
+		code   string
+	}{
+		{name: "own session", userId: "user-1", family: true},
+		{name: "session of another user", userId: "user-2", family: true, code: "session_not_found"},
+		{name: "unknown family", userId: "user-1", code: "session_not_found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			s := newTestService(rp, sessionSettings)
+			ctx := context.Background()
+
+			token, err := s.CreateSession(ctx, &model.User{Id: "user-1"}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, _, _ := strings.Cut(token, ".")
+			familyId := "unknown"
+			if tt.family {
+				familyId = rp.sessions[id].FamilyId
+			}
+
+			err = s.RevokeUserSession(model.WithActor(ctx, &model.Actor{UserId: tt.userId}), familyId)
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+			if rp.sessions[id].Revoked != (tt.code == "") {
+				t.Fatalf("revoked = %v", rp.sessions[id].Revoked)
+			}
+		})
+	}
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	rp := newFakeRepository()
+	s := newTestService(rp, sessionSettings)
+	ctx := context.Background()
+
+	for _, userId := range []string{"user-1", "user-1", "user-2"} {
+		if _, err := s.CreateSession(ctx, &model.User{Id: userId}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.RevokeAllSessions(model.WithActor(ctx, &model.Actor{UserId: "user-1"})); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, userId := range []string{"user-1", "user-2"} {
+		active, err := s.GetActiveSessions(model.WithActor(ctx, &model.Actor{UserId: userId}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]int{"user-1": 0, "user-2": 1}[userId]; len(active) != want {
+			t.Fatalf("%s has %d active sessions, want %d", userId, len(active), want)
+		}
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"pixelPromo/domain/errs"
 	"pixelPromo/domain/model"
+	"pixelPromo/domain/port"
 	"regexp"
 	"sort"
 	"strings"
@@ -13,121 +15,304 @@ import (
 )
 
 func (s *service) CreateUser(ctx context.Context, user *model.User) error {
+	if user != nil {
+		user.Email = model.NormalizeEmail(user.Email)
+	}
 
-	err := s.validUser(user)
+	err := s.validUser(user, true)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	user.CreatedAt = time.Now()
 	user.Id = fmt.Sprintf("%d", user.CreatedAt.UnixNano())
+	user.Role = model.RoleUser
+	user.EmailVerified = false
 
-	if err = s.rp.CreateOrUpdateUser(ctx, user); err != nil {
-		s.log.Error(err.Error())
+	user.Password, err = s.hashPassword(user.Password)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	err = s.rp.CreateUser(ctx, user)
+	if errors.Is(err, port.ErrConditionFailed) {
+		err = errs.Conflict("email_taken", "email already in use")
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("user created")
+	if err = s.sendVerificationEmail(ctx, user); err != nil {
+		s.logger(ctx).Error(err.Error())
+	}
+
+	s.logger(ctx).Debug("user created")
 	return nil
 }
 
 func (s *service) UpdateUser(ctx context.Context, user *model.User) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if user != nil {
+		user.Email = model.NormalizeEmail(user.Email)
+	}
 
-	err := s.validUser(user)
+	err = s.validUser(user, false)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	if user.Id == "" {
-		err = errors.New("user id is empty")
-		s.log.Error(err.Error())
+		user.Id = actor.UserId
+	}
+
+	if err = requireOwner(actor, user.Id); err != nil {
+		s.logger(ctx).Warn(err.Error())
 		return err
 	}
 
-	if err = s.rp.CreateOrUpdateUser(ctx, user); err != nil {
-		s.log.Error(err.Error())
+	currentUser, err := s.rp.GetUserById(ctx, user.Id)
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if currentUser == nil {
+		err = errs.NotFound("user")
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("user created")
+	user.PictureUrl = currentUser.PictureUrl
+	user.TotalScore = currentUser.TotalScore
+	user.Level = currentUser.Level
+	user.Elo = currentUser.Elo
+	user.Role = currentUser.Role
+	user.EmailVerified = currentUser.EmailVerified && currentUser.Email == user.Email
+	user.CreatedAt = currentUser.CreatedAt
+
+	passwordChanged := false
+	if user.Password == "" {
+		user.Password = currentUser.Password
+	} else {
+		unchanged, _, err := s.checkPassword(currentUser.Password, user.Password)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+
+		if unchanged {
+			user.Password = currentUser.Password
+		} else {
+			user.Password, err = s.hashPassword(user.Password)
+			if err != nil {
+				s.logger(ctx).Error(err.Error())
+				return err
+			}
+			passwordChanged = true
+		}
+	}
+
+	emailChanged := currentUser.Email != user.Email
+	if emailChanged {
+		err = s.rp.UpdateUserEmail(ctx, user, currentUser.Email)
+		if errors.Is(err, port.ErrConditionFailed) {
+			err = errs.Conflict("email_taken", "email already in use")
+			s.logger(ctx).Warn(err.Error())
+			return err
+		}
+	} else {
+		err = s.rp.CreateOrUpdateUser(ctx, user)
+	}
+	if err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	// a new password must log out every device that knew the old one
+	if passwordChanged {
+		if err = s.RevokeAllSessions(model.WithActor(ctx, &model.Actor{UserId: user.Id})); err != nil {
+			s.logger(ctx).Error(err.Error())
+			return err
+		}
+	}
+
+	if emailChanged {
+		if err = s.sendVerificationEmail(ctx, user); err != nil {
+			s.logger(ctx).Error(err.Error())
+		}
+	}
+
+	s.logger(ctx).Debug("user updated")
 	return nil
 }
 
 func (s *service) DeleteUser(ctx context.Context, id string) error {
-	if err := s.rp.DeleteUser(ctx, id); err != nil {
-		s.log.Error(err.Error())
+	actor, err := actorFromContext(ctx)
+	if err != nil {
 		return err
 	}
 
-	s.log.Debug("promotion deleted")
+	if err = requireOwner(actor, id, model.RoleAdmin); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
+
+	if err = s.revokeUserApiKeys(ctx, id); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	if err = s.rp.DeleteUser(ctx, id); err != nil {
+		s.logger(ctx).Error(err.Error())
+		return err
+	}
+
+	s.logger(ctx).Debug("user deleted")
 
 	return nil
 }
 
 func (s *service) UpdateUserPicture(ctx context.Context, id string, image io.Reader) error {
+	actor, err := actorFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = requireOwner(actor, id); err != nil {
+		s.logger(ctx).Warn(err.Error())
+		return err
+	}
 
 	user, err := s.rp.GetUserById(ctx, id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	if user == nil {
-		err = errors.New("user not found")
-		s.log.Error(err.Error())
+		err = errs.NotFound("user")
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	url, err := s.st.UploadUserPicture(ctx, fmt.Sprintf("%s.jpg", id), image)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
 	user.PictureUrl = url
 
 	if err = s.rp.CreateOrUpdateUser(ctx, user); err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return err
 	}
 
-	s.log.Debug("picture uploaded")
+	s.logger(ctx).Debug("picture uploaded")
 	return nil
 }
 
-func (s *service) Login(ctx context.Context, login *model.Login) (*model.User, error) {
+func (s *service) Login(ctx context.Context, login *model.Login, client *model.SessionClient) (*model.User, error) {
+	if login != nil {
+		login.Email = model.NormalizeEmail(login.Email)
+	}
+
 	err := s.validLogin(login)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
-	user, err := s.rp.GetUserByEmailAndPassword(ctx, login.Email, login.Password)
+
+	lockoutKeys := loginLockoutKeys(login.Email, client)
+	if err = s.checkLoginLockout(ctx, lockoutKeys); err != nil {
+		if errs.KindOf(err) == errs.KindTooManyRequests {
+			s.mt.LoginFailed("password", "locked_out")
+		}
+		s.logger(ctx).Warn(err.Error())
+		return nil, err
+	}
+
+	user, err := s.rp.GetUserByEmail(ctx, login.Email)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
+		return nil, err
+	}
+
+	match := false
+	rehash := false
+	if user != nil {
+		match, rehash, err = s.checkPassword(user.Password, login.Password)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+	} else {
+		s.burnPasswordCheck(login.Password)
+	}
+
+	if !match {
+		if err = s.registerLoginFailure(ctx, lockoutKeys, client); err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+		s.mt.LoginFailed("password", "invalid_credentials")
+		return nil, nil
+	}
+
+	if err = s.resetLoginFailures(ctx, login.Email); err != nil {
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
+	if rehash {
+		user.Password, err = s.hashPassword(login.Password)
+		if err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+
+		if err = s.rp.CreateOrUpdateUser(ctx, user); err != nil {
+			s.logger(ctx).Error(err.Error())
+			return nil, err
+		}
+		s.logger(ctx).Debug("user password rehashed")
+	}
+
+	s.mt.LoginSucceeded("password")
 	return user, nil
 }
 
 func (s *service) GetUserById(ctx context.Context, id string) (*model.User, error) {
 	user, err := s.rp.GetUserById(ctx, id)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *service) GetUserRank(ctx context.Context, limit int) ([]model.User, error) {
+func (s *service) GetUserRank(ctx context.Context, page *model.PageRequest) (*model.Page[model.User], error) {
+	limit := int(s.pageLimit(page.Limit))
+	offset, err := decodeOffsetCursor(page.Cursor)
+	if err != nil {
+		return nil, err
+	}
 
 	initDate := time.Now().Add((24 * 7 * time.Hour) * -1)
 	scoreList, err := s.rp.GetAllUserScoreByTime(ctx, initDate)
 	if err != nil {
-		s.log.Error(err.Error())
+		s.logger(ctx).Error(err.Error())
 		return nil, err
 	}
 
@@ -142,57 +327,74 @@ func (s *service) GetUserRank(ctx context.Context, limit int) ([]model.User, err
 		keys = append(keys, key)
 	}
 	sort.SliceStable(keys, func(i, j int) bool {
+		if usersRank[keys[i]] == usersRank[keys[j]] {
+			return keys[i] < keys[j]
+		}
 		return usersRank[keys[i]] > usersRank[keys[j]]
 	})
 
-	users := make([]model.User, 0, limit)
-	for i, k := range keys {
-		if i == limit {
+	rank := &model.Page[model.User]{Items: make([]model.User, 0, limit)}
+	for i := offset; i < len(keys); i++ {
+		if len(rank.Items) == limit {
+			rank.NextCursor = encodeOffsetCursor(i)
 			break
 		}
-		user, err := s.rp.GetUserById(ctx, k)
+		user, err := s.rp.GetUserById(ctx, keys[i])
 		if err != nil {
-			s.log.Error(err.Error())
+			s.logger(ctx).Error(err.Error())
 			return nil, err
 		}
-		user.TotalScore = usersRank[k]
-		users = append(users, *user)
+		if user == nil {
+			continue
+		}
+		user.TotalScore = usersRank[keys[i]]
+		rank.Items = append(rank.Items, *user)
 	}
 
-	return users, nil
+	return rank, nil
 }
 
-func (s *service) validUser(user *model.User) error {
+func (s *service) validUser(user *model.User, passwordRequired bool) error {
 	if user == nil {
-		return errors.New("user is nil")
+		return errs.Invalid("empty_body", "user is empty")
 	}
+
+	var fields []errs.FieldError
 	if len(strings.TrimSpace(user.Email)) == 0 {
-		return errors.New("email is empty")
+		fields = append(fields, errs.Required("email"))
+	} else if !isEmailValid(user.Email) {
+		fields = append(fields, errs.Field("email", "invalid_format", "email is invalid"))
 	}
 	if len(strings.TrimSpace(user.Name)) == 0 {
-		return errors.New("name is empty")
+		fields = append(fields, errs.Required("name"))
 	}
-	if len(strings.TrimSpace(user.Password)) == 0 {
-		return errors.New("password is empty")
+	if passwordRequired && len(strings.TrimSpace(user.Password)) == 0 {
+		fields = append(fields, errs.Required("password"))
 	}
-	if !isEmailValid(user.Email) {
-		return errors.New("user email is invalid")
+
+	if len(fields) > 0 {
+		return errs.Validation("user is invalid", fields...)
 	}
 	return nil
 }
 
 func (s *service) validLogin(login *model.Login) error {
 	if login == nil {
-		return errors.New("login is nil")
+		return errs.Invalid("empty_body", "login is empty")
 	}
+
+	var fields []errs.FieldError
 	if len(strings.TrimSpace(login.Email)) == 0 {
-		return errors.New("email is empty")
+		fields = append(fields, errs.Required("email"))
+	} else if !isEmailValid(login.Email) {
+		fields = append(fields, errs.Field("email", "invalid_format", "email is invalid"))
 	}
 	if len(strings.TrimSpace(login.Password)) == 0 {
-		return errors.New("password is empty")
+		fields = append(fields, errs.Required("password"))
 	}
-	if !isEmailValid(login.Email) {
-		return errors.New("user email is invalid")
+
+	if len(fields) > 0 {
+		return errs.Validation("login is invalid", fields...)
 	}
 	return nil
 }
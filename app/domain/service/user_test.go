@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"golang.org/x/crypto/bcrypt"
+	"pixelPromo/domain/model"
+	"testing"
+)
+
+func TestCreateUserEmailUniqueness(t *testing.T) {
+	existing := model.User{Id: "user-1", Name: "user", Email: "user@mail.com", Role: model.RoleUser}
+
+	tests := []struct {
+		name  string
+		email string
+		want  string
+		code  string
+	}{
+		{name: "free email", email: "new@mail.com", want: "new@mail.com"},
+		{name: "free email is normalized", email: " New@Mail.com ", want: "new@mail.com"},
+		{name: "taken email", email: "user@mail.com", code: "email_taken"},
+		{name: "taken email in another case", email: "USER@mail.com", code: "email_taken"},
+		{name: "taken email with spaces", email: " user@mail.com ", code: "email_taken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users[existing.Id] = existing
+			s := newTestService(rp, accountSettings)
+
+			user := &model.User{Name: "new", Email: tt.email, Password: "secret"}
+			err := s.CreateUser(context.Background(), user)
+			if tt.code != "" {
+				if errCode(err) != tt.code {
+					t.Fatalf("error = %v, want %s", err, tt.code)
+				}
+				if len(rp.users) != 1 || len(s.ml.(*fakeMailer).sent) != 0 {
+					t.Fatalf("users = %d, mails = %d after a rejected signup", len(rp.users), len(s.ml.(*fakeMailer).sent))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rp.users[user.Id].Email != tt.want {
+				t.Fatalf("stored email = %q, want %q", rp.users[user.Id].Email, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateUserEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		want     string
+		verified bool
+		mailed   bool
+		code     string
+	}{
+		{name: "same email", email: "user@mail.com", want: "user@mail.com", verified: true},
+		{name: "same email in another case", email: "User@Mail.com", want: "user@mail.com", verified: true},
+		{name: "new email needs verification", email: "new@mail.com", want: "new@mail.com", mailed: true},
+		{name: "email of another user", email: "OTHER@mail.com", want: "user@mail.com", verified: true, code: "email_taken"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Name: "user", Email: "user@mail.com", Role: model.RoleUser, EmailVerified: true}
+			rp.users["user-2"] = model.User{Id: "user-2", Name: "other", Email: "other@mail.com", Role: model.RoleUser}
+			s := newTestService(rp, accountSettings)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1", Role: model.RoleUser})
+			err := s.UpdateUser(ctx, &model.User{Name: "renamed", Email: tt.email, Password: "secret"})
+			if errCode(err) != tt.code {
+				t.Fatalf("error = %v, want %s", err, tt.code)
+			}
+
+			stored := rp.users["user-1"]
+			if stored.Email != tt.want || stored.EmailVerified != tt.verified {
+				t.Fatalf("stored user = %+v", stored)
+			}
+			if mailed := len(s.ml.(*fakeMailer).sent) != 0; mailed != tt.mailed {
+				t.Fatalf("verification mailed = %v, want %v", mailed, tt.mailed)
+			}
+		})
+	}
+}
+
+func TestUpdateUserPassword(t *testing.T) {
+	current := mustHash(t, "old-secret", bcrypt.MinCost+1)
+
+	tests := []struct {
+		name     string
+		password string
+		// changed expects a new hash and every session revoked
+		changed bool
+	}{
+		{name: "omitted keeps the password", password: ""},
+		{name: "same password keeps the sessions", password: "old-secret"},
+		{name: "new password revokes the sessions", password: "new-secret", changed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newFakeRepository()
+			rp.users["user-1"] = model.User{Id: "user-1", Email: "user@mail.com", Password: current}
+			rp.sessions["session-1"] = model.Session{Id: "session-1", FamilyId: "session-1", UserId: "user-1"}
+			s := newTestService(rp, passwordSettings)
+
+			ctx := model.WithActor(context.Background(), &model.Actor{UserId: "user-1"})
+			if err := s.UpdateUser(ctx, &model.User{Name: "renamed", Email: "user@mail.com", Password: tt.password}); err != nil {
+				t.Fatal(err)
+			}
+
+			stored := rp.users["user-1"]
+			if changed := stored.Password != current; changed != tt.changed {
+				t.Fatalf("password changed = %v, want %v", changed, tt.changed)
+			}
+			if match, _, _ := s.checkPassword(stored.Password, "new-secret"); match != tt.changed {
+				t.Fatalf("new password accepted = %v, want %v", match, tt.changed)
+			}
+			if revoked := rp.sessions["session-1"].Revoked; revoked != tt.changed {
+				t.Fatalf("session revoked = %v, want %v", revoked, tt.changed)
+			}
+		})
+	}
+}
@@ -24,6 +24,14 @@ aws dynamodb create-table \
     --attribute-definitions \
         AttributeName=id,AttributeType=S \
         AttributeName=createdAt,AttributeType=S \
+        AttributeName=feed,AttributeType=S \
+        AttributeName=sortNewest,AttributeType=S \
+        AttributeName=sortDiscount,AttributeType=S \
+        AttributeName=sortPrice,AttributeType=S \
+        AttributeName=sortPopular,AttributeType=S \
+        AttributeName=sortHot,AttributeType=S \
+        AttributeName=status,AttributeType=S \
+        AttributeName=expiresAt,AttributeType=S \
     --key-schema \
         AttributeName=id,KeyType=HASH \
     --billing-mode PAY_PER_REQUEST \
@@ -31,15 +39,45 @@ aws dynamodb create-table \
         "IndexName": "CreatedAtIndex",
         "KeySchema": [{"AttributeName": "createdAt", "KeyType": "HASH"}],
         "Projection": {"ProjectionType": "ALL"}
+    }, {
+        "IndexName": "FeedNewestIndex",
+        "KeySchema": [{"AttributeName": "feed", "KeyType": "HASH"}, {"AttributeName": "sortNewest", "KeyType": "RANGE"}],
+        "Projection": {"ProjectionType": "ALL"}
+    }, {
+        "IndexName": "FeedDiscountIndex",
+        "KeySchema": [{"AttributeName": "feed", "KeyType": "HASH"}, {"AttributeName": "sortDiscount", "KeyType": "RANGE"}],
+        "Projection": {"ProjectionType": "ALL"}
+    }, {
+        "IndexName": "FeedPriceIndex",
+        "KeySchema": [{"AttributeName": "feed", "KeyType": "HASH"}, {"AttributeName": "sortPrice", "KeyType": "RANGE"}],
+        "Projection": {"ProjectionType": "ALL"}
+    }, {
+        "IndexName": "FeedPopularIndex",
+        "KeySchema": [{"AttributeName": "feed", "KeyType": "HASH"}, {"AttributeName": "sortPopular", "KeyType": "RANGE"}],
+        "Projection": {"ProjectionType": "ALL"}
+    }, {
+        "IndexName": "FeedHotIndex",
+        "KeySchema": [{"AttributeName": "feed", "KeyType": "HASH"}, {"AttributeName": "sortHot", "KeyType": "RANGE"}],
+        "Projection": {"ProjectionType": "ALL"}
+    }, {
+        "IndexName": "StatusExpiresIndex",
+        "KeySchema": [{"AttributeName": "status", "KeyType": "HASH"}, {"AttributeName": "expiresAt", "KeyType": "RANGE"}],
+        "Projection": {"ProjectionType": "ALL"}
     }]' \
     --profile=eduardo-admin --region=us-east-1 > /dev/null
 
+aws dynamodb update-time-to-live \
+    --table-name pp-promotion-catalog \
+    --time-to-live-specification "Enabled=true, AttributeName=ttl" \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
 # Criando a tabela pp-promotion-interaction
 aws dynamodb create-table \
     --table-name pp-promotion-interaction \
     --attribute-definitions \
         AttributeName=id,AttributeType=S \
         AttributeName=createdAt,AttributeType=S \
+        AttributeName=promotionId,AttributeType=S \
     --key-schema \
         AttributeName=id,KeyType=HASH \
     --billing-mode PAY_PER_REQUEST \
@@ -47,6 +85,10 @@ aws dynamodb create-table \
         "IndexName": "CreatedAtIndex",
         "KeySchema": [{"AttributeName": "createdAt", "KeyType": "HASH"}],
         "Projection": {"ProjectionType": "ALL"}
+    }, {
+        "IndexName": "PromotionIdIndex",
+        "KeySchema": [{"AttributeName": "promotionId", "KeyType": "HASH"}],
+        "Projection": {"ProjectionType": "ALL"}
     }]' \
     --profile=eduardo-admin --region=us-east-1 > /dev/null
 
@@ -76,6 +118,113 @@ aws dynamodb create-table \
     }]' \
     --profile=eduardo-admin --region=us-east-1 > /dev/null
 
+# Criando a tabela pp-user-session
+aws dynamodb create-table \
+    --table-name pp-user-session \
+    --attribute-definitions \
+        AttributeName=id,AttributeType=S \
+        AttributeName=userId,AttributeType=S \
+        AttributeName=familyId,AttributeType=S \
+    --key-schema \
+        AttributeName=id,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --global-secondary-indexes '[{
+        "IndexName": "UserIdIndex",
+        "KeySchema": [{"AttributeName": "userId", "KeyType": "HASH"}],
+        "Projection": {"ProjectionType": "ALL"}
+    },{
+        "IndexName": "FamilyIdIndex",
+        "KeySchema": [{"AttributeName": "familyId", "KeyType": "HASH"}],
+        "Projection": {"ProjectionType": "ALL"}
+    }]' \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+aws dynamodb update-time-to-live \
+    --table-name pp-user-session \
+    --time-to-live-specification "Enabled=true, AttributeName=ttl" \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+# Criando a tabela pp-user-action-token
+aws dynamodb create-table \
+    --table-name pp-user-action-token \
+    --attribute-definitions \
+        AttributeName=id,AttributeType=S \
+    --key-schema \
+        AttributeName=id,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+aws dynamodb update-time-to-live \
+    --table-name pp-user-action-token \
+    --time-to-live-specification "Enabled=true, AttributeName=ttl" \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+# Criando a tabela pp-login-attempt
+aws dynamodb create-table \
+    --table-name pp-login-attempt \
+    --attribute-definitions \
+        AttributeName=id,AttributeType=S \
+    --key-schema \
+        AttributeName=id,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+aws dynamodb update-time-to-live \
+    --table-name pp-login-attempt \
+    --time-to-live-specification "Enabled=true, AttributeName=ttl" \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+# Criando a tabela pp-user-identity
+aws dynamodb create-table \
+    --table-name pp-user-identity \
+    --attribute-definitions \
+        AttributeName=id,AttributeType=S \
+    --key-schema \
+        AttributeName=id,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+# Criando a tabela pp-user-api-key
+aws dynamodb create-table \
+    --table-name pp-user-api-key \
+    --attribute-definitions \
+        AttributeName=id,AttributeType=S \
+        AttributeName=userId,AttributeType=S \
+    --key-schema \
+        AttributeName=id,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --global-secondary-indexes '[{
+        "IndexName": "UserIdIndex",
+        "KeySchema": [{"AttributeName": "userId", "KeyType": "HASH"}],
+        "Projection": {"ProjectionType": "ALL"}
+    }]' \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+# Criando a tabela pp-user-email
+aws dynamodb create-table \
+    --table-name pp-user-email \
+    --attribute-definitions \
+        AttributeName=email,AttributeType=S \
+    --key-schema \
+        AttributeName=email,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+# Criando a tabela pp-rate-limit
+aws dynamodb create-table \
+    --table-name pp-rate-limit \
+    --attribute-definitions \
+        AttributeName=key,AttributeType=S \
+    --key-schema \
+        AttributeName=key,KeyType=HASH \
+    --billing-mode PAY_PER_REQUEST \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
+aws dynamodb update-time-to-live \
+    --table-name pp-rate-limit \
+    --time-to-live-specification "Enabled=true, AttributeName=ttl" \
+    --profile=eduardo-admin --region=us-east-1 > /dev/null
+
 echo "Criando Buckets..."
 
 aws s3api create-bucket \
@@ -33,6 +33,10 @@ for i in $(seq 1 $USER_COUNT); do
     USER_EMAIL="user$i@gmail.com"
     USER_NAME="user_$i"
     USER_PASSWORD="123123"
+    USER_ROLE="user"
+    if [ "$i" -eq 1 ]; then
+        USER_ROLE="admin"
+    fi
     USER_PICTURE="http://localhost:4566/pp-user-pictures/perfil$i.png"
     CREATED_AT=$(date -Iseconds)
 
@@ -45,9 +49,16 @@ for i in $(seq 1 $USER_COUNT); do
             \"name\": {\"S\":\"$USER_NAME\"},
             \"password\": {\"S\":\"$USER_PASSWORD\"},
             \"pictureUrl\": {\"S\":\"$USER_PICTURE\"},
+            \"role\": {\"S\":\"$USER_ROLE\"},
+            \"emailVerified\": {\"BOOL\":true},
             \"createdAt\": {\"S\":\"$CREATED_AT\"}
         }" \
         --endpoint-url $DYNAMODB_ENDPOINT > /dev/null
+
+    aws dynamodb put-item \
+        --table-name pp-user-email \
+        --item "{\"email\": {\"S\":\"$USER_EMAIL\"}, \"userId\": {\"S\":\"$i\"}}" \
+        --endpoint-url $DYNAMODB_ENDPOINT > /dev/null
 done
 
 # Criar promoções
@@ -70,6 +81,14 @@ for i in $(seq 1 $PROMOTION_COUNT); do
 
     PLATFORM="Steam"
     CREATED_AT=$(date -Iseconds)
+
+    # Chaves de ordenação dos índices de listagem (popularidade inicial zero)
+    SORT_NEWEST="$(date -u -d "$CREATED_AT" +%Y-%m-%dT%H:%M:%S.000000000Z)#$PROMO_ID"
+    HOT_SCORE=$(awk -v t="$(date -d "$CREATED_AT" +%s)" 'BEGIN { printf "%.6f", t / 45000 }')
+    SORT_DISCOUNT=$(printf "%06.2f#%s" "$DISCOUNT_BADGE" "$SORT_NEWEST")
+    SORT_PRICE=$(printf "%015.2f#%s" "$DISCOUNTED_PRICE" "$SORT_NEWEST")
+    SORT_POPULAR=$(printf "%010d#%s" 0 "$SORT_NEWEST")
+    SORT_HOT=$(printf "%020.6f#%s" "$HOT_SCORE" "$SORT_NEWEST")
     CATEGORY_COUNT=$((RANDOM % 3 + 1))
     CATEGORIES=""
 
@@ -96,7 +115,15 @@ for i in $(seq 1 $PROMOTION_COUNT); do
             \"discountedPrice\": {\"N\":\"$DISCOUNTED_PRICE\"},
             \"discountBadge\": {\"N\":\"$DISCOUNT_BADGE\"},
             \"platform\": {\"S\":\"$PLATFORM\"},
-            \"createdAt\": {\"S\":\"$CREATED_AT\"}
+            \"popularity\": {\"N\":\"0\"},
+            \"hotScore\": {\"N\":\"$HOT_SCORE\"},
+            \"createdAt\": {\"S\":\"$CREATED_AT\"},
+            \"feed\": {\"S\":\"promotion\"},
+            \"sortNewest\": {\"S\":\"$SORT_NEWEST\"},
+            \"sortDiscount\": {\"S\":\"$SORT_DISCOUNT\"},
+            \"sortPrice\": {\"S\":\"$SORT_PRICE\"},
+            \"sortPopular\": {\"S\":\"$SORT_POPULAR\"},
+            \"sortHot\": {\"S\":\"$SORT_HOT\"}
         }" \
         --endpoint-url $DYNAMODB_ENDPOINT > /dev/null
 done